@@ -0,0 +1,131 @@
+package block
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// fileStore keeps block list in memory and writes the whole list to a json file on every change
+type fileStore struct {
+	*memoryStore
+	path string
+}
+
+// fileContent is the json file, every user has sorted list of blocked users and muted conversations
+type fileContent struct {
+	Blocks map[string][]string `json:"blocks"`
+	Mutes  map[string][]string `json:"mutes"`
+}
+
+// OpenFileStore returns store persisted to the json file at path, file is created on first change
+func OpenFileStore(path string) (Store, error) {
+	store := &fileStore{
+		memoryStore: newMemoryStore(),
+		path:        path,
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading block list file: %w", err)
+	}
+
+	var content fileContent
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, fmt.Errorf("error parsing block list file %v: %w", path, err)
+	}
+
+	for user, blocked := range content.Blocks {
+		for _, other := range blocked {
+			store.blocks.add(user, other)
+		}
+	}
+	for user, conversations := range content.Mutes {
+		for _, conversation := range conversations {
+			store.mutes.add(user, conversation)
+		}
+	}
+
+	return store, nil
+}
+
+func (s *fileStore) Block(user, blocked string) error {
+	return s.update(s.blocks.add, user, blocked)
+}
+
+func (s *fileStore) Unblock(user, blocked string) error {
+	return s.update(s.blocks.remove, user, blocked)
+}
+
+func (s *fileStore) Mute(user, conversation string) error {
+	return s.update(s.mutes.add, user, conversation)
+}
+
+func (s *fileStore) Unmute(user, conversation string) error {
+	return s.update(s.mutes.remove, user, conversation)
+}
+
+// update applies the change and saves the file only if the change did something
+func (s *fileStore) update(change func(string, string) bool, user, value string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if !change(user, value) {
+		return nil
+	}
+
+	return s.save()
+}
+
+// save writes the file through a temporary file so that a crash never leaves a partial file
+func (s *fileStore) save() error {
+	content := fileContent{
+		Blocks: sortedSets(s.blocks),
+		Mutes:  sortedSets(s.mutes),
+	}
+
+	data, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("error writing block list file: %w", err)
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(data); err != nil {
+		_ = temp.Close()
+		return fmt.Errorf("error writing block list file: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("error writing block list file: %w", err)
+	}
+
+	if err := os.Rename(temp.Name(), s.path); err != nil {
+		return fmt.Errorf("error writing block list file: %w", err)
+	}
+
+	return nil
+}
+
+func sortedSets(sets userSets) map[string][]string {
+	sorted := make(map[string][]string, len(sets))
+	for user, set := range sets {
+		values := make([]string, 0, len(set))
+		for value := range set {
+			values = append(values, value)
+		}
+		slices.Sort(values)
+		sorted[user] = values
+	}
+
+	return sorted
+}
@@ -0,0 +1,41 @@
+package block
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStoreIsPersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocks.json")
+
+	store, err := OpenFileStore(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Block("bob", "alice"))
+	require.NoError(t, store.Block("bob", "mallory"))
+	require.NoError(t, store.Unblock("bob", "mallory"))
+	require.NoError(t, store.Mute("bob", "carol"))
+
+	reopened, err := OpenFileStore(path)
+	require.NoError(t, err)
+	assert.True(t, reopened.IsBlocked("bob", "alice"))
+	assert.False(t, reopened.IsBlocked("bob", "mallory"))
+	assert.False(t, reopened.IsBlocked("alice", "bob"))
+	assert.True(t, reopened.IsMuted("bob", "carol"))
+	assert.False(t, reopened.IsMuted("carol", "bob"))
+
+	require.NoError(t, reopened.Unmute("bob", "carol"))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"blocks":{"bob":["alice"]},"mutes":{}}`, string(data))
+}
+
+func TestOpenFileStoreRejectsInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocks.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o600))
+
+	_, err := OpenFileStore(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,118 @@
+package block
+
+import (
+	"sync"
+)
+
+// Store keeps users blocked and conversations muted by every user
+//
+// conversation of a direct chat is the username of the other user
+type Store interface {
+	// Block stops user from receiving payloads of blocked
+	Block(user, blocked string) error
+
+	Unblock(user, blocked string) error
+
+	// IsBlocked reports whether user has blocked other
+	IsBlocked(user, other string) bool
+
+	// Mute flags payloads of the conversation delivered to user so that clients suppress notifications
+	Mute(user, conversation string) error
+
+	Unmute(user, conversation string) error
+
+	// IsMuted reports whether user has muted the conversation
+	IsMuted(user, conversation string) bool
+}
+
+// userSets is user -> set of usernames or conversations
+type userSets map[string]map[string]bool
+
+func (sets userSets) add(user, value string) bool {
+	if sets[user][value] {
+		return false
+	}
+
+	if sets[user] == nil {
+		sets[user] = make(map[string]bool)
+	}
+	sets[user][value] = true
+	return true
+}
+
+func (sets userSets) remove(user, value string) bool {
+	if !sets[user][value] {
+		return false
+	}
+
+	delete(sets[user], value)
+	if len(sets[user]) == 0 {
+		delete(sets, user)
+	}
+	return true
+}
+
+// memoryStore keeps block list in memory, it is lost on restart
+type memoryStore struct {
+	sync.RWMutex
+	blocks userSets
+	mutes  userSets
+}
+
+// NewMemoryStore returns store which keeps block list in memory only
+func NewMemoryStore() Store {
+	return newMemoryStore()
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		blocks: make(userSets),
+		mutes:  make(userSets),
+	}
+}
+
+func (s *memoryStore) Block(user, blocked string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.blocks.add(user, blocked)
+	return nil
+}
+
+func (s *memoryStore) Unblock(user, blocked string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.blocks.remove(user, blocked)
+	return nil
+}
+
+func (s *memoryStore) IsBlocked(user, other string) bool {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.blocks[user][other]
+}
+
+func (s *memoryStore) Mute(user, conversation string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.mutes.add(user, conversation)
+	return nil
+}
+
+func (s *memoryStore) Unmute(user, conversation string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.mutes.remove(user, conversation)
+	return nil
+}
+
+func (s *memoryStore) IsMuted(user, conversation string) bool {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.mutes[user][conversation]
+}
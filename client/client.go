@@ -1,6 +1,16 @@
 package client
 
-import "github.com/gorilla/websocket"
+import (
+	"github.com/gorilla/websocket"
+	"time"
+)
+
+// ConnectionInfo is metadata about how and when the client connected
+type ConnectionInfo struct {
+	ConnectedAt time.Time `json:"connectedAt"`
+	RemoteAddr  string    `json:"remoteAddr"`
+	UserAgent   string    `json:"userAgent"`
+}
 
 // Client is the global interface used by payload and hub to store and get clients
 type Client interface {
@@ -9,4 +19,14 @@ type Client interface {
 	WriteToChannel(*[]byte)
 	GetMySubscriptions() map[string]bool
 	AddSubscription(string)
-}
\ No newline at end of file
+	GetConnectionInfo() ConnectionInfo
+
+	// Negotiate sets protocol version and capabilities agreed with the client
+	// returns false if protocol was already negotiated
+	Negotiate(int, []string) bool
+	HasCapability(string) bool
+
+	// Close sends all the queued messages followed by close frame
+	// with the given code and reason and closes the connection
+	Close(int, string)
+}
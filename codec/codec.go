@@ -0,0 +1,69 @@
+package codec
+
+import "github.com/gorilla/websocket"
+
+// Codec converts frames between the client wire format and json
+//
+// json is the format used inside the service, payloads are validated as json and
+// the same json is forwarded to recipients, each recipient codec converts it only when needed
+type Codec interface {
+	// Name is the websocket subprotocol used to negotiate the codec
+	Name() string
+
+	// MessageType is the websocket message type used to send frames
+	MessageType() int
+
+	// ToJson converts frame received from the client to json
+	ToJson([]byte) ([]byte, error)
+
+	// FromJson converts json frame to the client wire format
+	FromJson([]byte) ([]byte, error)
+}
+
+var (
+	Json    Codec = jsonCodec{}
+	MsgPack Codec = msgPackCodec{}
+)
+
+// codecs are in order of server preference
+var codecs = []Codec{Json, MsgPack}
+
+// Subprotocols returns the subprotocols that can be negotiated with the client
+func Subprotocols() []string {
+	subprotocols := make([]string, 0, len(codecs))
+	for _, codec := range codecs {
+		subprotocols = append(subprotocols, codec.Name())
+	}
+
+	return subprotocols
+}
+
+// Get returns codec for the negotiated subprotocol
+// clients which don't ask for any subprotocol use json
+func Get(subprotocol string) Codec {
+	for _, codec := range codecs {
+		if codec.Name() == subprotocol {
+			return codec
+		}
+	}
+
+	return Json
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string {
+	return "doki.json.v1"
+}
+
+func (jsonCodec) MessageType() int {
+	return websocket.TextMessage
+}
+
+func (jsonCodec) ToJson(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+func (jsonCodec) FromJson(data []byte) ([]byte, error) {
+	return data, nil
+}
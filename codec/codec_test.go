@@ -0,0 +1,46 @@
+package codec
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	assert.Equal(t, MsgPack, Get("doki.msgpack.v1"))
+	assert.Equal(t, Json, Get("doki.json.v1"))
+	assert.Equal(t, Json, Get(""))
+	assert.Equal(t, Json, Get("unknown"))
+}
+
+func TestMsgPackRoundTrip(t *testing.T) {
+	data := []byte(`{"type":"poll_votes_update","from":"rohan","pollId":"p1","votes":[1,20,300],"ratio":0.5}`)
+
+	encoded, err := MsgPack.FromJson(data)
+	assert.NoError(t, err)
+	assert.Equal(t, websocket.BinaryMessage, MsgPack.MessageType())
+
+	var decoded struct {
+		Type  string  `msgpack:"type"`
+		Votes []int   `msgpack:"votes"`
+		Ratio float64 `msgpack:"ratio"`
+	}
+	// integers must stay integers for clients decoding into int fields
+	assert.NoError(t, msgpack.Unmarshal(encoded, &decoded))
+	assert.Equal(t, "poll_votes_update", decoded.Type)
+	assert.Equal(t, []int{1, 20, 300}, decoded.Votes)
+	assert.Equal(t, 0.5, decoded.Ratio)
+
+	back, err := MsgPack.ToJson(encoded)
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(data), string(back))
+}
+
+func TestJsonIsPassThrough(t *testing.T) {
+	data := []byte(`{"type":"chat_message"}`)
+
+	encoded, err := Json.FromJson(data)
+	assert.NoError(t, err)
+	assert.Equal(t, &data[0], &encoded[0])
+}
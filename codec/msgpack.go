@@ -0,0 +1,77 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// msgPackCodec sends frames as MessagePack binary messages
+// field names are same as json
+type msgPackCodec struct{}
+
+func (msgPackCodec) Name() string {
+	return "doki.msgpack.v1"
+}
+
+func (msgPackCodec) MessageType() int {
+	return websocket.BinaryMessage
+}
+
+func (msgPackCodec) ToJson(data []byte) ([]byte, error) {
+	var value any
+	if err := msgpack.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(value)
+}
+
+func (msgPackCodec) FromJson(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	encoder := msgpack.NewEncoder(&buffer)
+	// small ints like counts are sent in the smallest msgpack int type
+	encoder.UseCompactInts(true)
+	if err := encoder.Encode(convertNumbers(value)); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// convertNumbers replaces json numbers with int64 or float64
+// so that integers stay integers in msgpack
+func convertNumbers(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+
+	case map[string]any:
+		for key, item := range v {
+			v[key] = convertNumbers(item)
+		}
+		return v
+
+	case []any:
+		for i, item := range v {
+			v[i] = convertNumbers(item)
+		}
+		return v
+
+	default:
+		return v
+	}
+}
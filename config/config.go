@@ -0,0 +1,781 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration is time.Duration that can be written as "30s" in config file
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(duration)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// StringList is comma separated list when given through env or flags
+type StringList []string
+
+func (l *StringList) String() string {
+	if l == nil {
+		return ""
+	}
+
+	return strings.Join(*l, ",")
+}
+
+func (l *StringList) Set(value string) error {
+	*l = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+
+	return nil
+}
+
+// Config contains all the settings that can be tuned per environment
+//
+// settings are loaded in order, each one overriding the previous
+// defaults -> config file -> environment variables -> command line flags
+type Config struct {
+	// Port is where websocket, health and readiness endpoints are served
+	Port string `json:"port" yaml:"port"`
+
+	// AdminPort serves admin api on a separate listener, empty means same as Port
+	AdminPort string `json:"adminPort" yaml:"adminPort"`
+
+	// AdminApiKey is the bearer token for admin api, empty disables admin api
+	AdminApiKey string `json:"adminApiKey" yaml:"adminApiKey"`
+
+	// GrpcPort serves grpc transport, empty disables it
+	GrpcPort string `json:"grpcPort" yaml:"grpcPort"`
+
+	ShutdownTimeout Duration `json:"shutdownTimeout" yaml:"shutdownTimeout"`
+
+	// BlockListFile persists blocked users and muted conversations, empty keeps them in memory only
+	BlockListFile string `json:"blockListFile" yaml:"blockListFile"`
+
+	// MessagePolicy decides who can send direct messages to a user
+	// "anyone", "friends" for friends only or "requests" where others send message requests
+	// friends and requests need RelationsFile so that existing friends are known after a restart
+	MessagePolicy string `json:"messagePolicy" yaml:"messagePolicy"`
+
+	// RelationsFile loads friends and message requests at start and persists their changes
+	// it can be exported by the backend so that friends made before this service are known
+	RelationsFile string `json:"relationsFile" yaml:"relationsFile"`
+
+	// ReactionLimit is max number of different reactions a user can add to a message
+	ReactionLimit int `json:"reactionLimit" yaml:"reactionLimit"`
+
+	Auth      AuthConfig      `json:"auth" yaml:"auth"`
+	Websocket WebsocketConfig `json:"websocket" yaml:"websocket"`
+
+	Push       PushConfig       `json:"push" yaml:"push"`
+	Webhooks   WebhookConfig    `json:"webhooks" yaml:"webhooks"`
+	Moderation ModerationConfig `json:"moderation" yaml:"moderation"`
+
+	// Payloads adds payload types routed by declarative rules, they can only be set in config file
+	Payloads []PayloadConfig `json:"payloads" yaml:"payloads"`
+
+	// RateLimits limits payloads every connection can send by rate limit class of the payload type
+	// classes which are not listed are not limited, they can only be set in config file
+	// file values replace the default of their class, other classes keep their defaults
+	RateLimits map[string]RateLimit `json:"rateLimits" yaml:"rateLimits"`
+}
+
+// RateLimit is a token bucket, Burst payloads can be sent at once and Rate payloads per second after that
+type RateLimit struct {
+	Rate  float64 `json:"rate" yaml:"rate"`
+	Burst int     `json:"burst" yaml:"burst"`
+}
+
+// PushConfig is used to notify users who have no connected resource
+// fcm is enabled by project id and apns by key file, both can be enabled together
+type PushConfig struct {
+	FcmProjectId string `json:"fcmProjectId" yaml:"fcmProjectId"`
+
+	// FcmCredentialsFile is json key of a service account allowed to send messages
+	// access tokens are requested with it and refreshed before they expire
+	FcmCredentialsFile string `json:"fcmCredentialsFile" yaml:"fcmCredentialsFile"`
+
+	// FcmEndpoint and ApnsEndpoint replace the production apis, empty means production
+	FcmEndpoint  string `json:"fcmEndpoint" yaml:"fcmEndpoint"`
+	ApnsEndpoint string `json:"apnsEndpoint" yaml:"apnsEndpoint"`
+
+	// ApnsKeyFile is the .p8 token authentication key with its key id and team id
+	ApnsKeyFile string `json:"apnsKeyFile" yaml:"apnsKeyFile"`
+	ApnsKeyId   string `json:"apnsKeyId" yaml:"apnsKeyId"`
+	ApnsTeamId  string `json:"apnsTeamId" yaml:"apnsTeamId"`
+
+	// ApnsTopic is the bundle id of the app
+	ApnsTopic string `json:"apnsTopic" yaml:"apnsTopic"`
+
+	Timeout Duration `json:"timeout" yaml:"timeout"`
+
+	// Workers send notifications queued up to QueueSize, notifications are dropped when the queue is full
+	Workers   int `json:"workers" yaml:"workers"`
+	QueueSize int `json:"queueSize" yaml:"queueSize"`
+
+	// Templates replace default notification templates by payload type, they can only be set in config file
+	Templates map[string]PushTemplate `json:"templates" yaml:"templates"`
+}
+
+// PushTemplate is text/template of notification using fields of the json payload like {{.from}}
+// When is a boolean field of the payload, notification is sent only when it is true
+type PushTemplate struct {
+	Title       string `json:"title" yaml:"title"`
+	Body        string `json:"body" yaml:"body"`
+	CollapseKey string `json:"collapseKey" yaml:"collapseKey"`
+	When        string `json:"when" yaml:"when"`
+}
+
+// WebhookConfig sends payloads to http endpoints as they flow through the service
+// bodies are signed with hmac sha256 of the secret, failed deliveries are retried with exponential backoff
+type WebhookConfig struct {
+	// Secret signs the bodies of endpoints which have no secret of their own
+	Secret string `json:"secret" yaml:"secret"`
+
+	// Endpoints receive payloads of their types, they can only be set in config file
+	Endpoints []WebhookEndpoint `json:"endpoints" yaml:"endpoints"`
+
+	// QueueSize is the number of deliveries waiting to be sent, payloads are dropped when it is full
+	QueueSize int `json:"queueSize" yaml:"queueSize"`
+	Workers   int `json:"workers" yaml:"workers"`
+
+	// MaxAttempts includes the first attempt, backoff doubles after every failed attempt up to MaxBackoff
+	MaxAttempts    int      `json:"maxAttempts" yaml:"maxAttempts"`
+	InitialBackoff Duration `json:"initialBackoff" yaml:"initialBackoff"`
+	MaxBackoff     Duration `json:"maxBackoff" yaml:"maxBackoff"`
+	Timeout        Duration `json:"timeout" yaml:"timeout"`
+
+	// DeadLetterFile gets a json line for every delivery that failed all attempts, empty only logs them
+	DeadLetterFile string `json:"deadLetterFile" yaml:"deadLetterFile"`
+}
+
+// WebhookEndpoint receives payloads of the given types
+type WebhookEndpoint struct {
+	Url    string   `json:"url" yaml:"url"`
+	Types  []string `json:"types" yaml:"types"`
+	Secret string   `json:"secret" yaml:"secret"`
+}
+
+// ModerationConfig checks text of chat and edit messages before they are routed
+// every check is disabled until it is configured, actions are "redact", "hold" or "reject"
+type ModerationConfig struct {
+	// Words are blocked whole words and Patterns are regular expressions, WordAction is taken on a match
+	Words      StringList `json:"words" yaml:"words"`
+	Patterns   []string   `json:"patterns" yaml:"patterns"`
+	WordAction string     `json:"wordAction" yaml:"wordAction"`
+
+	// LinkAction is taken on links other than to AllowedDomains and their subdomains, empty allows all links
+	LinkAction     string     `json:"linkAction" yaml:"linkAction"`
+	AllowedDomains StringList `json:"allowedDomains" yaml:"allowedDomains"`
+
+	// ClassifierUrl is an http endpoint deciding the action after the filters, see moderation.NewHttpClassifier
+	ClassifierUrl string `json:"classifierUrl" yaml:"classifierUrl"`
+
+	// Timeout is the time allowed for the whole pipeline, message is allowed by the checks left when it is over
+	Timeout Duration `json:"timeout" yaml:"timeout"`
+
+	// user who gets StrikeLimit redacted or rejected messages within StrikeWindow can't send messages for MuteDuration
+	// zero StrikeLimit disables muting
+	StrikeLimit  int      `json:"strikeLimit" yaml:"strikeLimit"`
+	StrikeWindow Duration `json:"strikeWindow" yaml:"strikeWindow"`
+	MuteDuration Duration `json:"muteDuration" yaml:"muteDuration"`
+
+	// ReviewQueueSize is the max number of messages held for review, messages are rejected when it is full
+	ReviewQueueSize int `json:"reviewQueueSize" yaml:"reviewQueueSize"`
+}
+
+// PayloadConfig is a payload type which needs only type and from fields and is routed by its rule
+// Users and Nodes are fields of the payload with recipients, see payload.RoutingRule
+type PayloadConfig struct {
+	Type              string   `json:"type" yaml:"type"`
+	Users             []string `json:"users" yaml:"users"`
+	Nodes             []string `json:"nodes" yaml:"nodes"`
+	When              string   `json:"when" yaml:"when"`
+	EchoToSender      bool     `json:"echoToSender" yaml:"echoToSender"`
+	DropSelfAddressed bool     `json:"dropSelfAddressed" yaml:"dropSelfAddressed"`
+	RateLimitClass    string   `json:"rateLimitClass" yaml:"rateLimitClass"`
+}
+
+// AuthConfig is used to verify cognito id tokens
+type AuthConfig struct {
+	UserPoolID string `json:"userPoolId" yaml:"userPoolId"`
+	Region     string `json:"region" yaml:"region"`
+
+	// JwksURL overrides the url derived from Region and UserPoolID
+	JwksURL string `json:"jwksUrl" yaml:"jwksUrl"`
+}
+
+// WebsocketConfig contains connection timeouts and limits
+type WebsocketConfig struct {
+	PongWait     Duration `json:"pongWait" yaml:"pongWait"`
+	PingInterval Duration `json:"pingInterval" yaml:"pingInterval"`
+	WriteWait    Duration `json:"writeWait" yaml:"writeWait"`
+
+	// IncomingPayloadLimit is max payload in bytes any client can send
+	IncomingPayloadLimit int64 `json:"incomingPayloadLimit" yaml:"incomingPayloadLimit"`
+
+	ReadBufferSize  int `json:"readBufferSize" yaml:"readBufferSize"`
+	WriteBufferSize int `json:"writeBufferSize" yaml:"writeBufferSize"`
+
+	// SendQueueSize is number of messages that can be queued for a client
+	SendQueueSize int `json:"sendQueueSize" yaml:"sendQueueSize"`
+
+	// BatchSize is max queued messages coalesced in a single batch frame when client queue is backed up
+	// only clients declaring batch capability get batches, 0 or 1 disables coalescing
+	BatchSize int `json:"batchSize" yaml:"batchSize"`
+
+	// VerifiedSender adds username verified from the token to metadata of relayed payloads
+	VerifiedSender bool `json:"verifiedSender" yaml:"verifiedSender"`
+
+	// AllowedOrigins are the browser origins allowed to open websocket connection
+	// entry can be exact host "app.doki.co.in", host with scheme "https://app.doki.co.in",
+	// wildcard subdomain "*.doki.co.in" or "*" to allow any origin (only for dev)
+	// empty list allows only same origin requests
+	AllowedOrigins StringList `json:"allowedOrigins" yaml:"allowedOrigins"`
+
+	Compression CompressionConfig `json:"compression" yaml:"compression"`
+
+	// ShutdownReconnectAfter and ShutdownReconnectJitter are used for the reconnect
+	// hint sent to the clients on shutdown
+	ShutdownReconnectAfter  Duration `json:"shutdownReconnectAfter" yaml:"shutdownReconnectAfter"`
+	ShutdownReconnectJitter Duration `json:"shutdownReconnectJitter" yaml:"shutdownReconnectJitter"`
+
+	// Transport is "goroutine" where every connection has its own reader and writer goroutines
+	// or "netpoll" where a worker pool serves connections which are ready (linux only)
+	Transport string `json:"transport" yaml:"transport"`
+
+	// NetpollWorkers is size of netpoll worker pool, 0 uses 8 workers per cpu
+	NetpollWorkers int `json:"netpollWorkers" yaml:"netpollWorkers"`
+
+	// LongPollTimeout is how long a poll of long polling transport waits for messages
+	// session ends if the client doesn't poll again within pong wait
+	LongPollTimeout Duration `json:"longPollTimeout" yaml:"longPollTimeout"`
+}
+
+const (
+	TransportGoroutine = "goroutine"
+	TransportNetpoll   = "netpoll"
+)
+
+const (
+	MessagePolicyAnyone   = "anyone"
+	MessagePolicyFriends  = "friends"
+	MessagePolicyRequests = "requests"
+)
+
+const (
+	ModerationRedact = "redact"
+	ModerationHold   = "hold"
+	ModerationReject = "reject"
+)
+
+// CompressionConfig is used for permessage-deflate negotiated with the client
+type CompressionConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Level is flate compression level from -2 (huffman only) to 9 (best compression)
+	Level int `json:"level" yaml:"level"`
+
+	// Threshold is message size in bytes below which messages are sent uncompressed
+	Threshold int `json:"threshold" yaml:"threshold"`
+}
+
+// Default returns config with the default values
+func Default() *Config {
+	return &Config{
+		Port:            "8080",
+		ShutdownTimeout: Duration(20 * time.Second),
+		MessagePolicy:   MessagePolicyAnyone,
+		ReactionLimit:   3,
+		// limits are per connection and loose enough for any client, they stop flooding rather than shape traffic
+		// keys are the rate limit classes of package payload
+		RateLimits: map[string]RateLimit{
+			"default":      {Rate: 10, Burst: 50},
+			"protocol":     {Rate: 5, Burst: 20},
+			"messaging":    {Rate: 10, Burst: 50},
+			"typing":       {Rate: 2, Burst: 10},
+			"social":       {Rate: 1, Burst: 10},
+			"nodes":        {Rate: 2, Burst: 20},
+			"subscription": {Rate: 5, Burst: 50},
+		},
+		Push: PushConfig{
+			Timeout:   Duration(10 * time.Second),
+			Workers:   8,
+			QueueSize: 1024,
+		},
+		Moderation: ModerationConfig{
+			WordAction:      ModerationRedact,
+			Timeout:         Duration(2 * time.Second),
+			StrikeLimit:     3,
+			StrikeWindow:    Duration(time.Hour),
+			MuteDuration:    Duration(15 * time.Minute),
+			ReviewQueueSize: 1000,
+		},
+		Webhooks: WebhookConfig{
+			QueueSize:      1024,
+			Workers:        4,
+			MaxAttempts:    5,
+			InitialBackoff: Duration(time.Second),
+			MaxBackoff:     Duration(time.Minute),
+			Timeout:        Duration(10 * time.Second),
+		},
+		Websocket: WebsocketConfig{
+			PongWait:             Duration(30 * time.Second),
+			PingInterval:         Duration(27 * time.Second),
+			WriteWait:            Duration(10 * time.Second),
+			IncomingPayloadLimit: 1<<14 + 1024,
+			ReadBufferSize:       1024,
+			WriteBufferSize:      1024,
+			SendQueueSize:        256,
+			BatchSize:            32,
+			Compression: CompressionConfig{
+				Level:     1,
+				Threshold: 512,
+			},
+			ShutdownReconnectAfter:  Duration(5 * time.Second),
+			ShutdownReconnectJitter: Duration(10 * time.Second),
+			Transport:               TransportGoroutine,
+			LongPollTimeout:         Duration(25 * time.Second),
+		},
+	}
+}
+
+// GetJwksURL returns the jwks url of the cognito user pool
+func (c *Config) GetJwksURL() string {
+	if c.Auth.JwksURL != "" {
+		return c.Auth.JwksURL
+	}
+
+	return fmt.Sprintf("https://cognito-idp.%s.amazonaws.com/%s/.well-known/jwks.json", c.Auth.Region, c.Auth.UserPoolID)
+}
+
+// Load creates config from defaults, optional config file, environment and given command line args
+// config file is given by -config flag or CONFIG_FILE env and can be yaml or json
+func Load(args []string) (*Config, error) {
+	// first pass only finds the flags that were set, they are applied after file and env
+	flagConfig := Default()
+	flags := newFlagSet(flagConfig)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "path to yaml or json config file")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	config := Default()
+	if *configFile != "" {
+		if err := config.loadFile(*configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.loadEnv(); err != nil {
+		return nil, err
+	}
+
+	var flagErr error
+	finalFlags := newFlagSet(config)
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "config" {
+			return
+		}
+
+		if err := finalFlags.Set(f.Name, f.Value.String()); err != nil {
+			flagErr = errors.Join(flagErr, err)
+		}
+	})
+	if flagErr != nil {
+		return nil, flagErr
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// loadFile overrides config with values present in the file
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, c)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, c)
+	default:
+		return fmt.Errorf("unsupported config file type: %v", path)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error parsing config file %v: %w", path, err)
+	}
+
+	return nil
+}
+
+// loadEnv overrides config with values present in environment
+func (c *Config) loadEnv() error {
+	var errs []error
+
+	envString := func(key string, target *string) {
+		if value, ok := os.LookupEnv(key); ok && value != "" {
+			*target = value
+		}
+	}
+	envBool := func(key string, target *bool) {
+		if value, ok := os.LookupEnv(key); ok && value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid %v: %w", key, err))
+				return
+			}
+			*target = parsed
+		}
+	}
+	envInt := func(key string, target *int) {
+		if value, ok := os.LookupEnv(key); ok && value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid %v: %w", key, err))
+				return
+			}
+			*target = parsed
+		}
+	}
+	envInt64 := func(key string, target *int64) {
+		if value, ok := os.LookupEnv(key); ok && value != "" {
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid %v: %w", key, err))
+				return
+			}
+			*target = parsed
+		}
+	}
+	envList := func(key string, target *StringList) {
+		if value, ok := os.LookupEnv(key); ok && value != "" {
+			_ = target.Set(value)
+		}
+	}
+	envDuration := func(key string, target *Duration) {
+		if value, ok := os.LookupEnv(key); ok && value != "" {
+			if err := target.UnmarshalText([]byte(value)); err != nil {
+				errs = append(errs, fmt.Errorf("invalid %v: %w", key, err))
+			}
+		}
+	}
+
+	envString("PORT", &c.Port)
+	envString("ADMIN_PORT", &c.AdminPort)
+	envString("ADMIN_API_KEY", &c.AdminApiKey)
+	envString("GRPC_PORT", &c.GrpcPort)
+	envString("BLOCK_LIST_FILE", &c.BlockListFile)
+	envString("MESSAGE_POLICY", &c.MessagePolicy)
+	envString("RELATIONS_FILE", &c.RelationsFile)
+	envInt("REACTION_LIMIT", &c.ReactionLimit)
+	envString("PUSH_FCM_PROJECT_ID", &c.Push.FcmProjectId)
+	envString("PUSH_FCM_CREDENTIALS_FILE", &c.Push.FcmCredentialsFile)
+	envString("PUSH_APNS_KEY_FILE", &c.Push.ApnsKeyFile)
+	envString("PUSH_APNS_KEY_ID", &c.Push.ApnsKeyId)
+	envString("PUSH_APNS_TEAM_ID", &c.Push.ApnsTeamId)
+	envString("PUSH_APNS_TOPIC", &c.Push.ApnsTopic)
+	envString("WEBHOOK_SECRET", &c.Webhooks.Secret)
+	envList("MODERATION_WORDS", &c.Moderation.Words)
+	envString("MODERATION_LINK_ACTION", &c.Moderation.LinkAction)
+	envList("MODERATION_ALLOWED_DOMAINS", &c.Moderation.AllowedDomains)
+	envString("MODERATION_CLASSIFIER_URL", &c.Moderation.ClassifierUrl)
+	envString("WEBHOOK_DEAD_LETTER_FILE", &c.Webhooks.DeadLetterFile)
+	envDuration("SHUTDOWN_TIMEOUT", &c.ShutdownTimeout)
+
+	envString("USER_POOL_ID", &c.Auth.UserPoolID)
+	envString("REGION", &c.Auth.Region)
+	envString("JWKS_URL", &c.Auth.JwksURL)
+
+	envDuration("WS_PONG_WAIT", &c.Websocket.PongWait)
+	envDuration("WS_PING_INTERVAL", &c.Websocket.PingInterval)
+	envDuration("WS_WRITE_WAIT", &c.Websocket.WriteWait)
+	envInt64("WS_INCOMING_PAYLOAD_LIMIT", &c.Websocket.IncomingPayloadLimit)
+	envInt("WS_READ_BUFFER_SIZE", &c.Websocket.ReadBufferSize)
+	envInt("WS_WRITE_BUFFER_SIZE", &c.Websocket.WriteBufferSize)
+	envInt("WS_SEND_QUEUE_SIZE", &c.Websocket.SendQueueSize)
+	envInt("WS_BATCH_SIZE", &c.Websocket.BatchSize)
+	envBool("WS_VERIFIED_SENDER", &c.Websocket.VerifiedSender)
+	envList("WS_ALLOWED_ORIGINS", &c.Websocket.AllowedOrigins)
+	envBool("WS_COMPRESSION_ENABLED", &c.Websocket.Compression.Enabled)
+	envInt("WS_COMPRESSION_LEVEL", &c.Websocket.Compression.Level)
+	envInt("WS_COMPRESSION_THRESHOLD", &c.Websocket.Compression.Threshold)
+	envDuration("WS_SHUTDOWN_RECONNECT_AFTER", &c.Websocket.ShutdownReconnectAfter)
+	envDuration("WS_SHUTDOWN_RECONNECT_JITTER", &c.Websocket.ShutdownReconnectJitter)
+	envString("WS_TRANSPORT", &c.Websocket.Transport)
+	envInt("WS_NETPOLL_WORKERS", &c.Websocket.NetpollWorkers)
+	envDuration("WS_LONG_POLL_TIMEOUT", &c.Websocket.LongPollTimeout)
+
+	return errors.Join(errs...)
+}
+
+// newFlagSet creates command line flags bound to the given config
+func newFlagSet(c *Config) *flag.FlagSet {
+	flags := flag.NewFlagSet("doki_real_time_service", flag.ContinueOnError)
+
+	flags.StringVar(&c.Port, "port", c.Port, "port for websocket and health endpoints")
+	flags.StringVar(&c.AdminPort, "admin-port", c.AdminPort, "separate port for admin api")
+	flags.StringVar(&c.AdminApiKey, "admin-api-key", c.AdminApiKey, "bearer token for admin api")
+	flags.StringVar(&c.GrpcPort, "grpc-port", c.GrpcPort, "port for grpc transport")
+	flags.StringVar(&c.BlockListFile, "block-list-file", c.BlockListFile, "json file to persist blocked users and muted conversations")
+	flags.StringVar(&c.MessagePolicy, "message-policy", c.MessagePolicy, "who can send direct messages, anyone, friends or requests")
+	flags.StringVar(&c.RelationsFile, "relations-file", c.RelationsFile, "json file with friends and message requests, required by friends and requests message policies")
+	flags.IntVar(&c.ReactionLimit, "reaction-limit", c.ReactionLimit, "max reactions a user can add to a message")
+	flags.StringVar(&c.Push.FcmProjectId, "push-fcm-project-id", c.Push.FcmProjectId, "firebase project to send push notifications, empty disables fcm")
+	flags.StringVar(&c.Push.FcmCredentialsFile, "push-fcm-credentials-file", c.Push.FcmCredentialsFile, "service account json key to send fcm push notifications")
+	flags.StringVar(&c.Push.ApnsKeyFile, "push-apns-key-file", c.Push.ApnsKeyFile, "apns .p8 key file to send push notifications, empty disables apns")
+	flags.StringVar(&c.Push.ApnsKeyId, "push-apns-key-id", c.Push.ApnsKeyId, "key id of the apns key")
+	flags.StringVar(&c.Push.ApnsTeamId, "push-apns-team-id", c.Push.ApnsTeamId, "team id of the apns key")
+	flags.StringVar(&c.Push.ApnsTopic, "push-apns-topic", c.Push.ApnsTopic, "bundle id of the app for apns")
+	flags.Var(&c.Moderation.Words, "moderation-words", "comma separated words blocked in messages")
+	flags.StringVar(&c.Moderation.LinkAction, "moderation-link-action", c.Moderation.LinkAction, "action on links in messages, redact, hold or reject, empty allows links")
+	flags.StringVar(&c.Moderation.ClassifierUrl, "moderation-classifier-url", c.Moderation.ClassifierUrl, "http classifier checking messages, empty disables it")
+	flags.StringVar(&c.Webhooks.DeadLetterFile, "webhook-dead-letter-file", c.Webhooks.DeadLetterFile, "json lines file for webhook deliveries that failed all attempts")
+	flags.TextVar(&c.ShutdownTimeout, "shutdown-timeout", c.ShutdownTimeout, "time given to clients to drain on shutdown")
+
+	flags.StringVar(&c.Auth.UserPoolID, "user-pool-id", c.Auth.UserPoolID, "cognito user pool id")
+	flags.StringVar(&c.Auth.Region, "region", c.Auth.Region, "cognito user pool region")
+	flags.StringVar(&c.Auth.JwksURL, "jwks-url", c.Auth.JwksURL, "jwks url overriding the cognito url")
+
+	flags.TextVar(&c.Websocket.PongWait, "ws-pong-wait", c.Websocket.PongWait, "time to wait for pong from client")
+	flags.TextVar(&c.Websocket.PingInterval, "ws-ping-interval", c.Websocket.PingInterval, "interval between pings")
+	flags.TextVar(&c.Websocket.WriteWait, "ws-write-wait", c.Websocket.WriteWait, "time allowed to write a message")
+	flags.Int64Var(&c.Websocket.IncomingPayloadLimit, "ws-incoming-payload-limit", c.Websocket.IncomingPayloadLimit, "max payload size in bytes")
+	flags.IntVar(&c.Websocket.ReadBufferSize, "ws-read-buffer-size", c.Websocket.ReadBufferSize, "websocket read buffer size")
+	flags.IntVar(&c.Websocket.WriteBufferSize, "ws-write-buffer-size", c.Websocket.WriteBufferSize, "websocket write buffer size")
+	flags.IntVar(&c.Websocket.SendQueueSize, "ws-send-queue-size", c.Websocket.SendQueueSize, "messages queued per client")
+	flags.IntVar(&c.Websocket.BatchSize, "ws-batch-size", c.Websocket.BatchSize, "max queued messages coalesced in a batch frame")
+	flags.BoolVar(&c.Websocket.VerifiedSender, "ws-verified-sender", c.Websocket.VerifiedSender, "add verified sender username to payload metadata")
+	flags.Var(&c.Websocket.AllowedOrigins, "ws-allowed-origins", "comma separated origins allowed to connect")
+	flags.BoolVar(&c.Websocket.Compression.Enabled, "ws-compression", c.Websocket.Compression.Enabled, "negotiate permessage-deflate")
+	flags.IntVar(&c.Websocket.Compression.Level, "ws-compression-level", c.Websocket.Compression.Level, "flate compression level")
+	flags.IntVar(&c.Websocket.Compression.Threshold, "ws-compression-threshold", c.Websocket.Compression.Threshold, "min message size to compress")
+	flags.TextVar(&c.Websocket.ShutdownReconnectAfter, "ws-shutdown-reconnect-after", c.Websocket.ShutdownReconnectAfter, "min reconnect hint on shutdown")
+	flags.TextVar(&c.Websocket.ShutdownReconnectJitter, "ws-shutdown-reconnect-jitter", c.Websocket.ShutdownReconnectJitter, "random jitter added to reconnect hint")
+	flags.StringVar(&c.Websocket.Transport, "ws-transport", c.Websocket.Transport, "connection transport, goroutine or netpoll")
+	flags.IntVar(&c.Websocket.NetpollWorkers, "ws-netpoll-workers", c.Websocket.NetpollWorkers, "netpoll worker pool size")
+	flags.TextVar(&c.Websocket.LongPollTimeout, "ws-long-poll-timeout", c.Websocket.LongPollTimeout, "time a long poll waits for messages")
+
+	return flags
+}
+
+// Validate checks all the settings and returns every problem found
+func (c *Config) Validate() error {
+	var errs []error
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("port must be between 1 and 65535, got %q", c.Port))
+	}
+	if c.AdminPort != "" {
+		if port, err := strconv.Atoi(c.AdminPort); err != nil || port < 1 || port > 65535 {
+			errs = append(errs, fmt.Errorf("adminPort must be between 1 and 65535, got %q", c.AdminPort))
+		} else if c.AdminPort == c.Port {
+			errs = append(errs, errors.New("adminPort must be different from port"))
+		}
+	}
+	if c.GrpcPort != "" {
+		if port, err := strconv.Atoi(c.GrpcPort); err != nil || port < 1 || port > 65535 {
+			errs = append(errs, fmt.Errorf("grpcPort must be between 1 and 65535, got %q", c.GrpcPort))
+		} else if c.GrpcPort == c.Port || c.GrpcPort == c.AdminPort {
+			errs = append(errs, errors.New("grpcPort must be different from port and adminPort"))
+		}
+	}
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("shutdownTimeout must be positive"))
+	}
+	switch c.MessagePolicy {
+	case MessagePolicyAnyone:
+	case MessagePolicyFriends, MessagePolicyRequests:
+		// relations seen by this process alone would drop messages between existing friends
+		if c.RelationsFile == "" {
+			errs = append(errs, fmt.Errorf("relationsFile is required when messagePolicy is %q", c.MessagePolicy))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("messagePolicy must be %q, %q or %q, got %q", MessagePolicyAnyone, MessagePolicyFriends, MessagePolicyRequests, c.MessagePolicy))
+	}
+	if c.ReactionLimit <= 0 {
+		errs = append(errs, errors.New("reactionLimit must be positive"))
+	}
+
+	if c.Auth.JwksURL == "" && (c.Auth.UserPoolID == "" || c.Auth.Region == "") {
+		errs = append(errs, errors.New("auth.userPoolId and auth.region are required when auth.jwksUrl is not set"))
+	}
+
+	ws := c.Websocket
+	if ws.PongWait <= 0 {
+		errs = append(errs, errors.New("websocket.pongWait must be positive"))
+	}
+	if ws.PingInterval <= 0 || ws.PingInterval >= ws.PongWait {
+		errs = append(errs, errors.New("websocket.pingInterval must be positive and less than websocket.pongWait"))
+	}
+	if ws.WriteWait <= 0 {
+		errs = append(errs, errors.New("websocket.writeWait must be positive"))
+	}
+	if ws.IncomingPayloadLimit <= 0 {
+		errs = append(errs, errors.New("websocket.incomingPayloadLimit must be positive"))
+	}
+	if ws.ReadBufferSize <= 0 || ws.WriteBufferSize <= 0 {
+		errs = append(errs, errors.New("websocket buffer sizes must be positive"))
+	}
+	if ws.SendQueueSize <= 0 {
+		errs = append(errs, errors.New("websocket.sendQueueSize must be positive"))
+	}
+	if ws.BatchSize < 0 {
+		errs = append(errs, errors.New("websocket.batchSize can't be negative"))
+	}
+	for _, origin := range ws.AllowedOrigins {
+		if origin != "*" && strings.Contains(strings.TrimPrefix(origin, "*."), "*") {
+			errs = append(errs, fmt.Errorf("websocket.allowedOrigins entry %q can only use * as whole entry or subdomain prefix", origin))
+		}
+	}
+	if ws.Compression.Level < -2 || ws.Compression.Level > 9 {
+		errs = append(errs, errors.New("websocket.compression.level must be between -2 and 9"))
+	}
+	if ws.Compression.Threshold < 0 {
+		errs = append(errs, errors.New("websocket.compression.threshold can't be negative"))
+	}
+	if ws.ShutdownReconnectAfter < 0 || ws.ShutdownReconnectJitter < 0 {
+		errs = append(errs, errors.New("websocket shutdown reconnect hints can't be negative"))
+	}
+	switch ws.Transport {
+	case TransportGoroutine:
+	case TransportNetpoll:
+		if runtime.GOOS != "linux" {
+			errs = append(errs, fmt.Errorf("websocket.transport %q is only supported on linux", ws.Transport))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("websocket.transport must be %q or %q, got %q", TransportGoroutine, TransportNetpoll, ws.Transport))
+	}
+	if ws.NetpollWorkers < 0 {
+		errs = append(errs, errors.New("websocket.netpollWorkers can't be negative"))
+	}
+	if ws.LongPollTimeout <= 0 {
+		errs = append(errs, errors.New("websocket.longPollTimeout must be positive"))
+	}
+
+	if c.Push.FcmProjectId != "" && c.Push.FcmCredentialsFile == "" {
+		errs = append(errs, errors.New("push.fcmCredentialsFile is required when push.fcmProjectId is set"))
+	}
+	if c.Push.ApnsKeyFile != "" && (c.Push.ApnsKeyId == "" || c.Push.ApnsTeamId == "" || c.Push.ApnsTopic == "") {
+		errs = append(errs, errors.New("push.apnsKeyId, push.apnsTeamId and push.apnsTopic are required when push.apnsKeyFile is set"))
+	}
+	if c.Push.Timeout <= 0 {
+		errs = append(errs, errors.New("push.timeout must be positive"))
+	}
+	if c.Push.Workers <= 0 || c.Push.QueueSize <= 0 {
+		errs = append(errs, errors.New("push.workers and push.queueSize must be positive"))
+	}
+
+	webhooks := c.Webhooks
+	for index, endpoint := range webhooks.Endpoints {
+		if parsed, err := url.Parse(endpoint.Url); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			errs = append(errs, fmt.Errorf("webhooks.endpoints[%d].url must be an http or https url", index))
+		}
+		if len(endpoint.Types) == 0 {
+			errs = append(errs, fmt.Errorf("webhooks.endpoints[%d].types is required", index))
+		}
+		if endpoint.Secret == "" && webhooks.Secret == "" {
+			errs = append(errs, fmt.Errorf("webhooks.endpoints[%d] has no secret", index))
+		}
+	}
+	if webhooks.QueueSize <= 0 || webhooks.Workers <= 0 {
+		errs = append(errs, errors.New("webhooks.queueSize and webhooks.workers must be positive"))
+	}
+	if webhooks.MaxAttempts <= 0 {
+		errs = append(errs, errors.New("webhooks.maxAttempts must be positive"))
+	}
+	if webhooks.InitialBackoff <= 0 || webhooks.MaxBackoff < webhooks.InitialBackoff {
+		errs = append(errs, errors.New("webhooks.initialBackoff must be positive and not more than webhooks.maxBackoff"))
+	}
+	if webhooks.Timeout <= 0 {
+		errs = append(errs, errors.New("webhooks.timeout must be positive"))
+	}
+
+	moderation := c.Moderation
+	if !isModerationAction(moderation.WordAction) {
+		errs = append(errs, fmt.Errorf("moderation.wordAction must be %q, %q or %q, got %q", ModerationRedact, ModerationHold, ModerationReject, moderation.WordAction))
+	}
+	if moderation.LinkAction != "" && !isModerationAction(moderation.LinkAction) {
+		errs = append(errs, fmt.Errorf("moderation.linkAction must be empty, %q, %q or %q, got %q", ModerationRedact, ModerationHold, ModerationReject, moderation.LinkAction))
+	}
+	if moderation.ClassifierUrl != "" {
+		if parsed, err := url.Parse(moderation.ClassifierUrl); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			errs = append(errs, errors.New("moderation.classifierUrl must be an http or https url"))
+		}
+	}
+	if moderation.Timeout <= 0 {
+		errs = append(errs, errors.New("moderation.timeout must be positive"))
+	}
+	if moderation.StrikeLimit < 0 {
+		errs = append(errs, errors.New("moderation.strikeLimit can't be negative"))
+	} else if moderation.StrikeLimit > 0 && (moderation.StrikeWindow <= 0 || moderation.MuteDuration <= 0) {
+		errs = append(errs, errors.New("moderation.strikeWindow and moderation.muteDuration must be positive"))
+	}
+	if moderation.ReviewQueueSize <= 0 {
+		errs = append(errs, errors.New("moderation.reviewQueueSize must be positive"))
+	}
+
+	for _, class := range slices.Sorted(maps.Keys(c.RateLimits)) {
+		limit := c.RateLimits[class]
+		if limit.Rate <= 0 || limit.Burst <= 0 {
+			errs = append(errs, fmt.Errorf("rateLimits.%s rate and burst must be positive", class))
+		}
+	}
+
+	payloadTypes := make(map[string]bool)
+	for index, p := range c.Payloads {
+		if p.Type == "" {
+			errs = append(errs, fmt.Errorf("payloads[%d].type is required", index))
+		} else if payloadTypes[p.Type] {
+			errs = append(errs, fmt.Errorf("payloads[%d].type %q is repeated", index, p.Type))
+		}
+		payloadTypes[p.Type] = true
+
+		if len(p.Users) == 0 && len(p.Nodes) == 0 && !p.EchoToSender {
+			errs = append(errs, fmt.Errorf("payloads[%d] has no recipients", index))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+}
+
+func isModerationAction(action string) bool {
+	return action == ModerationRedact || action == ModerationHold || action == ModerationReject
+}
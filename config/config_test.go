@@ -0,0 +1,160 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	content := []byte("port: \"9000\"\nauth:\n  jwksUrl: https://example.com/jwks.json\nwebsocket:\n  pongWait: 60s\n  pingInterval: 50s\n  sendQueueSize: 10\n")
+	assert.NoError(t, os.WriteFile(file, content, 0o600))
+
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("PORT", "9001")
+	t.Setenv("WS_SEND_QUEUE_SIZE", "20")
+
+	config, err := Load([]string{"-port", "9002"})
+	assert.NoError(t, err)
+
+	// flag wins over env which wins over file
+	assert.Equal(t, "9002", config.Port)
+	assert.Equal(t, 20, config.Websocket.SendQueueSize)
+	assert.Equal(t, Duration(60*time.Second), config.Websocket.PongWait)
+	assert.Equal(t, "https://example.com/jwks.json", config.GetJwksURL())
+
+	// untouched values keep their defaults
+	assert.Equal(t, Default().Websocket.WriteWait, config.Websocket.WriteWait)
+}
+
+func TestLoadJsonFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	content := []byte(`{"auth": {"region": "ap-south-1", "userPoolId": "pool"}, "websocket": {"writeWait": "5s"}}`)
+	assert.NoError(t, os.WriteFile(file, content, 0o600))
+
+	config, err := Load([]string{"-config", file})
+	assert.NoError(t, err)
+	assert.Equal(t, Duration(5*time.Second), config.Websocket.WriteWait)
+	assert.Equal(t, "https://cognito-idp.ap-south-1.amazonaws.com/pool/.well-known/jwks.json", config.GetJwksURL())
+}
+
+func TestValidate(t *testing.T) {
+	config := Default()
+	config.Port = "abc"
+	config.Websocket.PingInterval = config.Websocket.PongWait
+	config.MessagePolicy = "strangers"
+	config.ReactionLimit = 0
+	config.Push.FcmProjectId = "doki"
+	config.Push.Workers = 0
+
+	err := config.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "port must be between")
+	assert.Contains(t, err.Error(), "pingInterval")
+	assert.Contains(t, err.Error(), "auth.userPoolId")
+	assert.Contains(t, err.Error(), "messagePolicy")
+	assert.Contains(t, err.Error(), "reactionLimit")
+	assert.Contains(t, err.Error(), "push.fcmCredentialsFile")
+	assert.Contains(t, err.Error(), "push.workers")
+}
+
+func TestValidateMessagePolicy(t *testing.T) {
+	config := Default()
+	config.Auth.JwksURL = "https://example.com/jwks.json"
+	assert.NoError(t, config.Validate())
+
+	// relations seen by this process alone would not know existing friends
+	for _, policy := range []string{MessagePolicyFriends, MessagePolicyRequests} {
+		config.MessagePolicy = policy
+		config.RelationsFile = ""
+		err := config.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "relationsFile")
+
+		config.RelationsFile = "relations.json"
+		assert.NoError(t, config.Validate())
+	}
+}
+
+func TestLoadPayloads(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	content := []byte("auth:\n  jwksUrl: https://example.com/jwks.json\npayloads:\n  - type: poke\n    users: [to]\n    echoToSender: true\n  - type: poke\n  - users: [to]\n")
+	assert.NoError(t, os.WriteFile(file, content, 0o600))
+
+	_, err := Load([]string{"-config", file})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `payloads[1].type "poke" is repeated`)
+	assert.Contains(t, err.Error(), "payloads[1] has no recipients")
+	assert.Contains(t, err.Error(), "payloads[2].type is required")
+
+	content = []byte("auth:\n  jwksUrl: https://example.com/jwks.json\npayloads:\n  - type: poke\n    users: [to]\n    echoToSender: true\n")
+	assert.NoError(t, os.WriteFile(file, content, 0o600))
+
+	config, err := Load([]string{"-config", file})
+	assert.NoError(t, err)
+	assert.Equal(t, []PayloadConfig{{Type: "poke", Users: []string{"to"}, EchoToSender: true}}, config.Payloads)
+}
+
+func TestLoadRateLimits(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	content := []byte("auth:\n  jwksUrl: https://example.com/jwks.json\nrateLimits:\n  typing:\n    rate: 0\n    burst: 5\n")
+	assert.NoError(t, os.WriteFile(file, content, 0o600))
+
+	_, err := Load([]string{"-config", file})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "rateLimits.typing rate and burst must be positive")
+
+	content = []byte("auth:\n  jwksUrl: https://example.com/jwks.json\nrateLimits:\n  messaging:\n    rate: 0.5\n    burst: 10\n")
+	assert.NoError(t, os.WriteFile(file, content, 0o600))
+
+	config, err := Load([]string{"-config", file})
+	assert.NoError(t, err)
+	assert.Equal(t, RateLimit{Rate: 0.5, Burst: 10}, config.RateLimits["messaging"])
+	assert.Equal(t, Default().RateLimits["typing"], config.RateLimits["typing"])
+}
+
+func TestLoadWebhooks(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	content := []byte("auth:\n  jwksUrl: https://example.com/jwks.json\nwebhooks:\n  maxAttempts: 0\n  endpoints:\n    - url: ftp://example.com\n      types: [chat_message]\n")
+	assert.NoError(t, os.WriteFile(file, content, 0o600))
+
+	_, err := Load([]string{"-config", file})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "webhooks.endpoints[0].url")
+	assert.Contains(t, err.Error(), "webhooks.endpoints[0] has no secret")
+	assert.Contains(t, err.Error(), "webhooks.maxAttempts")
+
+	content = []byte("auth:\n  jwksUrl: https://example.com/jwks.json\nwebhooks:\n  initialBackoff: 2s\n  endpoints:\n    - url: https://example.com/events\n      types: [chat_message]\n")
+	assert.NoError(t, os.WriteFile(file, content, 0o600))
+	t.Setenv("WEBHOOK_SECRET", "secret")
+
+	config, err := Load([]string{"-config", file})
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", config.Webhooks.Secret)
+	assert.Equal(t, Duration(2*time.Second), config.Webhooks.InitialBackoff)
+	assert.Equal(t, []WebhookEndpoint{{Url: "https://example.com/events", Types: []string{"chat_message"}}}, config.Webhooks.Endpoints)
+}
+
+func TestValidateModeration(t *testing.T) {
+	config := Default()
+	config.Auth.JwksURL = "https://example.com/jwks.json"
+	config.Moderation.WordAction = "delete"
+	config.Moderation.LinkAction = "hide"
+	config.Moderation.ClassifierUrl = "classifier"
+	config.Moderation.StrikeWindow = 0
+
+	err := config.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "moderation.wordAction")
+	assert.Contains(t, err.Error(), "moderation.linkAction")
+	assert.Contains(t, err.Error(), "moderation.classifierUrl")
+	assert.Contains(t, err.Error(), "moderation.strikeWindow")
+
+	config.Moderation = Default().Moderation
+	config.Moderation.LinkAction = ModerationHold
+	assert.NoError(t, config.Validate())
+}
@@ -0,0 +1,267 @@
+package hub
+
+import (
+	"crypto/subtle"
+	"doki.co.in/doki_real_time_service/client"
+	"doki.co.in/doki_real_time_service/payload"
+	"doki.co.in/doki_real_time_service/utils"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// adminDisconnectReason is used when admin doesn't provide any reason
+	adminDisconnectReason = "disconnected by admin"
+
+	// maxCloseReasonLength is the max reason length allowed in websocket close frame
+	maxCloseReasonLength = 123
+)
+
+// resourceInfo is the admin view of a single connected resource
+type resourceInfo struct {
+	Resource          string `json:"resource"`
+	SubscriptionCount int    `json:"subscriptionCount"`
+	client.ConnectionInfo
+}
+
+// subscriberInfo is the admin view of a node subscriber
+type subscriberInfo struct {
+	User      string `json:"user"`
+	Connected bool   `json:"connected"`
+}
+
+// hubStats is the aggregate view of the hub
+type hubStats struct {
+	Users           int       `json:"users"`
+	Connections     int       `json:"connections"`
+	Nodes           int       `json:"nodes"`
+	Subscriptions   int       `json:"subscriptions"`
+	StartedAt       time.Time `json:"startedAt"`
+	ShuttingDown    bool      `json:"shuttingDown"`
+	RejectedOrigins int64     `json:"rejectedOrigins"`
+}
+
+// AdminHandler returns http handler for the admin api
+// every request must have authorization header with the configured api key as bearer token
+// empty api key disables the admin api
+func (h *Hub) AdminHandler() http.Handler {
+	apiKey := h.config.AdminApiKey
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/users/{username}/resources", h.listResources)
+	mux.HandleFunc("DELETE /admin/users/{username}/resources", h.disconnectUser)
+	mux.HandleFunc("DELETE /admin/users/{username}/resources/{resource}", h.disconnectResource)
+	mux.HandleFunc("GET /admin/nodes/{nodeId}/subscribers", h.listSubscribers)
+	mux.HandleFunc("GET /admin/stats", h.stats)
+	mux.HandleFunc("GET /admin/payloads", h.listPayloads)
+	mux.HandleFunc("GET /admin/moderation/held", h.listHeld)
+	mux.HandleFunc("POST /admin/moderation/held/{id}/release", h.releaseHeld)
+	mux.HandleFunc("DELETE /admin/moderation/held/{id}", h.discardHeld)
+	mux.HandleFunc("DELETE /admin/moderation/mutes/{username}", h.unmuteUser)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := parseAdminAuthHeader(r, apiKey); err != nil {
+			http.Error(w, err.Error(), err.Code)
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// parseAdminAuthHeader checks that request carries the admin api key
+func parseAdminAuthHeader(r *http.Request, apiKey string) *authError {
+	if apiKey == "" {
+		return &authError{
+			Code:   http.StatusNotFound,
+			Reason: http.StatusText(http.StatusNotFound),
+		}
+	}
+
+	authArray := strings.Split(r.Header.Get("Authorization"), " ")
+	if len(authArray) != 2 || strings.ToLower(authArray[0]) != "bearer" {
+		return &authError{
+			Code:   http.StatusUnauthorized,
+			Reason: "invalid auth header provided",
+		}
+	}
+
+	if subtle.ConstantTimeCompare([]byte(authArray[1]), []byte(apiKey)) != 1 {
+		return &authError{
+			Code:   http.StatusForbidden,
+			Reason: "invalid api key",
+		}
+	}
+
+	return nil
+}
+
+// ConnectedResources returns snapshot of all the connected clients of the user
+// keyed by resource
+func (h *Hub) ConnectedResources(username string) map[string]client.Client {
+	h.RLock()
+	defer h.RUnlock()
+
+	resources := make(map[string]client.Client, len(h.clients[username]))
+	for resource, conn := range h.clients[username] {
+		resources[resource] = conn
+	}
+
+	return resources
+}
+
+// DisconnectResource closes connection of the complete user with the given reason
+// returns false if user is not connected
+func (h *Hub) DisconnectResource(user string, reason string) bool {
+	conn := h.GetIndividualClient(user)
+
+	if conn == nil {
+		return false
+	}
+
+	conn.Close(websocket.ClosePolicyViolation, closeReason(reason))
+	return true
+}
+
+// DisconnectUser closes all the connections of the user with the given reason
+// returns number of connections closed
+func (h *Hub) DisconnectUser(username string, reason string) int {
+	resources := h.ConnectedResources(username)
+	for _, conn := range resources {
+		conn.Close(websocket.ClosePolicyViolation, closeReason(reason))
+	}
+
+	return len(resources)
+}
+
+// subscriptionCount returns number of nodes complete user has subscribed to
+func (h *Hub) subscriptionCount(completeUser string) int {
+	h.subscription.RLock()
+	defer h.subscription.RUnlock()
+
+	count := 0
+	for _, nodeSubscribers := range h.subscription.subscriptions {
+		if nodeSubscribers[completeUser] {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (h *Hub) listResources(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("username")
+
+	resources := make([]resourceInfo, 0)
+	for resource, conn := range h.ConnectedResources(username) {
+		completeUser := utils.CreateUserFromUsernameAndResource(username, resource)
+		resources = append(resources, resourceInfo{
+			Resource:          resource,
+			SubscriptionCount: h.subscriptionCount(completeUser),
+			ConnectionInfo:    conn.GetConnectionInfo(),
+		})
+	}
+
+	writeJson(w, http.StatusOK, map[string]any{
+		"username":  username,
+		"connected": len(resources) > 0,
+		"resources": resources,
+	})
+}
+
+func (h *Hub) listSubscribers(w http.ResponseWriter, r *http.Request) {
+	nodeId := r.PathValue("nodeId")
+
+	h.subscription.RLock()
+	subscribed := make([]string, 0, len(h.subscription.subscriptions[nodeId]))
+	for completeUser := range h.subscription.subscriptions[nodeId] {
+		subscribed = append(subscribed, completeUser)
+	}
+	h.subscription.RUnlock()
+
+	subscriberList := make([]subscriberInfo, 0, len(subscribed))
+	for _, completeUser := range subscribed {
+		subscriberList = append(subscriberList, subscriberInfo{
+			User:      completeUser,
+			Connected: h.GetIndividualClient(completeUser) != nil,
+		})
+	}
+
+	writeJson(w, http.StatusOK, map[string]any{
+		"nodeId":      nodeId,
+		"subscribers": subscriberList,
+	})
+}
+
+func (h *Hub) disconnectResource(w http.ResponseWriter, r *http.Request) {
+	user := utils.CreateUserFromUsernameAndResource(r.PathValue("username"), r.PathValue("resource"))
+
+	if !h.DisconnectResource(user, r.URL.Query().Get("reason")) {
+		http.Error(w, "resource not connected", http.StatusNotFound)
+		return
+	}
+
+	writeJson(w, http.StatusOK, map[string]any{
+		"disconnected": 1,
+	})
+}
+
+func (h *Hub) disconnectUser(w http.ResponseWriter, r *http.Request) {
+	disconnected := h.DisconnectUser(r.PathValue("username"), r.URL.Query().Get("reason"))
+
+	writeJson(w, http.StatusOK, map[string]any{
+		"disconnected": disconnected,
+	})
+}
+
+func (h *Hub) stats(w http.ResponseWriter, _ *http.Request) {
+	stats := hubStats{
+		StartedAt:       h.startedAt,
+		ShuttingDown:    h.shuttingDown.Load(),
+		RejectedOrigins: h.rejectedOrigins.Load(),
+	}
+
+	h.RLock()
+	stats.Users = len(h.clients)
+	for _, resources := range h.clients {
+		stats.Connections += len(resources)
+	}
+	h.RUnlock()
+
+	h.subscription.RLock()
+	stats.Nodes = len(h.subscription.subscriptions)
+	for _, nodeSubscribers := range h.subscription.subscriptions {
+		stats.Subscriptions += len(nodeSubscribers)
+	}
+	h.subscription.RUnlock()
+
+	writeJson(w, http.StatusOK, stats)
+}
+
+// listPayloads returns every payload type registered in the payload package
+func (h *Hub) listPayloads(w http.ResponseWriter, _ *http.Request) {
+	writeJson(w, http.StatusOK, payload.Registered())
+}
+
+// closeReason returns reason that fits in the websocket close frame
+func closeReason(reason string) string {
+	if reason == "" {
+		return adminDisconnectReason
+	}
+
+	if len(reason) > maxCloseReasonLength {
+		return strings.ToValidUTF8(reason[:maxCloseReasonLength], "")
+	}
+
+	return reason
+}
+
+// writeJson writes the given value as json response
+func writeJson(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,128 @@
+package hub
+
+import (
+	"doki.co.in/doki_real_time_service/client"
+	"doki.co.in/doki_real_time_service/config"
+	"doki.co.in/doki_real_time_service/utils"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+const testAdminKey = "admin-key"
+
+type fakeClient struct {
+	user       string
+	written    [][]byte
+	closedWith *closeFrame
+
+	// capabilities are declared by the client, none by default
+	capabilities []string
+}
+
+func (f *fakeClient) GetConnection() *websocket.Conn { return nil }
+
+func (f *fakeClient) GetUserInfo() (string, string) {
+	return utils.GetUsernameAndResourceFromUser(f.user)
+}
+
+func (f *fakeClient) WriteToChannel(data *[]byte) { f.written = append(f.written, *data) }
+
+func (f *fakeClient) GetMySubscriptions() map[string]bool { return nil }
+
+func (f *fakeClient) AddSubscription(string) {}
+
+func (f *fakeClient) GetConnectionInfo() client.ConnectionInfo {
+	return client.ConnectionInfo{ConnectedAt: time.Unix(0, 0), RemoteAddr: "10.0.0.1:1234", UserAgent: "doki-test"}
+}
+
+func (f *fakeClient) Negotiate(int, []string) bool { return true }
+
+func (f *fakeClient) HasCapability(capability string) bool {
+	return slices.Contains(f.capabilities, capability)
+}
+
+func (f *fakeClient) Close(code int, reason string) {
+	f.closedWith = &closeFrame{code: code, reason: reason}
+}
+
+func createTestHub() *Hub {
+	testConfig := config.Default()
+	testConfig.AdminApiKey = testAdminKey
+	return CreateHub(testConfig, nil)
+}
+
+func adminRequest(h *Hub, method, target, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+
+	rec := httptest.NewRecorder()
+	h.AdminHandler().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminRequiresApiKey(t *testing.T) {
+	h := createTestHub()
+
+	assert.Equal(t, http.StatusUnauthorized, adminRequest(h, http.MethodGet, "/admin/stats", "").Code)
+	assert.Equal(t, http.StatusForbidden, adminRequest(h, http.MethodGet, "/admin/stats", "wrong").Code)
+	assert.Equal(t, http.StatusOK, adminRequest(h, http.MethodGet, "/admin/stats", testAdminKey).Code)
+
+	h.config.AdminApiKey = ""
+	rec := httptest.NewRecorder()
+	h.AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/stats", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestAdminListResources(t *testing.T) {
+	h := createTestHub()
+	h.addClient("alice@phone", &fakeClient{user: "alice@phone"})
+	h.addClient("alice@web", &fakeClient{user: "alice@web"})
+	h.Subscribe("poll1", "alice@web", false)
+
+	rec := adminRequest(h, http.MethodGet, "/admin/users/alice/resources", testAdminKey)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var body struct {
+		Connected bool           `json:"connected"`
+		Resources []resourceInfo `json:"resources"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.True(t, body.Connected)
+	assert.Len(t, body.Resources, 2)
+	for _, resource := range body.Resources {
+		assert.Equal(t, "doki-test", resource.UserAgent)
+		if resource.Resource == "web" {
+			assert.Equal(t, 1, resource.SubscriptionCount)
+		} else {
+			assert.Equal(t, 0, resource.SubscriptionCount)
+		}
+	}
+}
+
+func TestAdminDisconnect(t *testing.T) {
+	h := createTestHub()
+	phone := &fakeClient{user: "alice@phone"}
+	web := &fakeClient{user: "alice@web"}
+	h.addClient("alice@phone", phone)
+	h.addClient("alice@web", web)
+
+	rec := adminRequest(h, http.MethodDelete, "/admin/users/alice/resources/phone?reason=spam", testAdminKey)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, &closeFrame{code: websocket.ClosePolicyViolation, reason: "spam"}, phone.closedWith)
+	assert.Nil(t, web.closedWith)
+
+	rec = adminRequest(h, http.MethodDelete, "/admin/users/bob/resources/phone", testAdminKey)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = adminRequest(h, http.MethodDelete, "/admin/users/alice/resources", testAdminKey)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, adminDisconnectReason, web.closedWith.reason)
+}
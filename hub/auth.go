@@ -18,7 +18,12 @@ func (a *authError) Error() string {
 
 // parseAuthHeader parses AWS cognito id tokens and after validating returns username
 func parseAuthHeader(r *http.Request, jwks *keyfunc.Keyfunc) (string, error) {
-	authHeader := r.Header.Get("Authorization")
+	return parseAuthorization(r.Header.Get("Authorization"), jwks)
+}
+
+// parseAuthorization validates bearer token in the authorization value and returns username
+// it is shared by all the transports, grpc sends the same value in authorization metadata
+func parseAuthorization(authHeader string, jwks *keyfunc.Keyfunc) (string, error) {
 	if authHeader == "" {
 		return "", &authError{
 			Code:   http.StatusUnauthorized,
@@ -42,8 +47,9 @@ func parseAuthHeader(r *http.Request, jwks *keyfunc.Keyfunc) (string, error) {
 	}
 
 	jwtString := authArray[1]
-	token, _ := jwt.Parse(jwtString, (*jwks).Keyfunc)
-	if !token.Valid {
+	// malformed token is returned as nil with the error
+	token, err := jwt.Parse(jwtString, (*jwks).Keyfunc)
+	if err != nil || token == nil || !token.Valid {
 		return "", &authError{
 			Code:   http.StatusUnauthorized,
 			Reason: "invalid token",
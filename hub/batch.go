@@ -0,0 +1,55 @@
+package hub
+
+import (
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/payload"
+	"doki.co.in/doki_real_time_service/utils"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"time"
+)
+
+// batchSize returns number of queued messages to send in a single frame
+// messages are coalesced only when more messages are waiting behind the current one
+func (h *Hub) batchSize(c *clientState, waiting int) int {
+	if waiting == 0 || h.config.Websocket.BatchSize < 2 || !c.HasCapability(featureBatch) {
+		return 1
+	}
+
+	return min(waiting+1, h.config.Websocket.BatchSize)
+}
+
+// writeBatch writes messages to the websocket connection as a single batch frame
+// every message is adapted to protocol version on its own, batch frame is not cached as it is only for the client
+func (h *Hub) writeBatch(conn *websocket.Conn, username string, messages [][]byte, clientCodec codec.Codec, version int) error {
+	payloads := make([]json.RawMessage, 0, len(messages))
+	for _, message := range messages {
+		if version != payload.CurrentProtocolVersion {
+			message = payload.AdaptForVersion(message, version)
+		}
+		if message != nil {
+			payloads = append(payloads, message)
+		}
+	}
+	if len(payloads) == 0 {
+		return nil
+	}
+
+	data := utils.PayloadToJson(payload.CreateBatchPayload(username, payloads))
+	if data == nil {
+		return nil
+	}
+
+	frame, err := clientCodec.FromJson(*data)
+	if err != nil {
+		return err
+	}
+
+	compression := h.config.Websocket.Compression
+	if compression.Enabled {
+		conn.EnableWriteCompression(len(frame) >= compression.Threshold)
+	}
+
+	_ = conn.SetWriteDeadline(time.Now().Add(time.Duration(h.config.Websocket.WriteWait)))
+	return conn.WriteMessage(clientCodec.MessageType(), frame)
+}
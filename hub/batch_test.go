@@ -0,0 +1,124 @@
+package hub
+
+import (
+	"doki.co.in/doki_real_time_service/client"
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/config"
+	"doki.co.in/doki_real_time_service/payload"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBatchPayloadResults(t *testing.T) {
+	h, server := createWebsocketTestServer(t, config.Default())
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	alice, _, err := websocket.DefaultDialer.Dial(url+"?user=alice&resource=phone", nil)
+	require.NoError(t, err)
+	defer alice.Close()
+	bob, _, err := websocket.DefaultDialer.Dial(url+"?user=bob&resource=phone", nil)
+	require.NoError(t, err)
+	defer bob.Close()
+
+	assert.Eventually(t, func() bool {
+		return len(h.ConnectedResources("bob")) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, alice.WriteMessage(websocket.TextMessage, []byte(`{"type":"batch","from":"alice","id":"offline-sync","payloads":[`+
+		`{"type":"chat_message","from":"alice","to":"bob","id":"m1","subject":"text","body":"first","sendAt":"2025-01-20T10:15:30Z"},`+
+		`{"type":"chat_message","from":"alice","to":"bob","id":"m2"},`+
+		`{"type":"typing_status","from":"mallory","to":"bob"},`+
+		`{"type":"batch","from":"alice","payloads":[{"type":"typing_status","from":"alice","to":"bob"}]},`+
+		`{"type":"chat_message","from":"alice","to":"bob","id":"m3","subject":"text","body":"second","sendAt":"2025-01-20T10:15:31Z"}]}`)))
+
+	assert.Equal(t, "first", readJson(t, bob)["body"])
+	assert.Equal(t, "second", readJson(t, bob)["body"])
+
+	result := readJson(t, alice)
+	assert.Equal(t, "batch_result", result["type"])
+	assert.Equal(t, "offline-sync", result["id"])
+	assert.Equal(t, []any{
+		map[string]any{"index": 0.0, "id": "m1", "ok": true},
+		map[string]any{"index": 1.0, "id": "m2", "ok": false, "error": "Invalid payload received"},
+		map[string]any{"index": 2.0, "ok": false, "error": "Client username and payload from mismatch."},
+		map[string]any{"index": 3.0, "ok": false, "error": "Batch can't contain another batch."},
+		map[string]any{"index": 4.0, "id": "m3", "ok": true},
+	}, result["results"])
+}
+
+func TestEmptyBatchIsRejected(t *testing.T) {
+	payload.InitPayload()
+
+	data := []byte(`{"type":"batch","from":"alice","payloads":[]}`)
+	_, err := payload.CreatePayload(&data, "alice", codec.Json)
+	assert.Error(t, err)
+}
+
+// createQueuedClient serves a single websocket client whose queue already has the messages when writer starts
+func createQueuedClient(t *testing.T, capabilities []string, version int, messages ...string) *websocket.Conn {
+	payload.InitPayload()
+	h := CreateHub(config.Default(), nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := h.websocketUpgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+
+		c := createClient(conn, h, "bob@phone", client.ConnectionInfo{}, codec.Json).(*clientImpl)
+		c.Negotiate(version, capabilities)
+		for _, message := range messages {
+			data := []byte(message)
+			c.WriteToChannel(&data)
+		}
+
+		h.writers.Add(1)
+		go c.writeMessage()
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+func TestBackedUpQueueIsCoalesced(t *testing.T) {
+	messages := []string{
+		`{"type":"typing_status","from":"alice","to":"bob"}`,
+		`{"type":"server_shutdown","to":"bob","reconnectAfter":5000}`,
+		`{"type":"chat_message","from":"alice","to":"bob","body":"hello"}`,
+	}
+
+	t.Run("batch capability", func(t *testing.T) {
+		conn := createQueuedClient(t, []string{featureBatch}, payload.CurrentProtocolVersion, messages...)
+
+		batch := readJson(t, conn)
+		assert.Equal(t, "batch", batch["type"])
+		assert.Equal(t, "bob", batch["to"])
+		require.Len(t, batch["payloads"], 3)
+		assert.Equal(t, "hello", batch["payloads"].([]any)[2].(map[string]any)["body"])
+	})
+
+	t.Run("payloads are adapted to protocol version", func(t *testing.T) {
+		conn := createQueuedClient(t, []string{featureBatch}, payload.LegacyProtocolVersion, messages...)
+
+		batch := readJson(t, conn)
+		require.Len(t, batch["payloads"], 2)
+		assert.Equal(t, "typing_status", batch["payloads"].([]any)[0].(map[string]any)["type"])
+		assert.Equal(t, "chat_message", batch["payloads"].([]any)[1].(map[string]any)["type"])
+	})
+
+	t.Run("without capability", func(t *testing.T) {
+		conn := createQueuedClient(t, nil, payload.CurrentProtocolVersion, messages...)
+
+		assert.Equal(t, "typing_status", readJson(t, conn)["type"])
+		assert.Equal(t, "server_shutdown", readJson(t, conn)["type"])
+		assert.Equal(t, "chat_message", readJson(t, conn)["type"])
+	})
+}
@@ -0,0 +1,124 @@
+package hub
+
+import (
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/payload"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBlockedSenderIsNotDelivered(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected map[string]int
+	}{
+		{
+			name:     "chat message",
+			data:     `{"type":"chat_message","from":"alice","to":"bob","id":"1","subject":"text","body":"hi","sendAt":"2025-01-20T10:15:30Z"}`,
+			expected: map[string]int{"alice@web": 1},
+		},
+		{
+			name:     "typing status",
+			data:     `{"type":"typing_status","from":"alice","to":"bob"}`,
+			expected: map[string]int{},
+		},
+		{
+			name:     "friend request",
+			data:     `{"type":"user_send_friend_request","from":"alice","to":"bob","requestedBy":"alice","addedOn":"2025-01-20T10:15:30Z"}`,
+			expected: map[string]int{"alice@web": 1},
+		},
+		{
+			name: "mention",
+			data: `{"type":"user_create_secondary_node","from":"alice","to":"carol","nodeId":"c1","nodeType":"comment",` +
+				`"mentions":["bob"],"parents":[{"nodeId":"n1","nodeType":"post"}]}`,
+			expected: map[string]int{"alice@web": 1, "carol@phone": 1},
+		},
+		{
+			name:     "tag",
+			data:     `{"type":"user_create_root_node","from":"alice","id":"n1","nodeType":"post","usersTagged":["bob","carol"]}`,
+			expected: map[string]int{"alice@web": 1, "carol@phone": 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, clients := createRoutingHub("alice@phone", "alice@web", "bob@phone", "carol@phone")
+			require.NoError(t, h.receive([]byte(`{"type":"block_user","from":"bob","user":"alice"}`), "bob", "phone", codec.Json))
+
+			require.NoError(t, h.receive([]byte(test.data), "alice", "phone", codec.Json))
+			assert.Equal(t, test.expected, received(clients))
+		})
+	}
+}
+
+func TestBlockIsOneWayAndCanBeUndone(t *testing.T) {
+	h, clients := createRoutingHub("alice@phone", "bob@phone", "bob@web")
+	require.NoError(t, h.receive([]byte(`{"type":"block_user","from":"bob","user":"alice"}`), "bob", "phone", codec.Json))
+	assert.True(t, h.BlockStore().IsBlocked("bob", "alice"))
+
+	// other resources of the blocker are kept in sync
+	assert.Equal(t, map[string]int{"bob@web": 1}, received(clients))
+
+	require.NoError(t, h.receive([]byte(`{"type":"typing_status","from":"bob","to":"alice"}`), "bob", "phone", codec.Json))
+	assert.Len(t, clients["alice@phone"].written, 1)
+
+	require.NoError(t, h.receive([]byte(`{"type":"unblock_user","from":"bob","user":"alice"}`), "bob", "phone", codec.Json))
+	require.NoError(t, h.receive([]byte(`{"type":"typing_status","from":"alice","to":"bob"}`), "alice", "phone", codec.Json))
+	assert.Len(t, clients["bob@phone"].written, 1)
+}
+
+func TestBlockedUserLosesPresence(t *testing.T) {
+	h, clients := createRoutingHub("alice@phone", "alice@web", "bob@phone")
+	require.NoError(t, h.receive([]byte(`{"type":"user_presence_subscription","from":"alice","user":"bob","subscribe":true}`), "alice", "phone", codec.Json))
+	require.NoError(t, h.receive([]byte(`{"type":"user_presence_subscription","from":"alice","user":"bob","subscribe":true}`), "alice", "web", codec.Json))
+	require.Len(t, h.GetSubscribers("bob"), 2)
+
+	require.NoError(t, h.receive([]byte(`{"type":"block_user","from":"bob","user":"alice"}`), "bob", "phone", codec.Json))
+	assert.Empty(t, h.GetSubscribers("bob"))
+
+	written := len(clients["alice@phone"].written)
+	require.NoError(t, h.receive([]byte(`{"type":"user_presence_subscription","from":"alice","user":"bob","subscribe":true}`), "alice", "phone", codec.Json))
+	assert.Empty(t, h.GetSubscribers("bob"))
+	assert.Len(t, clients["alice@phone"].written, written)
+}
+
+func TestMutedConversationIsFlagged(t *testing.T) {
+	h, clients := createRoutingHub("alice@phone", "bob@phone", "bob@web", "carol@phone")
+	require.NoError(t, h.receive([]byte(`{"type":"mute_conversation","from":"bob","conversation":"alice","muted":true}`), "bob", "phone", codec.Json))
+	assert.Len(t, clients["bob@web"].written, 1)
+
+	chat := func(from, body string) string {
+		return `{"type":"chat_message","from":"` + from + `","to":"bob","id":"1","subject":"text","body":"` + body + `","sendAt":"2025-01-20T10:15:30Z","muted":false}`
+	}
+	require.NoError(t, h.receive([]byte(chat("alice", "hi")), "alice", "phone", codec.Json))
+	require.NoError(t, h.receive([]byte(chat("carol", "hey")), "carol", "phone", codec.Json))
+
+	var muted, unmuted map[string]any
+	require.NoError(t, json.Unmarshal(clients["bob@phone"].written[0], &muted))
+	require.NoError(t, json.Unmarshal(clients["bob@phone"].written[1], &unmuted))
+	assert.Equal(t, true, muted["muted"])
+	assert.Equal(t, "hi", muted["body"])
+	assert.Equal(t, false, unmuted["muted"])
+
+	// muted frame still has metadata last, so it can be stripped for legacy clients
+	var legacy map[string]any
+	require.NoError(t, json.Unmarshal(payload.AdaptForVersion(clients["bob@phone"].written[0], payload.LegacyProtocolVersion), &legacy))
+	assert.Equal(t, true, legacy["muted"])
+	assert.NotContains(t, legacy, "meta")
+
+	// muted with an escaped name can't override the flag either
+	escaped := `{"type":"chat_message","from":"alice","to":"bob","id":"2","subject":"text","body":"hi","sendAt":"2025-01-20T10:15:30Z","\u006duted":false}`
+	require.NoError(t, h.receive([]byte(escaped), "alice", "phone", codec.Json))
+	var flagged map[string]any
+	require.NoError(t, json.Unmarshal(clients["bob@phone"].written[2], &flagged))
+	assert.Equal(t, true, flagged["muted"])
+
+	require.NoError(t, h.receive([]byte(`{"type":"mute_conversation","from":"bob","conversation":"alice"}`), "bob", "phone", codec.Json))
+	require.NoError(t, h.receive([]byte(chat("alice", "again")), "alice", "phone", codec.Json))
+	var again map[string]any
+	require.NoError(t, json.Unmarshal(clients["bob@phone"].written[3], &again))
+	assert.Equal(t, false, again["muted"])
+}
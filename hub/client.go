@@ -2,25 +2,25 @@ package hub
 
 import (
 	"doki.co.in/doki_real_time_service/client"
-	"doki.co.in/doki_real_time_service/payload"
+	"doki.co.in/doki_real_time_service/codec"
 	"doki.co.in/doki_real_time_service/utils"
 	"github.com/gorilla/websocket"
+	"sync"
 	"time"
 )
 
-const (
-	pongWait             = 30 * time.Second
-	pingInterval         = (pongWait * 9) / 10
-	incomingPayloadLimit = int64(1<<14 + 1024)
-	writeWait            = 10 * time.Second
-)
-
 type rawClient interface {
 	client.Client
 	readMessage()
 	writeMessage()
 }
 
+// closeFrame is the close code and reason sent to the client before closing the connection
+type closeFrame struct {
+	code   int
+	reason string
+}
+
 type resourceList map[string]client.Client
 
 // clientList contains all the connection that are currently
@@ -41,18 +41,16 @@ type clientImpl struct {
 	user string
 
 	// channel buffering to prevent writing to connection concurrently
-	write           chan []byte
-	mySubscriptions map[string]bool
-}
+	write chan []byte
 
-// AddSubscription add node to users subscription list
-// used to clean up at the end when user disconnect
-func (c *clientImpl) AddSubscription(user string) {
-	c.mySubscriptions[user] = true
-}
+	clientState
 
-func (c *clientImpl) GetMySubscriptions() map[string]bool {
-	return c.mySubscriptions
+	// closeRequest asks writer to drain the queue and close the connection
+	closeRequest chan closeFrame
+	closeOnce    sync.Once
+
+	// done is closed when writer exits, nothing is written to connection after that
+	done chan struct{}
 }
 
 func (c *clientImpl) GetConnection() *websocket.Conn {
@@ -64,7 +62,22 @@ func (c *clientImpl) GetUserInfo() (string, string) {
 }
 
 func (c *clientImpl) WriteToChannel(data *[]byte) {
-	c.write <- *data
+	select {
+	case c.write <- *data:
+	case <-c.done:
+		// writer has exited, dropping the message instead of blocking sender forever
+	}
+}
+
+// Close asks writer to send the queued messages and close the connection with given code and reason
+// only the first call is considered
+func (c *clientImpl) Close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.closeRequest <- closeFrame{
+			code:   code,
+			reason: reason,
+		}
+	})
 }
 
 // readMessage reads all the incoming messages from the connection
@@ -74,9 +87,10 @@ func (c *clientImpl) readMessage() {
 	}()
 
 	username, resource := c.GetUserInfo()
+	pongWait := time.Duration(c.hub.config.Websocket.PongWait)
 
 	// adding max payload any Client can send through [connection]
-	c.connection.SetReadLimit(incomingPayloadLimit)
+	c.connection.SetReadLimit(c.hub.config.Websocket.IncomingPayloadLimit)
 
 	// set pong wait
 	if err := c.connection.SetReadDeadline(time.Now().Add(pongWait)); err != nil {
@@ -98,46 +112,44 @@ func (c *clientImpl) readMessage() {
 			return
 		}
 
-		incomingPayload, err := payload.CreatePayload(&data, username)
-		if err != nil {
+		if err := c.hub.receive(data, username, resource, c.codec); err != nil {
 			//log.Println(err.Error())
-			continue
 		}
-
-		// sending payload to relevant recipients
-		incomingPayload.SendPayload(&data, c.hub, resource)
 	}
 }
 
 func (c *clientImpl) writeMessage() {
 	// ping ticker
-	ticker := time.NewTicker(pingInterval)
+	ticker := time.NewTicker(time.Duration(c.hub.config.Websocket.PingInterval))
 	defer func() {
 		ticker.Stop()
+		close(c.done)
 		//c.hub.removeClient(c)
 		_ = c.GetConnection().Close()
-
+		c.hub.writers.Done()
 	}()
 
 	for {
 		select {
-		case message, ok := <-c.write:
-			_ = c.connection.SetWriteDeadline(time.Now().Add(writeWait))
-			if !ok {
-				if err := c.connection.WriteMessage(websocket.CloseMessage, nil); err != nil {
-					//log.Printf("error closing connection: %v\n", err)
-				}
-				return
-			}
-
-			if err := c.connection.WriteMessage(websocket.TextMessage, message); err != nil {
+		case message := <-c.write:
+			if err := c.sendQueued(message); err != nil {
 				//log.Printf("error sending message: %v\n", err)
 			} else {
 				//log.Printf("message send to Client: %v\n\n", c.user)
 			}
 
+		case frame := <-c.closeRequest:
+			c.drainQueue()
+
+			_ = c.connection.SetWriteDeadline(time.Now().Add(c.writeWait()))
+			closeMessage := websocket.FormatCloseMessage(frame.code, frame.reason)
+			if err := c.connection.WriteMessage(websocket.CloseMessage, closeMessage); err != nil {
+				//log.Printf("error closing connection: %v\n", err)
+			}
+			return
+
 		case <-ticker.C:
-			_ = c.connection.SetWriteDeadline(time.Now().Add(writeWait))
+			_ = c.connection.SetWriteDeadline(time.Now().Add(c.writeWait()))
 			//log.Printf("sending ping to Client: %v\n", c.user)
 			if err := c.connection.WriteMessage(websocket.PingMessage, []byte{}); err != nil {
 				//log.Printf("error sending ping to Client: %v\n", err)
@@ -147,12 +159,62 @@ func (c *clientImpl) writeMessage() {
 	}
 }
 
-func createClient(conn *websocket.Conn, hub *Hub, user string) rawClient {
+// writeWait is time allowed to write a single message to the connection
+func (c *clientImpl) writeWait() time.Duration {
+	return time.Duration(c.hub.config.Websocket.WriteWait)
+}
+
+// sendMessage writes a single message to the connection
+func (c *clientImpl) sendMessage(message []byte) error {
+	return c.hub.writeFrame(c.connection, message, c.codec, c.protocolVersion())
+}
+
+// sendQueued writes message taken from the queue
+// messages waiting behind it are sent along in a batch frame if the client supports it
+func (c *clientImpl) sendQueued(message []byte) error {
+	size := c.hub.batchSize(&c.clientState, len(c.write))
+	if size == 1 {
+		return c.sendMessage(message)
+	}
+
+	messages := append(make([][]byte, 0, size), message)
+collect:
+	for len(messages) < size {
+		select {
+		case next := <-c.write:
+			messages = append(messages, next)
+		default:
+			break collect
+		}
+	}
+
+	username, _ := c.GetUserInfo()
+	return c.hub.writeBatch(c.connection, username, messages, c.codec, c.protocolVersion())
+}
+
+// drainQueue writes all the messages that are already queued for the client
+// it stops at first write error as connection is not usable after that
+func (c *clientImpl) drainQueue() {
+	for {
+		select {
+		case message := <-c.write:
+			if err := c.sendQueued(message); err != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func createClient(conn *websocket.Conn, hub *Hub, user string, info client.ConnectionInfo, clientCodec codec.Codec) rawClient {
 	return &clientImpl{
-		connection:      conn,
-		hub:             hub,
-		write:           make(chan []byte),
-		user:            user,
-		mySubscriptions: make(map[string]bool),
+		connection:   conn,
+		hub:          hub,
+		write:        make(chan []byte, hub.config.Websocket.SendQueueSize),
+		user:         user,
+		clientState:  createClientState(info, clientCodec),
+		closeRequest: make(chan closeFrame, 1),
+		done:         make(chan struct{}),
 	}
 }
\ No newline at end of file
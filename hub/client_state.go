@@ -0,0 +1,137 @@
+package hub
+
+import (
+	"doki.co.in/doki_real_time_service/client"
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/payload"
+	"doki.co.in/doki_real_time_service/utils"
+	"github.com/gorilla/websocket"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// clientState is the part of client which is same for every transport
+type clientState struct {
+	// subscriptions are added by the reader and read by the hub when client is removed
+	subscriptionLock sync.Mutex
+	mySubscriptions  map[string]bool
+
+	info client.ConnectionInfo
+
+	// codec is negotiated through websocket subprotocol
+	codec codec.Codec
+
+	// protocol is nil till client declares its version
+	protocol atomic.Pointer[protocol]
+
+	// rates are buckets of the rate limit classes used by the client
+	rateLock sync.Mutex
+	rates    map[string]*rateBucket
+}
+
+func createClientState(info client.ConnectionInfo, clientCodec codec.Codec) clientState {
+	return clientState{
+		mySubscriptions: make(map[string]bool),
+		info:            info,
+		codec:           clientCodec,
+	}
+}
+
+// AddSubscription add node to users subscription list
+// used to clean up at the end when user disconnect
+func (c *clientState) AddSubscription(user string) {
+	c.subscriptionLock.Lock()
+	defer c.subscriptionLock.Unlock()
+
+	c.mySubscriptions[user] = true
+}
+
+// GetMySubscriptions returns snapshot of the subscriptions
+func (c *clientState) GetMySubscriptions() map[string]bool {
+	c.subscriptionLock.Lock()
+	defer c.subscriptionLock.Unlock()
+
+	return maps.Clone(c.mySubscriptions)
+}
+
+func (c *clientState) GetConnectionInfo() client.ConnectionInfo {
+	return c.info
+}
+
+// Negotiate sets protocol version and capabilities of the client, only first call is considered
+func (c *clientState) Negotiate(version int, capabilities []string) bool {
+	negotiated := &protocol{
+		version:      version,
+		capabilities: make(map[string]bool),
+	}
+	for _, capability := range capabilities {
+		negotiated.capabilities[capability] = true
+	}
+
+	return c.protocol.CompareAndSwap(nil, negotiated)
+}
+
+func (c *clientState) HasCapability(capability string) bool {
+	negotiated := c.protocol.Load()
+	return negotiated != nil && negotiated.capabilities[capability]
+}
+
+// protocolVersion returns negotiated version, clients which didn't declare version are legacy
+func (c *clientState) protocolVersion() int {
+	negotiated := c.protocol.Load()
+	if negotiated == nil {
+		return payload.LegacyProtocolVersion
+	}
+
+	return negotiated.version
+}
+
+// receive sends the message read from the client to relevant recipients
+// returns error if message is not a valid payload
+func (h *Hub) receive(data []byte, username, resource string, clientCodec codec.Codec) error {
+	incomingPayload, err := payload.CreatePayload(&data, username, clientCodec)
+	if err != nil {
+		return err
+	}
+
+	data = payload.StampMetadata(data, h.metadata(username, resource))
+	incomingPayload.SendPayload(&data, h, resource)
+	return nil
+}
+
+// metadata returns metadata stamped on the payload relayed from the client
+func (h *Hub) metadata(username, resource string) payload.Metadata {
+	metadata := payload.Metadata{
+		ServerTs: time.Now().UnixMilli(),
+		Resource: resource,
+		EventId:  utils.NewEventId(),
+	}
+	if h.config.Websocket.VerifiedSender {
+		metadata.VerifiedFrom = username
+	}
+
+	return metadata
+}
+
+// writeFrame writes a single message to the websocket connection in client codec and protocol version
+// small messages are sent uncompressed as deflate overhead is more than the saving
+func (h *Hub) writeFrame(conn *websocket.Conn, message []byte, clientCodec codec.Codec, version int) error {
+	prepared, err := h.frames.prepare(message, clientCodec, version)
+	if err != nil {
+		return err
+	}
+	if prepared == nil {
+		// frame is not meant for the client protocol version
+		return nil
+	}
+
+	compression := h.config.Websocket.Compression
+	if compression.Enabled {
+		conn.EnableWriteCompression(len(message) >= compression.Threshold)
+	}
+
+	_ = conn.SetWriteDeadline(time.Now().Add(time.Duration(h.config.Websocket.WriteWait)))
+	return conn.WritePreparedMessage(prepared)
+}
@@ -0,0 +1,129 @@
+package hub
+
+import (
+	"compress/flate"
+	"doki.co.in/doki_real_time_service/config"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// payload shapes as they are sent by the apps
+var benchmarkPayloads = map[string][]byte{
+	"presence": []byte(`{"type":"user_presence_info","to":"rohan_verma__","user":"doki_user_01","online":true}`),
+	"chat": []byte(`{"type":"chat_message","from":"rohan_verma__","to":"doki_user_01","id":"0193b0c4-7d4e-7c3a-9f1e-2a6b1c8d9e0f",` +
+		`"subject":"text","body":"Hey! are we still meeting tomorrow for the design review? I have pushed the latest changes to the branch.",` +
+		`"replyOn":"","sendAt":"2025-01-20T10:15:30.123Z"}`),
+	"secondaryNode": []byte(`{"type":"user_create_secondary_node","from":"rohan_verma__","to":"doki_user_01","nodeId":"comment_0193b0c4",` +
+		`"nodeType":"comment","mentions":["doki_user_02","doki_user_03","doki_user_04"],"replyOnNodeCreatedBy":"doki_user_05",` +
+		`"parents":[{"nodeId":"post_0193b0c4","nodeType":"post"},{"nodeId":"comment_0193b0c3","nodeType":"comment"}]}`),
+	"longChat": []byte(`{"type":"chat_message","from":"rohan_verma__","to":"doki_user_01","id":"0193b0c4-7d4e-7c3a-9f1e-2a6b1c8d9e0f",` +
+		`"subject":"text","body":"` + strings.Repeat("Sharing the notes from today's sync, please go through them before friday. ", 40) + `",` +
+		`"replyOn":"0193b0c4-7d4e-7c3a-9f1e-2a6b1c8d9e00","sendAt":"2025-01-20T10:15:30.123Z"}`),
+}
+
+// countingConn counts bytes read from the network
+type countingConn struct {
+	net.Conn
+	read *atomic.Int64
+}
+
+func (c *countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.read.Add(int64(n))
+	return n, err
+}
+
+func benchmarkCompression(b *testing.B, compression config.CompressionConfig, message []byte) {
+	testConfig := config.Default()
+	testConfig.Websocket.Compression = compression
+	h := CreateHub(testConfig, nil)
+
+	serverConn := make(chan *websocket.Conn, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := h.websocketUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			b.Error(err)
+			return
+		}
+		_ = conn.SetCompressionLevel(compression.Level)
+		serverConn <- conn
+	}))
+	defer server.Close()
+
+	var wireBytes atomic.Int64
+	dialer := websocket.Dialer{
+		EnableCompression: true,
+		NetDial: func(network, addr string) (net.Conn, error) {
+			conn, err := net.Dial(network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return &countingConn{Conn: conn, read: &wireBytes}, nil
+		},
+	}
+
+	clientConn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer clientConn.Close()
+
+	c := &clientImpl{connection: <-serverConn, hub: h}
+	defer c.connection.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < b.N; i++ {
+			if _, _, err := clientConn.ReadMessage(); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	}()
+
+	wireBytes.Store(0)
+	b.SetBytes(int64(len(message)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := c.sendMessage(message); err != nil {
+			b.Fatal(err)
+		}
+	}
+	<-done
+
+	b.StopTimer()
+	b.ReportMetric(float64(wireBytes.Load())/float64(b.N), "wire-B/op")
+}
+
+// BenchmarkCompression compares cpu cost of writes against bytes on the wire
+// go test ./hub -run ^$ -bench Compression
+func BenchmarkCompression(b *testing.B) {
+	settings := []struct {
+		name        string
+		compression config.CompressionConfig
+	}{
+		{"off", config.CompressionConfig{Enabled: false}},
+		{"huffmanOnly", config.CompressionConfig{Enabled: true, Level: flate.HuffmanOnly}},
+		{"bestSpeed", config.CompressionConfig{Enabled: true, Level: flate.BestSpeed}},
+		{"default", config.CompressionConfig{Enabled: true, Level: 6}},
+		{"best", config.CompressionConfig{Enabled: true, Level: flate.BestCompression}},
+		{"bestSpeedThreshold512", config.CompressionConfig{Enabled: true, Level: flate.BestSpeed, Threshold: 512}},
+	}
+
+	for name, message := range benchmarkPayloads {
+		for _, setting := range settings {
+			b.Run(fmt.Sprintf("%v/%v", name, setting.name), func(b *testing.B) {
+				benchmarkCompression(b, setting.compression, message)
+			})
+		}
+	}
+}
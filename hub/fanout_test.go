@@ -0,0 +1,133 @@
+package hub
+
+import (
+	"bufio"
+	"compress/flate"
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/config"
+	"doki.co.in/doki_real_time_service/payload"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const fanoutConnections = 10_000
+
+// discardConn is an in-process network connection which drops everything written to it
+type discardConn struct {
+	net.Conn
+}
+
+func (discardConn) Write(p []byte) (int, error)      { return len(p), nil }
+func (discardConn) Read([]byte) (int, error)         { return 0, io.EOF }
+func (discardConn) Close() error                     { return nil }
+func (discardConn) SetWriteDeadline(time.Time) error { return nil }
+func (discardConn) SetReadDeadline(time.Time) error  { return nil }
+func (discardConn) LocalAddr() net.Addr              { return &net.TCPAddr{} }
+func (discardConn) RemoteAddr() net.Addr             { return &net.TCPAddr{} }
+func (discardConn) SetDeadline(time.Time) error      { return nil }
+func (d discardConn) hijacked() (net.Conn, *bufio.ReadWriter) {
+	return d, bufio.NewReadWriter(bufio.NewReader(d), bufio.NewWriter(d))
+}
+
+// hijackRecorder lets websocket upgrader take over a discardConn
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (h hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw := discardConn{}.hijacked()
+	return conn, rw, nil
+}
+
+// createFakeConnection creates server side websocket connection writing to nowhere
+func createFakeConnection(tb testing.TB, h *Hub, compress bool) *websocket.Conn {
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-Websocket-Version", "13")
+	req.Header.Set("Sec-Websocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	if compress {
+		req.Header.Set("Sec-Websocket-Extensions", "permessage-deflate; server_no_context_takeover; client_no_context_takeover")
+	}
+
+	conn, err := h.websocketUpgrader.Upgrade(hijackRecorder{httptest.NewRecorder()}, req, nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return conn
+}
+
+func TestFrameCacheKeysMessage(t *testing.T) {
+	frames := createFrameCache()
+	message := []byte(`{"type":"chat_message","from":"alice","to":"bob","body":"hi"}{"type":"welcome"}`)
+	chat := message[:len(message)-len(`{"type":"welcome"}`)]
+
+	prepared, err := frames.prepare(chat, codec.Json, payload.CurrentProtocolVersion)
+	require.NoError(t, err)
+	again, err := frames.prepare(chat, codec.Json, payload.CurrentProtocolVersion)
+	require.NoError(t, err)
+	assert.Same(t, prepared, again)
+
+	// message starting at the same byte but longer is a different message
+	whole, err := frames.prepare(message, codec.Json, payload.CurrentProtocolVersion)
+	require.NoError(t, err)
+	assert.NotSame(t, prepared, whole)
+
+	legacy, err := frames.prepare(chat, codec.Json, payload.LegacyProtocolVersion)
+	require.NoError(t, err)
+	assert.NotSame(t, prepared, legacy)
+}
+
+// BenchmarkFanout measures sending one event to 10k connections
+// "writeMessage" frames the message for every connection like before
+// "prepared" uses the frame cache so the message is framed once per fanout
+// go test ./hub -run ^$ -bench Fanout -benchmem
+func BenchmarkFanout(b *testing.B) {
+	message := benchmarkPayloads["chat"]
+
+	for _, compress := range []bool{false, true} {
+		testConfig := config.Default()
+		testConfig.Websocket.Compression = config.CompressionConfig{Enabled: compress, Level: flate.BestSpeed}
+		h := CreateHub(testConfig, nil)
+
+		clients := make([]*clientImpl, fanoutConnections)
+		for i := range clients {
+			conn := createFakeConnection(b, h, compress)
+			_ = conn.SetCompressionLevel(flate.BestSpeed)
+			clients[i] = &clientImpl{connection: conn, hub: h}
+		}
+
+		b.Run(fmt.Sprintf("writeMessage/compress=%v", compress), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for _, c := range clients {
+					if err := c.connection.WriteMessage(websocket.TextMessage, message); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("prepared/compress=%v", compress), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				// every fanout forwards a new message read from the sender
+				fanoutMessage := append([]byte(nil), message...)
+				for _, c := range clients {
+					if err := c.sendMessage(fanoutMessage); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
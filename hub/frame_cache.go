@@ -0,0 +1,110 @@
+package hub
+
+import (
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/payload"
+	"github.com/gorilla/websocket"
+	"sync"
+)
+
+// frameCacheSize is number of prepared frames kept, it only needs to cover fanouts in progress
+const frameCacheSize = 1024
+
+type frameKey struct {
+	// data is the first byte of the json message and size is its length, fanout passes same message
+	// to every recipient so they identify the fanout without hashing the message
+	// messages which are prefixes of each other share the first byte, so size is part of the key
+	// cached key keeps the message alive, so its memory can't be reused by another message meanwhile
+	// messages are never changed in place once written, as queues of every recipient share the same slice,
+	// so the same address and length always have the same content while the key is cached
+	data    *byte
+	size    int
+	codec   string
+	version int
+}
+
+// frameCache keeps json messages prepared for every codec and protocol version
+// so that a fanout encodes and frames the message once per codec and version
+// instead of once per recipient
+//
+// prepared message also keeps the compressed frame so deflate runs once per fanout
+type frameCache struct {
+	sync.Mutex
+	frames map[frameKey]*websocket.PreparedMessage
+
+	// order is a ring of keys used to evict the oldest frame
+	order []frameKey
+	next  int
+}
+
+func createFrameCache() *frameCache {
+	return &frameCache{
+		frames: make(map[frameKey]*websocket.PreparedMessage, frameCacheSize),
+		order:  make([]frameKey, frameCacheSize),
+	}
+}
+
+// encodeFrame returns json message in the shape of protocol version and in the codec format
+// returns nil message if it should not be sent to the protocol version
+func encodeFrame(message []byte, clientCodec codec.Codec, version int) ([]byte, error) {
+	if version != payload.CurrentProtocolVersion {
+		message = payload.AdaptForVersion(message, version)
+		if message == nil {
+			return nil, nil
+		}
+	}
+
+	return clientCodec.FromJson(message)
+}
+
+// prepare returns the message framed for the codec and protocol version
+// returns nil if message should not be sent to the protocol version
+func (f *frameCache) prepare(message []byte, clientCodec codec.Codec, version int) (*websocket.PreparedMessage, error) {
+	if clientCodec == nil {
+		clientCodec = codec.Json
+	}
+
+	if len(message) == 0 {
+		return websocket.NewPreparedMessage(clientCodec.MessageType(), message)
+	}
+
+	key := frameKey{
+		data:    &message[0],
+		size:    len(message),
+		codec:   clientCodec.Name(),
+		version: version,
+	}
+
+	f.Lock()
+	prepared, ok := f.frames[key]
+	f.Unlock()
+	if ok {
+		return prepared, nil
+	}
+
+	frame, err := encodeFrame(message, clientCodec, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if frame != nil {
+		if prepared, err = websocket.NewPreparedMessage(clientCodec.MessageType(), frame); err != nil {
+			return nil, err
+		}
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	if cached, ok := f.frames[key]; ok {
+		// another recipient prepared it first, using the same one keeps a single framing
+		return cached, nil
+	}
+
+	delete(f.frames, f.order[f.next])
+	f.order[f.next] = key
+	f.next = (f.next + 1) % frameCacheSize
+	f.frames[key] = prepared
+
+	return prepared, nil
+}
@@ -0,0 +1,253 @@
+package hub
+
+import (
+	"doki.co.in/doki_real_time_service/client"
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/realtimepb"
+	"doki.co.in/doki_real_time_service/utils"
+	"errors"
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"strings"
+	"sync"
+	"time"
+)
+
+// grpcClient is client of grpc transport, every payload is an envelope on the Connect stream
+type grpcClient struct {
+	stream realtimepb.Realtime_ConnectServer
+	hub    *Hub
+
+	// user is complete user with resource part
+	user string
+
+	write chan []byte
+
+	clientState
+
+	closeRequest chan closeFrame
+	closeOnce    sync.Once
+
+	// received is closed when client stops sending
+	received chan struct{}
+
+	// done is closed when writer exits, nothing is sent after that
+	done chan struct{}
+}
+
+func createGrpcClient(stream realtimepb.Realtime_ConnectServer, hub *Hub, user string, info client.ConnectionInfo) *grpcClient {
+	return &grpcClient{
+		stream:       stream,
+		hub:          hub,
+		user:         user,
+		write:        make(chan []byte, hub.config.Websocket.SendQueueSize),
+		clientState:  createClientState(info, codec.Json),
+		closeRequest: make(chan closeFrame, 1),
+		received:     make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+}
+
+// GetConnection returns nil as there is no websocket connection
+func (c *grpcClient) GetConnection() *websocket.Conn {
+	return nil
+}
+
+func (c *grpcClient) GetUserInfo() (string, string) {
+	return utils.GetUsernameAndResourceFromUser(c.user)
+}
+
+func (c *grpcClient) WriteToChannel(data *[]byte) {
+	select {
+	case c.write <- *data:
+	case <-c.done:
+	}
+}
+
+// Close asks writer to send the queued messages and end the stream with status for the close code
+func (c *grpcClient) Close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.closeRequest <- closeFrame{
+			code:   code,
+			reason: reason,
+		}
+	})
+}
+
+// readMessage receives envelopes till client closes its side of the stream
+func (c *grpcClient) readMessage() {
+	defer close(c.received)
+
+	username, resource := c.GetUserInfo()
+	for {
+		envelope, err := c.stream.Recv()
+		if err != nil {
+			return
+		}
+
+		data, err := envelope.ToJson()
+		if err != nil {
+			continue
+		}
+
+		if err := c.hub.receive(data, username, resource, codec.Json); err != nil {
+			//log.Println(err.Error())
+		}
+	}
+}
+
+// writeMessage sends queued messages till stream ends, returned error is the stream status
+func (c *grpcClient) writeMessage() error {
+	defer func() {
+		close(c.done)
+		c.hub.writers.Done()
+	}()
+
+	for {
+		select {
+		case message := <-c.write:
+			if err := c.sendMessage(message); err != nil {
+				return err
+			}
+
+		case frame := <-c.closeRequest:
+			c.drainQueue()
+			return closeStatus(frame)
+
+		case <-c.received:
+			return nil
+
+		case <-c.stream.Context().Done():
+			return c.stream.Context().Err()
+		}
+	}
+}
+
+func (c *grpcClient) sendMessage(message []byte) error {
+	frame, err := encodeFrame(message, codec.Json, c.protocolVersion())
+	if err != nil || frame == nil {
+		return nil
+	}
+
+	envelope, err := realtimepb.EnvelopeFromJson(frame)
+	if err != nil {
+		return nil
+	}
+
+	return c.stream.Send(envelope)
+}
+
+func (c *grpcClient) drainQueue() {
+	for {
+		select {
+		case message := <-c.write:
+			if err := c.sendMessage(message); err != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// closeStatus maps websocket close code to grpc status
+func closeStatus(frame closeFrame) error {
+	switch frame.code {
+	case websocket.CloseNormalClosure:
+		return nil
+	case websocket.CloseServiceRestart, websocket.CloseTryAgainLater:
+		return status.Error(codes.Unavailable, frame.reason)
+	case websocket.ClosePolicyViolation:
+		return status.Error(codes.PermissionDenied, frame.reason)
+	case websocket.CloseProtocolError:
+		return status.Error(codes.InvalidArgument, frame.reason)
+	default:
+		return status.Error(codes.Aborted, frame.reason)
+	}
+}
+
+// grpcService implements realtime grpc service on the hub
+type grpcService struct {
+	realtimepb.UnimplementedRealtimeServer
+	hub *Hub
+}
+
+// RegisterGrpc registers realtime service on the grpc server
+func (h *Hub) RegisterGrpc(server *grpc.Server) {
+	realtimepb.RegisterRealtimeServer(server, &grpcService{hub: h})
+}
+
+// Connect authenticates the stream with the authorization metadata same as websocket
+func (s *grpcService) Connect(stream realtimepb.Realtime_ConnectServer) error {
+	h := s.hub
+	if h.shuttingDown.Load() {
+		return status.Error(codes.Unavailable, shutdownCloseReason)
+	}
+
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	username, err := parseAuthorization(firstMetadata(md, "authorization"), h.jwks)
+	if err != nil {
+		var authErrorObject *authError
+		if errors.As(err, &authErrorObject) {
+			return status.Error(codes.Unauthenticated, authErrorObject.Reason)
+		}
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return h.connectStream(stream, username, md)
+}
+
+func (h *Hub) connectStream(stream realtimepb.Realtime_ConnectServer, username string, md metadata.MD) error {
+	version, declared, err := parseVersion(firstMetadata(md, "version"))
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	resource := firstMetadata(md, "resource")
+	if resource == "" {
+		resource = utils.RandomString()
+	}
+
+	info := client.ConnectionInfo{
+		ConnectedAt: time.Now(),
+		UserAgent:   firstMetadata(md, "user-agent"),
+	}
+	if remote, ok := peer.FromContext(stream.Context()); ok {
+		info.RemoteAddr = remote.Addr.String()
+	}
+
+	user := utils.CreateUserFromUsernameAndResource(username, resource)
+	newClient := createGrpcClient(stream, h, user, info)
+
+	// writer is counted before client is visible to shutdown
+	h.writers.Add(1)
+	h.addClient(user, newClient)
+	defer h.removeClient(newClient)
+
+	// sending my initial online presence
+	h.sendPresence(true, username)
+
+	if declared {
+		var capabilities []string
+		if values := md.Get("capabilities"); len(values) > 0 {
+			capabilities = splitCapabilities(strings.Join(values, ","))
+		}
+		h.Negotiate(user, version, capabilities)
+	}
+
+	go newClient.readMessage()
+	return newClient.writeMessage()
+}
+
+func firstMetadata(md metadata.MD, key string) string {
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
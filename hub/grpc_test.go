@@ -0,0 +1,190 @@
+package hub
+
+import (
+	"context"
+	"doki.co.in/doki_real_time_service/config"
+	"doki.co.in/doki_real_time_service/payload"
+	"doki.co.in/doki_real_time_service/realtimepb"
+	"encoding/json"
+	"github.com/MicahParks/keyfunc/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// testGrpcService takes username from metadata instead of the token
+type testGrpcService struct {
+	realtimepb.UnimplementedRealtimeServer
+	hub *Hub
+}
+
+func (s *testGrpcService) Connect(stream realtimepb.Realtime_ConnectServer) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	return s.hub.connectStream(stream, firstMetadata(md, "user"), md)
+}
+
+func createGrpcTestServer(t *testing.T) (*Hub, realtimepb.RealtimeClient) {
+	payload.InitPayload()
+	h := CreateHub(config.Default(), nil)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	realtimepb.RegisterRealtimeServer(server, &testGrpcService{hub: h})
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return h, realtimepb.NewRealtimeClient(conn)
+}
+
+func connectGrpc(t *testing.T, ctx context.Context, rpc realtimepb.RealtimeClient, pairs ...string) realtimepb.Realtime_ConnectClient {
+	stream, err := rpc.Connect(metadata.AppendToOutgoingContext(ctx, pairs...))
+	require.NoError(t, err)
+
+	return stream
+}
+
+// receiveGrpc returns the next envelope or fails the test if nothing arrives in time
+func receiveGrpc(t *testing.T, stream realtimepb.Realtime_ConnectClient) *realtimepb.Envelope {
+	received := make(chan *realtimepb.Envelope, 1)
+	go func() {
+		envelope, err := stream.Recv()
+		if err != nil {
+			close(received)
+			return
+		}
+		received <- envelope
+	}()
+
+	select {
+	case envelope, ok := <-received:
+		require.True(t, ok, "stream ended")
+		return envelope
+	case <-time.After(time.Second):
+		t.Fatal("nothing received on grpc stream")
+		return nil
+	}
+}
+
+func TestGrpcChatAndPresence(t *testing.T) {
+	h, rpc := createGrpcTestServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bob := connectGrpc(t, ctx, rpc, "user", "bob", "resource", "native", "version", strconv.Itoa(payload.CurrentProtocolVersion))
+
+	welcome := receiveGrpc(t, bob).GetWelcome()
+	require.NotNil(t, welcome)
+	assert.Equal(t, "native", welcome.GetResource())
+	assert.EqualValues(t, payload.CurrentProtocolVersion, welcome.GetProtocolVersion())
+
+	aliceCtx, aliceDisconnect := context.WithCancel(ctx)
+	alice := connectGrpc(t, aliceCtx, rpc, "user", "alice", "resource", "worker")
+	assert.Eventually(t, func() bool {
+		return len(h.ConnectedResources("alice")) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, bob.Send(&realtimepb.Envelope{
+		Payload: &realtimepb.Envelope_UserPresenceSubscription{UserPresenceSubscription: &realtimepb.UserPresenceSubscription{
+			From:      "bob",
+			User:      "alice",
+			Subscribe: true,
+		}},
+	}))
+	presence := receiveGrpc(t, bob).GetUserPresenceInfo()
+	require.NotNil(t, presence)
+	assert.Equal(t, "alice", presence.GetUser())
+	assert.True(t, presence.GetOnline())
+
+	sendAt := time.Date(2025, 1, 20, 10, 15, 30, 0, time.UTC)
+	require.NoError(t, alice.Send(&realtimepb.Envelope{
+		Payload: &realtimepb.Envelope_ChatMessage{ChatMessage: &realtimepb.ChatMessage{
+			From:    "alice",
+			To:      "bob",
+			Id:      "0193b0c4-7d4e-7c3a-9f1e-2a6b1c8d9e0f",
+			Subject: "text",
+			Body:    "hello over grpc",
+			SendAt:  timestamppb.New(sendAt),
+		}},
+	}))
+	envelope := receiveGrpc(t, bob)
+	chat := envelope.GetChatMessage()
+	require.NotNil(t, chat)
+	assert.Equal(t, "worker", envelope.GetMeta().GetResource())
+	assert.NotEmpty(t, envelope.GetMeta().GetEventId())
+	assert.Equal(t, "alice", chat.GetFrom())
+	assert.Equal(t, "hello over grpc", chat.GetBody())
+	assert.True(t, sendAt.Equal(chat.GetSendAt().AsTime()))
+
+	aliceDisconnect()
+	presence = receiveGrpc(t, bob).GetUserPresenceInfo()
+	require.NotNil(t, presence)
+	assert.False(t, presence.GetOnline())
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, time.Second)
+	defer shutdownCancel()
+	require.NoError(t, h.Shutdown(shutdownCtx))
+
+	assert.NotNil(t, receiveGrpc(t, bob).GetServerShutdown())
+	_, err := bob.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestGrpcRequiresAuthorization(t *testing.T) {
+	h := CreateHub(config.Default(), nil)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	h.RegisterGrpc(server)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	stream, err := realtimepb.NewRealtimeClient(conn).Connect(context.Background())
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestGrpcRejectsMalformedToken(t *testing.T) {
+	jwks, err := keyfunc.NewJWKSetJSON(json.RawMessage(`{"keys":[]}`))
+	require.NoError(t, err)
+	h := CreateHub(config.Default(), &jwks)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	h.RegisterGrpc(server)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	for _, authorization := range []string{"Bearer junk", "Bearer a.b.c"} {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", authorization)
+		stream, err := realtimepb.NewRealtimeClient(conn).Connect(ctx)
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Unauthenticated, status.Code(err), authorization)
+	}
+}
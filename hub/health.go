@@ -0,0 +1,34 @@
+package hub
+
+import (
+	"context"
+	"net/http"
+)
+
+// IsReady reports if hub can accept new connections
+// hub is ready once jwks keys are loaded and shutdown has not started
+func (h *Hub) IsReady(ctx context.Context) bool {
+	if h.shuttingDown.Load() || h.jwks == nil {
+		return false
+	}
+
+	keys, err := (*h.jwks).Storage().KeyReadAll(ctx)
+	return err == nil && len(keys) > 0
+}
+
+// ServeHealth reports that process is up, it doesn't check any dependency
+func (h *Hub) ServeHealth(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok"))
+}
+
+// ServeReady reports if the instance should receive traffic
+func (h *Hub) ServeReady(w http.ResponseWriter, r *http.Request) {
+	if !h.IsReady(r.Context()) {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ready"))
+}
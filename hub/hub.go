@@ -1,26 +1,86 @@
 package hub
 
 import (
+	"doki.co.in/doki_real_time_service/block"
 	"doki.co.in/doki_real_time_service/client"
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/config"
+	"doki.co.in/doki_real_time_service/moderation"
+	"doki.co.in/doki_real_time_service/push"
+	"doki.co.in/doki_real_time_service/reaction"
+	"doki.co.in/doki_real_time_service/relation"
+	"doki.co.in/doki_real_time_service/unread"
 	"doki.co.in/doki_real_time_service/utils"
+	"doki.co.in/doki_real_time_service/webhook"
 	"errors"
 	"github.com/MicahParks/keyfunc/v3"
 	"github.com/gorilla/websocket"
+	"log"
+	"maps"
 	"net/http"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
-var websocketUpgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-}
-
 // Hub handles all the client connection and related methods
 type Hub struct {
 	sync.RWMutex
 	clients      clientList
 	jwks         *keyfunc.Keyfunc
 	subscription subscription
+	config       *config.Config
+
+	websocketUpgrader websocket.Upgrader
+	frames            *frameCache
+	originPolicy      *originPolicy
+
+	// poller serves connections when netpoll transport is used, nil otherwise
+	poller *poller
+
+	// rejectedOrigins counts connections rejected by origin policy
+	rejectedOrigins atomic.Int64
+
+	// shuttingDown is set once shutdown starts, no new connection is accepted after that
+	shuttingDown atomic.Bool
+
+	// writers tracks running client writers so that shutdown can wait for queues to drain
+	writers sync.WaitGroup
+
+	// blocks has users blocked and conversations muted by every user
+	blocks block.Store
+
+	// relations has friends and message requests, messagePolicy uses it to authorize direct messages
+	relations     relation.Store
+	messagePolicy relation.Policy
+
+	// devices has push notification tokens, notifiers by platform and templates notify offline users
+	devices   push.DeviceStore
+	notifiers map[string]push.PushNotifier
+	templates push.Templates
+
+	// unread has unread message counts by conversation of every user
+	unread unread.Store
+
+	// reactions has reactions of users on messages, limited per user and message
+	reactions reaction.Store
+
+	// pushQueue has notifications waiting for push workers, nil when push is not enabled or closed
+	// pushes tracks the queued notifications and the ones being sent
+	pushLock  sync.RWMutex
+	pushQueue chan pushJob
+	pushes    sync.WaitGroup
+
+	// webhooks sends payloads to webhook endpoints, nil when there are none
+	webhooks *webhook.Dispatcher
+
+	// moderator checks messages before routing, strikes mute users and review has held messages
+	// all of them are nil when moderation is not enabled
+	moderator moderation.Moderator
+	strikes   *moderation.Strikes
+	review    *moderation.ReviewQueue
+
+	startedAt time.Time
 }
 
 // addClient adds newly connected client to Hub
@@ -28,6 +88,11 @@ func (h *Hub) addClient(user string, client client.Client) {
 	h.Lock()
 	defer h.Unlock()
 
+	h.putClient(user, client)
+}
+
+// putClient adds connection to Hub, must be called with lock held
+func (h *Hub) putClient(user string, client client.Client) {
 	username, resource := utils.GetUsernameAndResourceFromUser(user)
 	if username == "" || resource == "" {
 		return
@@ -42,36 +107,42 @@ func (h *Hub) addClient(user string, client client.Client) {
 
 // removeClient closes and removes connection from Hub
 func (h *Hub) removeClient(c client.Client) {
-	h.Lock()
-	defer h.Unlock()
-
 	username, resource := c.GetUserInfo()
 	completeUser := utils.CreateUserFromUsernameAndResource(username, resource)
 	if username == "" || resource == "" {
 		return
 	}
 
+	h.Lock()
 	// check the connection we are tyring to remove and the connection that is present are same
 	// this can happen if client resource is same but underlying tcp connection is changed
-	if conn, ok := h.clients[username][resource]; ok && conn.GetConnection() == c.GetConnection() {
-		// close the websocket connection
-		if conn.GetConnection() != nil {
-			_ = conn.GetConnection().Close()
-		}
+	// clients are compared instead of websocket connections as every transport doesn't have one
+	conn, ok := h.clients[username][resource]
+	if !ok || conn != c {
+		h.Unlock()
+		return
+	}
 
-		mySubscriptions := conn.GetMySubscriptions()
-		for subscription := range mySubscriptions {
-			h.Unsubscribe(subscription, completeUser)
-		}
-		// remove resource from username
-		delete(h.clients[username], resource)
-
-		// if empty remove the username too
-		if len(h.clients[username]) == 0 {
-			// send offline status too for this user
-			h.sendPresence(false, username)
-			delete(h.clients, username)
-		}
+	// remove resource from username, if empty remove the username too
+	delete(h.clients[username], resource)
+	offline := len(h.clients[username]) == 0
+	if offline {
+		delete(h.clients, username)
+	}
+	h.Unlock()
+
+	// close the websocket connection
+	if conn.GetConnection() != nil {
+		_ = conn.GetConnection().Close()
+	}
+
+	// subscriptions and presence look up clients again, so they are updated without holding the lock
+	for subscription := range conn.GetMySubscriptions() {
+		h.Unsubscribe(subscription, completeUser)
+	}
+	if offline {
+		// send offline status too for this user
+		h.sendPresence(false, username)
 	}
 }
 
@@ -83,6 +154,9 @@ func (h *Hub) GetIndividualClient(user string) client.Client {
 		return nil
 	}
 
+	h.RLock()
+	defer h.RUnlock()
+
 	if _, ok := h.clients[username]; !ok {
 		return nil
 	}
@@ -95,55 +169,239 @@ func (h *Hub) GetIndividualClient(user string) client.Client {
 
 }
 
-// GetAllConnectedClients will return all the connected clients for a particular user
+// GetAllConnectedClients will return snapshot of all the connected clients for a particular user
 // this will be used when forwarding user messages
 func (h *Hub) GetAllConnectedClients(username string) map[string]client.Client {
-	return h.clients[username]
+	h.RLock()
+	defer h.RUnlock()
+
+	return maps.Clone(h.clients[username])
 }
 
 // ServeWS methods takes the current [http] request
 // and upgrade it to [websocket] connection
 func (h *Hub) ServeWS(w http.ResponseWriter, r *http.Request) {
+	username, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	// clients can declare protocol while connecting instead of sending hello frame
+	version, declared, err := parseProtocolVersion(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.connect(w, r, username, version, declared)
+}
+
+// authenticate checks the request of any transport and returns username of the client
+// error response is already written if request is not allowed
+func (h *Hub) authenticate(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if h.shuttingDown.Load() {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return "", false
+	}
+
+	if !h.checkOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return "", false
+	}
+
 	username, err := parseAuthHeader(r, h.jwks)
 	if err != nil {
 		var authErrorObject *authError
 		if errors.As(err, &authErrorObject) {
 			http.Error(w, authErrorObject.Error(), authErrorObject.Code)
 		}
-		return
+		return "", false
+	}
+
+	return username, true
+}
+
+// connect upgrades the authenticated request and starts serving the client on configured transport
+func (h *Hub) connect(w http.ResponseWriter, r *http.Request, username string, version int, declared bool) {
+	if h.poller != nil {
+		w = pollHijacker{ResponseWriter: w}
 	}
 
-	conn, err := websocketUpgrader.Upgrade(w, r, nil)
+	conn, err := h.websocketUpgrader.Upgrade(w, r, nil)
 	if err != nil {
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
 
+	if h.config.Websocket.Compression.Enabled {
+		// level is validated with config
+		_ = conn.SetCompressionLevel(h.config.Websocket.Compression.Level)
+	}
+
+	info := client.ConnectionInfo{
+		ConnectedAt: time.Now(),
+		RemoteAddr:  r.RemoteAddr,
+		UserAgent:   r.UserAgent(),
+	}
+
+	// mqtt client is added once it sends CONNECT packet
+	if conn.Subprotocol() == mqttSubprotocol {
+		capabilities := parseCapabilities(r)
+		h.connectMqtt(conn, username, r.URL.Query().Get("resource"), info, func(user string) {
+			if declared {
+				h.Negotiate(user, version, capabilities)
+			}
+		})
+		return
+	}
+
 	resource := r.URL.Query().Get("resource")
 	if resource == "" {
 		resource = utils.RandomString()
 	}
 
 	user := utils.CreateUserFromUsernameAndResource(username, resource)
-	newClient := createClient(conn, h, user)
+	clientCodec := codec.Get(conn.Subprotocol())
 
+	var newClient client.Client
+	var start func() error
+	if polled, ok := conn.NetConn().(*pollConn); ok && h.poller != nil {
+		pollClient := createPollClient(conn, polled, h.poller, user, info, clientCodec)
+		newClient = pollClient
+		start = func() error {
+			return h.poller.add(pollClient)
+		}
+	} else {
+		goroutineClient := createClient(conn, h, user, info, clientCodec)
+		newClient = goroutineClient
+		start = func() error {
+			go goroutineClient.readMessage()
+			go goroutineClient.writeMessage()
+			return nil
+		}
+	}
+
+	// writer is counted before client is visible to shutdown
+	h.writers.Add(1)
 	h.addClient(user, newClient)
 
 	// sending my initial online presence
 	h.sendPresence(true, username)
 
-	go newClient.readMessage()
-	go newClient.writeMessage()
+	if declared {
+		h.Negotiate(user, version, parseCapabilities(r))
+	}
 
+	if err := start(); err != nil {
+		log.Printf("error registering connection with netpoll: %v\n", err)
+		newClient.(*pollClient).shutdown()
+	}
 }
 
 // CreateHub creates a new hub
-func CreateHub(jwks *keyfunc.Keyfunc) *Hub {
-	return &Hub{
-		clients: make(clientList),
-		jwks:    jwks,
+// netpoll transport falls back to goroutine transport if event poller can't be created
+func CreateHub(appConfig *config.Config, jwks *keyfunc.Keyfunc) *Hub {
+	h := &Hub{
+		clients:   make(clientList),
+		jwks:      jwks,
+		config:    appConfig,
+		startedAt: time.Now(),
+		websocketUpgrader: websocket.Upgrader{
+			ReadBufferSize:  appConfig.Websocket.ReadBufferSize,
+			WriteBufferSize: appConfig.Websocket.WriteBufferSize,
+			// permessage-deflate is used only if client also supports it
+			EnableCompression: appConfig.Websocket.Compression.Enabled,
+			Subprotocols:      append(codec.Subprotocols(), mqttSubprotocol),
+			// origin is already checked before authenticating the request
+			CheckOrigin: func(*http.Request) bool { return true },
+		},
+		frames:       createFrameCache(),
+		originPolicy: createOriginPolicy(appConfig.Websocket.AllowedOrigins),
 		subscription: subscription{
 			subscriptions: make(nodeSubscription),
 		},
+		blocks:    block.NewMemoryStore(),
+		relations: relation.NewMemoryStore(),
+		devices:   push.NewMemoryDeviceStore(),
+		unread:    unread.NewMemoryStore(),
+		reactions: reaction.NewMemoryStore(appConfig.ReactionLimit),
+	}
+	h.messagePolicy = createMessagePolicy(appConfig.MessagePolicy, h.relations)
+
+	if appConfig.Websocket.Transport == config.TransportNetpoll {
+		p, err := createPoller(h)
+		if err != nil {
+			log.Printf("error creating netpoll transport, using goroutine transport: %v\n", err)
+		} else {
+			h.poller = p
+			// idle connections don't hold a write buffer
+			h.websocketUpgrader.WriteBufferPool = &sync.Pool{}
+		}
 	}
-}
\ No newline at end of file
+
+	return h
+}
+
+// SetBlockStore replaces the in memory block list, it must be called before serving clients
+func (h *Hub) SetBlockStore(store block.Store) {
+	h.blocks = store
+}
+
+// BlockStore returns users blocked and conversations muted by every user
+func (h *Hub) BlockStore() block.Store {
+	return h.blocks
+}
+
+// createMessagePolicy returns the configured direct message policy
+func createMessagePolicy(name string, store relation.Store) relation.Policy {
+	switch name {
+	case config.MessagePolicyFriends:
+		return relation.FriendsOnly(store)
+	case config.MessagePolicyRequests:
+		return relation.FriendsAndRequests(store)
+	default:
+		return relation.Anyone()
+	}
+}
+
+// SetRelationStore replaces the in memory relations and the configured policy using them
+// it must be called before serving clients
+func (h *Hub) SetRelationStore(store relation.Store) {
+	h.relations = store
+	h.messagePolicy = createMessagePolicy(h.config.MessagePolicy, store)
+}
+
+// SetMessagePolicy replaces the configured direct message policy, it must be called before serving clients
+func (h *Hub) SetMessagePolicy(policy relation.Policy) {
+	h.messagePolicy = policy
+}
+
+// Relations returns friends and message requests between users
+func (h *Hub) Relations() relation.Store {
+	return h.relations
+}
+
+// MessagePolicy returns the policy authorizing direct messages
+func (h *Hub) MessagePolicy() relation.Policy {
+	return h.messagePolicy
+}
+
+// SetUnreadStore replaces the in memory unread counts, it must be called before serving clients
+func (h *Hub) SetUnreadStore(store unread.Store) {
+	h.unread = store
+}
+
+// Unread returns unread message counts of every user
+func (h *Hub) Unread() unread.Store {
+	return h.unread
+}
+
+// SetReactionStore replaces the in memory reactions, it must be called before serving clients
+func (h *Hub) SetReactionStore(store reaction.Store) {
+	h.reactions = store
+}
+
+// Reactions returns reactions of users on messages
+func (h *Hub) Reactions() reaction.Store {
+	return h.reactions
+}
@@ -0,0 +1,312 @@
+package hub
+
+import (
+	"context"
+	"doki.co.in/doki_real_time_service/client"
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/utils"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// longPollOverflowReason is used when client doesn't poll fast enough to keep its queue in limit
+const longPollOverflowReason = "long poll queue overflow"
+
+type longPollFrame struct {
+	cursor  int64
+	message []byte
+}
+
+// longPollCloseFrame tells the client that session has ended, same as websocket close frame
+type longPollCloseFrame struct {
+	Code   int    `json:"code"`
+	Reason string `json:"reason"`
+}
+
+type longPollResponse struct {
+	Resource string              `json:"resource"`
+	Cursor   string              `json:"cursor"`
+	Messages []json.RawMessage   `json:"messages"`
+	Close    *longPollCloseFrame `json:"close,omitempty"`
+}
+
+// longPollClient is client of long polling transport
+//
+// messages stay queued till client acknowledges them by polling with the cursor of the last response
+// so a response lost on the way is sent again on the next poll
+// session is kept between polls and ends if client doesn't poll again within pong wait
+type longPollClient struct {
+	hub *Hub
+
+	// user is complete user with resource part
+	user string
+
+	clientState
+
+	sync.Mutex
+	frames []longPollFrame
+
+	// cursor is the cursor of last queued message, it starts from session creation time
+	// so that a cursor of an older session never acknowledges messages of a new one
+	cursor int64
+
+	// wake is closed and replaced when a message is queued or close is requested
+	wake    chan struct{}
+	closing *closeFrame
+	ended   bool
+
+	// polls is number of polls waiting, session expires only when no poll is waiting
+	polls  int
+	expiry *time.Timer
+
+	endOnce sync.Once
+}
+
+func createLongPollClient(hub *Hub, user string, info client.ConnectionInfo) *longPollClient {
+	c := &longPollClient{
+		hub:         hub,
+		user:        user,
+		clientState: createClientState(info, codec.Json),
+		cursor:      time.Now().UnixNano(),
+		wake:        make(chan struct{}),
+	}
+	c.expiry = time.AfterFunc(time.Duration(hub.config.Websocket.PongWait), c.end)
+
+	return c
+}
+
+// GetConnection returns nil as there is no websocket connection
+func (c *longPollClient) GetConnection() *websocket.Conn {
+	return nil
+}
+
+func (c *longPollClient) GetUserInfo() (string, string) {
+	return utils.GetUsernameAndResourceFromUser(c.user)
+}
+
+// WriteToChannel queues the message till client acknowledges it
+// session is closed if queue is full as client is not polling fast enough
+func (c *longPollClient) WriteToChannel(data *[]byte) {
+	c.Lock()
+	if c.ended || c.closing != nil {
+		c.Unlock()
+		return
+	}
+
+	if len(c.frames) >= c.hub.config.Websocket.SendQueueSize {
+		c.Unlock()
+		c.Close(websocket.CloseTryAgainLater, longPollOverflowReason)
+		return
+	}
+
+	c.cursor++
+	c.frames = append(c.frames, longPollFrame{
+		cursor:  c.cursor,
+		message: *data,
+	})
+	c.notify()
+	c.Unlock()
+}
+
+// Close sends close with the queued messages on next poll and ends the session
+func (c *longPollClient) Close(code int, reason string) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.ended || c.closing != nil {
+		return
+	}
+
+	c.closing = &closeFrame{
+		code:   code,
+		reason: reason,
+	}
+	c.notify()
+}
+
+// notify wakes up waiting polls, must be called with lock held
+func (c *longPollClient) notify() {
+	close(c.wake)
+	c.wake = make(chan struct{})
+}
+
+// acknowledge drops messages client has received, must be called with lock held
+func (c *longPollClient) acknowledge(cursor int64) {
+	acknowledged := 0
+	for acknowledged < len(c.frames) && c.frames[acknowledged].cursor <= cursor {
+		acknowledged++
+	}
+
+	c.frames = c.frames[acknowledged:]
+	if len(c.frames) == 0 {
+		c.frames = nil
+	}
+}
+
+// poll waits till there are messages after the cursor, close is requested or timeout
+func (c *longPollClient) poll(ctx context.Context, cursor int64, timeout time.Duration) longPollResponse {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	c.Lock()
+	c.polls++
+	c.expiry.Stop()
+
+	c.acknowledge(cursor)
+	for len(c.frames) == 0 && c.closing == nil && !c.ended {
+		wake := c.wake
+		c.Unlock()
+
+		expired := false
+		select {
+		case <-wake:
+		case <-timer.C:
+			expired = true
+		case <-ctx.Done():
+			expired = true
+		}
+
+		c.Lock()
+		if expired {
+			break
+		}
+	}
+
+	_, resource := c.GetUserInfo()
+	response := longPollResponse{
+		Resource: resource,
+		Cursor:   strconv.FormatInt(cursor, 10),
+		Messages: make([]json.RawMessage, 0, len(c.frames)),
+	}
+
+	version := c.protocolVersion()
+	for _, frame := range c.frames {
+		response.Cursor = strconv.FormatInt(frame.cursor, 10)
+
+		message, err := encodeFrame(frame.message, codec.Json, version)
+		if err != nil || message == nil {
+			// message which can't be sent to this client is acknowledged with the rest
+			continue
+		}
+		response.Messages = append(response.Messages, message)
+	}
+
+	closing := c.closing
+	if closing != nil {
+		response.Close = &longPollCloseFrame{
+			Code:   closing.code,
+			Reason: closing.reason,
+		}
+	}
+
+	c.polls--
+	if c.polls == 0 && !c.ended {
+		c.expiry.Reset(time.Duration(c.hub.config.Websocket.PongWait))
+	}
+	c.Unlock()
+
+	if closing != nil {
+		c.end()
+	}
+
+	return response
+}
+
+// end removes the session from the hub
+func (c *longPollClient) end() {
+	c.endOnce.Do(func() {
+		c.Lock()
+		c.ended = true
+		c.frames = nil
+		c.expiry.Stop()
+		c.notify()
+		c.Unlock()
+
+		c.hub.removeClient(c)
+		c.hub.writers.Done()
+	})
+}
+
+// longPollSession returns the session of user, creating one if there is none
+// lookup and insert are done under one lock so that concurrent first polls share a session
+func (h *Hub) longPollSession(user string, info client.ConnectionInfo) (*longPollClient, bool) {
+	username, resource := utils.GetUsernameAndResourceFromUser(user)
+
+	h.Lock()
+	defer h.Unlock()
+
+	if session, ok := h.clients[username][resource].(*longPollClient); ok {
+		return session, false
+	}
+
+	session := createLongPollClient(h, user, info)
+
+	// session is counted as writer till its close is delivered or it expires
+	h.writers.Add(1)
+	h.putClient(user, session)
+
+	return session, true
+}
+
+// ServePoll is long polling transport for clients which can use neither websocket nor server sent events
+// a poll returns queued messages or waits for them till long poll timeout
+// client sends the cursor of last response with next poll, messages after it are sent again
+// client sends its payloads through [Hub.ServeSend]
+func (h *Hub) ServePoll(w http.ResponseWriter, r *http.Request) {
+	username, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	version, declared, err := parseProtocolVersion(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.longPoll(w, r, username, version, declared)
+}
+
+func (h *Hub) longPoll(w http.ResponseWriter, r *http.Request, username string, version int, declared bool) {
+	var cursor int64
+	if value := r.URL.Query().Get("cursor"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+		cursor = parsed
+	}
+
+	resource := r.URL.Query().Get("resource")
+	if resource == "" {
+		resource = utils.RandomString()
+	}
+	user := utils.CreateUserFromUsernameAndResource(username, resource)
+
+	info := client.ConnectionInfo{
+		ConnectedAt: time.Now(),
+		RemoteAddr:  r.RemoteAddr,
+		UserAgent:   r.UserAgent(),
+	}
+	session, created := h.longPollSession(user, info)
+
+	if created {
+		// sending my initial online presence
+		h.sendPresence(true, username)
+
+		if declared {
+			h.Negotiate(user, version, parseCapabilities(r))
+		}
+	}
+
+	response := session.poll(r.Context(), cursor, time.Duration(h.config.Websocket.LongPollTimeout))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,142 @@
+package hub
+
+import (
+	"context"
+	"doki.co.in/doki_real_time_service/client"
+	"doki.co.in/doki_real_time_service/config"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type testLongPollResponse struct {
+	Resource string              `json:"resource"`
+	Cursor   string              `json:"cursor"`
+	Messages []map[string]any    `json:"messages"`
+	Close    *longPollCloseFrame `json:"close"`
+}
+
+func longPollRequest(t *testing.T, server *httptest.Server, query string) testLongPollResponse {
+	response, err := http.Get(server.URL + "/poll?" + query)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	var decoded testLongPollResponse
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&decoded))
+	return decoded
+}
+
+func TestLongPollTransport(t *testing.T) {
+	h, server := createHttpTransportServer()
+	defer server.Close()
+	h.config.Websocket.LongPollTimeout = config.Duration(200 * time.Millisecond)
+
+	// first polls create the sessions, bob declared version so he gets welcome right away
+	alice := longPollRequest(t, server, "user=alice&resource=webview")
+	assert.Equal(t, "webview", alice.Resource)
+	assert.Empty(t, alice.Messages)
+	bob := longPollRequest(t, server, "user=bob&resource=webview&version=2")
+	require.Len(t, bob.Messages, 1)
+	assert.Equal(t, "welcome", bob.Messages[0]["type"])
+	assert.Len(t, h.ConnectedResources("alice"), 1)
+
+	cursor := bob.Cursor
+	polled := make(chan testLongPollResponse, 1)
+	go func() {
+		polled <- longPollRequest(t, server, "user=bob&resource=webview&cursor="+cursor)
+	}()
+
+	message := `{"type":"chat_message","from":"alice","to":"bob","id":"0193b0c4-7d4e-7c3a-9f1e-2a6b1c8d9e0f",` +
+		`"subject":"text","body":"hello over long polling","sendAt":"2025-01-20T10:15:30.123Z"}`
+	assert.Equal(t, http.StatusAccepted, postPayload(t, server, "user=alice&resource=webview", message))
+
+	var received testLongPollResponse
+	select {
+	case received = <-polled:
+	case <-time.After(time.Second):
+		t.Fatal("waiting poll did not return the message")
+	}
+	require.Len(t, received.Messages, 1)
+	assert.Equal(t, "hello over long polling", received.Messages[0]["body"])
+	assert.NotEqual(t, cursor, received.Cursor)
+
+	// response is lost, polling with older cursor sends the message again
+	again := longPollRequest(t, server, "user=bob&resource=webview&cursor="+cursor)
+	require.Len(t, again.Messages, 1)
+	assert.Equal(t, received.Cursor, again.Cursor)
+
+	acknowledged := longPollRequest(t, server, "user=bob&resource=webview&cursor="+received.Cursor)
+	assert.Empty(t, acknowledged.Messages)
+	assert.Equal(t, received.Cursor, acknowledged.Cursor)
+
+	go func() {
+		polled <- longPollRequest(t, server, "user=bob&resource=webview&cursor="+received.Cursor)
+	}()
+	assert.Eventually(t, func() bool {
+		session := h.ConnectedResources("bob")["webview"].(*longPollClient)
+		session.Lock()
+		defer session.Unlock()
+		return session.polls == 1
+	}, time.Second, 5*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	// alice is not polling, her session is drained only when it expires
+	_ = h.Shutdown(ctx)
+
+	closed := <-polled
+	require.NotNil(t, closed.Close)
+	assert.Equal(t, websocket.CloseServiceRestart, closed.Close.Code)
+	require.Len(t, closed.Messages, 1)
+	assert.Equal(t, "server_shutdown", closed.Messages[0]["type"])
+	assert.Empty(t, h.ConnectedResources("bob"))
+}
+
+func TestLongPollSessionExpires(t *testing.T) {
+	h, server := createHttpTransportServer()
+	defer server.Close()
+	h.config.Websocket.LongPollTimeout = config.Duration(200 * time.Millisecond)
+	h.config.Websocket.PongWait = config.Duration(200 * time.Millisecond)
+
+	longPollRequest(t, server, "user=alice&resource=webview")
+	assert.Len(t, h.ConnectedResources("alice"), 1)
+
+	assert.Eventually(t, func() bool {
+		return len(h.ConnectedResources("alice")) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestConcurrentFirstPollsShareSession(t *testing.T) {
+	h := createTestHub()
+
+	sessions := make(chan *longPollClient, 16)
+	created := make(chan bool, cap(sessions))
+	for range cap(sessions) {
+		go func() {
+			session, ok := h.longPollSession("alice@webview", client.ConnectionInfo{})
+			sessions <- session
+			created <- ok
+		}()
+	}
+
+	first := <-sessions
+	creations := 0
+	for range cap(sessions) {
+		if <-created {
+			creations++
+		}
+	}
+	for range cap(sessions) - 1 {
+		assert.Same(t, first, <-sessions)
+	}
+	assert.Equal(t, 1, creations)
+	assert.Same(t, first, h.GetIndividualClient("alice@webview"))
+
+	first.end()
+}
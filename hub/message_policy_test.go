@@ -0,0 +1,110 @@
+package hub
+
+import (
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/config"
+	"doki.co.in/doki_real_time_service/relation"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func chatFrom(from, to, body string) []byte {
+	return []byte(`{"type":"chat_message","from":"` + from + `","to":"` + to + `","id":"1","subject":"text","body":"` + body + `","sendAt":"2025-01-20T10:15:30Z"}`)
+}
+
+// lastFrame returns the last frame written to the client as json
+func lastFrame(t *testing.T, c *fakeClient) map[string]any {
+	require.NotEmpty(t, c.written)
+
+	var frame map[string]any
+	require.NoError(t, json.Unmarshal(c.written[len(c.written)-1], &frame))
+	return frame
+}
+
+func TestMessageRequestFlow(t *testing.T) {
+	h, clients := createRoutingHub("alice@phone", "alice@web", "bob@phone", "bob@web")
+	h.SetMessagePolicy(relation.FriendsAndRequests(h.Relations()))
+
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "hi"), "alice", "phone", codec.Json))
+	assert.Equal(t, map[string]int{"alice@web": 1, "bob@phone": 1, "bob@web": 1}, received(clients))
+	assert.Equal(t, "chat_message", lastFrame(t, clients["alice@web"])["type"])
+
+	request := lastFrame(t, clients["bob@phone"])
+	assert.Equal(t, "message_request", request["type"])
+	assert.Equal(t, "alice", request["from"])
+	assert.Equal(t, "hi", request["message"].(map[string]any)["body"])
+	assert.Equal(t, relation.RequestPending, h.Relations().RequestStatus("bob", "alice"))
+
+	// signals don't start a message request
+	require.NoError(t, h.receive([]byte(`{"type":"typing_status","from":"alice","to":"bob"}`), "alice", "phone", codec.Json))
+	assert.Len(t, clients["bob@phone"].written, 1)
+
+	require.NoError(t, h.receive([]byte(`{"type":"message_request_response","from":"bob","user":"alice","accept":true}`), "bob", "phone", codec.Json))
+	assert.Equal(t, "message_request_response", lastFrame(t, clients["alice@phone"])["type"])
+	assert.Equal(t, "message_request_response", lastFrame(t, clients["bob@web"])["type"])
+
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "again"), "alice", "phone", codec.Json))
+	assert.Equal(t, "again", lastFrame(t, clients["bob@phone"])["body"])
+
+	require.NoError(t, h.receive(chatFrom("bob", "alice", "reply"), "bob", "phone", codec.Json))
+	assert.Equal(t, "reply", lastFrame(t, clients["alice@phone"])["body"])
+}
+
+func TestDeclinedMessageRequest(t *testing.T) {
+	h, clients := createRoutingHub("alice@phone", "bob@phone")
+	h.SetMessagePolicy(relation.FriendsAndRequests(h.Relations()))
+
+	// response without a request is ignored
+	require.NoError(t, h.receive([]byte(`{"type":"message_request_response","from":"bob","user":"alice","accept":true}`), "bob", "phone", codec.Json))
+	assert.Equal(t, relation.RequestNone, h.Relations().RequestStatus("bob", "alice"))
+
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "hi"), "alice", "phone", codec.Json))
+	require.NoError(t, h.receive([]byte(`{"type":"message_request_response","from":"bob","user":"alice"}`), "bob", "phone", codec.Json))
+
+	// declined user is not told, further messages are rejected
+	assert.Empty(t, clients["alice@phone"].written)
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "please"), "alice", "phone", codec.Json))
+	assert.Len(t, clients["bob@phone"].written, 1)
+	assert.Equal(t, "message_policy_rejected", lastFrame(t, clients["alice@phone"])["code"])
+}
+
+func TestFriendsOnlyPolicy(t *testing.T) {
+	testConfig := config.Default()
+	testConfig.MessagePolicy = config.MessagePolicyFriends
+	h := CreateHub(testConfig, nil)
+
+	alice := &fakeClient{user: "alice@phone"}
+	bob := &fakeClient{user: "bob@phone"}
+	h.addClient("alice@phone", alice)
+	h.addClient("bob@phone", bob)
+
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "hi"), "alice", "phone", codec.Json))
+	assert.Empty(t, bob.written)
+
+	// accepting a friend request never sent through the service doesn't make friends
+	accept := `{"type":"user_accepted_friend_request","from":"bob","to":"alice","requestedBy":"alice","addedOn":"2025-01-20T10:15:30Z"}`
+	require.NoError(t, h.receive([]byte(accept), "bob", "phone", codec.Json))
+	assert.False(t, h.Relations().AreFriends("alice", "bob"))
+
+	request := `{"type":"user_send_friend_request","from":"alice","to":"bob","requestedBy":"alice","addedOn":"2025-01-20T10:15:30Z"}`
+	require.NoError(t, h.receive([]byte(request), "alice", "phone", codec.Json))
+	require.NoError(t, h.receive([]byte(accept), "bob", "phone", codec.Json))
+	assert.True(t, h.Relations().AreFriends("alice", "bob"))
+
+	written := len(bob.written)
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "hi"), "alice", "phone", codec.Json))
+	assert.Len(t, bob.written, written+1)
+
+	require.NoError(t, h.receive([]byte(`{"type":"user_removes_friend_relation","from":"bob","to":"alice"}`), "bob", "phone", codec.Json))
+	written = len(bob.written)
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "hi"), "alice", "phone", codec.Json))
+	assert.Len(t, bob.written, written)
+	assert.Equal(t, map[string]any{"type": "error", "to": "alice", "id": "1", "code": "message_policy_rejected", "reason": "Recipient doesn't accept messages from you."}, lastFrame(t, alice))
+
+	// typing status has no id to report, so it is dropped silently
+	written = len(alice.written)
+	require.NoError(t, h.receive([]byte(`{"type":"typing_status","from":"alice","to":"bob"}`), "alice", "phone", codec.Json))
+	assert.Len(t, alice.written, written)
+}
@@ -0,0 +1,133 @@
+package hub
+
+import (
+	"context"
+	"doki.co.in/doki_real_time_service/moderation"
+	"doki.co.in/doki_real_time_service/payload"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// SetModeration checks chat and edit messages with the moderator before they are routed
+// strikes mute users whose messages are redacted or rejected too often, it must be called before serving clients
+func (h *Hub) SetModeration(moderator moderation.Moderator, strikes *moderation.Strikes) {
+	h.moderator = moderator
+	h.strikes = strikes
+	h.review = moderation.NewReviewQueue(h.config.Moderation.ReviewQueueSize)
+}
+
+// Moderate returns verdict of the moderation pipeline, messages of muted users are rejected without checking them
+func (h *Hub) Moderate(message moderation.Message) moderation.Verdict {
+	if h.moderator == nil {
+		return moderation.Verdict{Action: moderation.Allow, Text: message.Text}
+	}
+
+	if h.strikes != nil {
+		if until, muted := h.strikes.MutedUntil(message.From); muted {
+			return moderation.Verdict{
+				Action: moderation.Reject,
+				Reason: fmt.Sprintf("Sending messages is muted until %v.", until.UTC().Format(time.RFC3339)),
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(h.config.Moderation.Timeout))
+	defer cancel()
+
+	verdict, err := h.moderator.Moderate(ctx, message)
+	if err != nil {
+		log.Printf("error moderating message: %v\n", err)
+	}
+
+	if verdict.Action == moderation.Redact || verdict.Action == moderation.Reject {
+		h.strike(message.From)
+	}
+
+	return verdict
+}
+
+// HoldForReview keeps the message until admin releases or discards it
+func (h *Hub) HoldForReview(held moderation.Held) bool {
+	if h.review == nil {
+		return false
+	}
+
+	return h.review.Hold(held)
+}
+
+// strike counts a strike of the user, user is muted once strikes reach the limit
+func (h *Hub) strike(user string) {
+	if h.strikes == nil {
+		return
+	}
+
+	if until, muted := h.strikes.Add(user); muted {
+		log.Printf("user %v is muted until %v\n", user, until.UTC().Format(time.RFC3339))
+	}
+}
+
+func (h *Hub) listHeld(w http.ResponseWriter, _ *http.Request) {
+	held := make([]moderation.Held, 0)
+	if h.review != nil {
+		held = h.review.List()
+	}
+
+	writeJson(w, http.StatusOK, map[string]any{
+		"held": held,
+	})
+}
+
+// releaseHeld sends the held message as if it was allowed
+func (h *Hub) releaseHeld(w http.ResponseWriter, r *http.Request) {
+	held, ok := h.takeHeld(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "message not held", http.StatusNotFound)
+		return
+	}
+
+	data := []byte(held.Payload)
+	if err := payload.ReleasePayload(data, held.Message.From, held.Resource, h); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	writeJson(w, http.StatusOK, map[string]any{
+		"released": held.Id,
+	})
+}
+
+// discardHeld drops the held message, it counts as a strike of the sender
+func (h *Hub) discardHeld(w http.ResponseWriter, r *http.Request) {
+	held, ok := h.takeHeld(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "message not held", http.StatusNotFound)
+		return
+	}
+	h.strike(held.Message.From)
+
+	writeJson(w, http.StatusOK, map[string]any{
+		"discarded": held.Id,
+	})
+}
+
+// unmuteUser lifts the mute of the user before it is over
+func (h *Hub) unmuteUser(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("username")
+	if h.strikes != nil {
+		h.strikes.Unmute(username)
+	}
+
+	writeJson(w, http.StatusOK, map[string]any{
+		"unmuted": username,
+	})
+}
+
+func (h *Hub) takeHeld(id string) (moderation.Held, bool) {
+	if h.review == nil {
+		return moderation.Held{}, false
+	}
+
+	return h.review.Take(id)
+}
@@ -0,0 +1,104 @@
+package hub
+
+import (
+	"context"
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/moderation"
+	"doki.co.in/doki_real_time_service/payload"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createModerationHub(t *testing.T, users ...string) (*Hub, map[string]*fakeClient) {
+	h, clients := createRoutingHub(users...)
+
+	words, err := moderation.NewWordFilter([]string{"darn"}, nil, moderation.Redact)
+	require.NoError(t, err)
+	rejectSpam := moderation.ModeratorFunc(func(_ context.Context, message moderation.Message) (moderation.Verdict, error) {
+		switch message.Text {
+		case "spam":
+			return moderation.Verdict{Action: moderation.Reject, Reason: "Message is spam."}, nil
+		case "review":
+			return moderation.Verdict{Action: moderation.Hold}, nil
+		}
+		return moderation.Verdict{Action: moderation.Allow, Text: message.Text}, nil
+	})
+	h.SetModeration(moderation.Pipeline{words, rejectSpam}, moderation.NewStrikes(3, time.Hour, time.Hour))
+
+	return h, clients
+}
+
+func TestModerationRedactAndReject(t *testing.T) {
+	h, clients := createModerationHub(t, "alice@phone", "alice@web", "bob@phone")
+
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "darn it"), "alice", "phone", codec.Json))
+	assert.Equal(t, "**** it", lastFrame(t, clients["bob@phone"])["body"])
+	assert.Equal(t, "**** it", lastFrame(t, clients["alice@web"])["body"])
+	assert.Contains(t, lastFrame(t, clients["bob@phone"]), "meta")
+
+	// redacted field is replaced in place, other members keep their bytes and order
+	assert.Equal(t, string(chatFrom("alice", "bob", "**** it")), string(payload.AdaptForVersion(clients["bob@phone"].written[0], 2)))
+
+	edit := `{"type":"edit_message","from":"alice","to":"bob","id":"1","body":"darn","editedOn":"2025-01-20T10:15:30Z"}`
+	require.NoError(t, h.receive([]byte(edit), "alice", "phone", codec.Json))
+	assert.Equal(t, "****", lastFrame(t, clients["bob@phone"])["body"])
+
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "spam"), "alice", "phone", codec.Json))
+	assert.Equal(t, map[string]int{"alice@phone": 1, "alice@web": 2, "bob@phone": 2}, received(clients))
+
+	errorFrame := lastFrame(t, clients["alice@phone"])
+	assert.Equal(t, "error", errorFrame["type"])
+	assert.Equal(t, "moderation_rejected", errorFrame["code"])
+	assert.Equal(t, "1", errorFrame["id"])
+	assert.Equal(t, "Message is spam.", errorFrame["reason"])
+
+	// third strike mutes alice, even clean messages are rejected
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "hello"), "alice", "phone", codec.Json))
+	assert.Len(t, clients["alice@phone"].written, 2)
+	assert.Contains(t, lastFrame(t, clients["alice@phone"])["reason"], "muted until")
+	assert.Len(t, clients["bob@phone"].written, 2)
+
+	assert.Equal(t, http.StatusOK, adminRequest(h, http.MethodDelete, "/admin/moderation/mutes/alice", testAdminKey).Code)
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "hello"), "alice", "phone", codec.Json))
+	assert.Equal(t, "hello", lastFrame(t, clients["bob@phone"])["body"])
+}
+
+func TestModerationHoldForReview(t *testing.T) {
+	h, clients := createModerationHub(t, "alice@phone", "bob@phone")
+
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "review"), "alice", "phone", codec.Json))
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "review"), "alice", "phone", codec.Json))
+	assert.Empty(t, received(clients))
+
+	var list struct {
+		Held []moderation.Held `json:"held"`
+	}
+	rec := adminRequest(h, http.MethodGet, "/admin/moderation/held", testAdminKey)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Held, 2)
+	assert.Equal(t, "review", list.Held[0].Message.Text)
+	assert.Equal(t, "phone", list.Held[0].Resource)
+
+	rec = adminRequest(h, http.MethodPost, "/admin/moderation/held/"+list.Held[0].Id+"/release", testAdminKey)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "review", lastFrame(t, clients["bob@phone"])["body"])
+	assert.Equal(t, http.StatusNotFound, adminRequest(h, http.MethodPost, "/admin/moderation/held/"+list.Held[0].Id+"/release", testAdminKey).Code)
+
+	assert.Equal(t, http.StatusOK, adminRequest(h, http.MethodDelete, "/admin/moderation/held/"+list.Held[1].Id, testAdminKey).Code)
+	assert.Equal(t, map[string]int{"bob@phone": 1}, received(clients))
+	assert.Empty(t, h.review.List())
+}
+
+func TestModerationDisabled(t *testing.T) {
+	h, clients := createRoutingHub("alice@phone", "bob@phone")
+
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "spam"), "alice", "phone", codec.Json))
+	assert.Equal(t, "spam", lastFrame(t, clients["bob@phone"])["body"])
+	assert.Equal(t, http.StatusNotFound, adminRequest(h, http.MethodDelete, "/admin/moderation/held/1", testAdminKey).Code)
+}
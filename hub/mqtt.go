@@ -0,0 +1,499 @@
+package hub
+
+import (
+	"bufio"
+	"doki.co.in/doki_real_time_service/client"
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/utils"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/websocket"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mqttSubprotocol is the websocket subprotocol of mqtt clients
+const mqttSubprotocol = "mqtt"
+
+// topic kinds, every topic maps to routing of the hub
+//
+//	users/<name>/inbox  payloads sent to the user
+//	nodes/<id>          node updates, same as Hub.Subscribe
+//	presence/<name>     presence of the user
+const (
+	mqttInboxTopic    = "users"
+	mqttNodeTopic     = "nodes"
+	mqttPresenceTopic = "presence"
+)
+
+// payload types which are published on their own topic instead of inbox
+const (
+	mqttPresenceInfoType    = "user_presence_info"
+	mqttPollVotesUpdateType = "poll_votes_update"
+)
+
+var (
+	errMqttUnexpectedMessage = errors.New("mqtt packets must be sent in binary messages")
+	errMqttUnsupportedQos    = errors.New("mqtt qos 2 is not supported")
+)
+
+// mqttClient is client speaking mqtt 3.1.1 over websocket, payloads are the same json as websocket clients
+//
+// every session is clean and server publishes with qos 0, messages of topics which client
+// hasn't subscribed are dropped
+type mqttClient struct {
+	connection *websocket.Conn
+	hub        *Hub
+
+	// user is complete user with resource part, it is set once CONNECT packet is accepted
+	user string
+
+	write chan mqttOutgoing
+
+	clientState
+
+	topicsLock sync.RWMutex
+	topics     map[string]bool
+
+	closeRequest chan closeFrame
+	closeOnce    sync.Once
+
+	// received is closed when reader exits
+	received chan struct{}
+
+	// done is closed when writer exits, nothing is written to connection after that
+	done chan struct{}
+}
+
+// mqttOutgoing is either a payload to publish or a control packet replying to the client
+type mqttOutgoing struct {
+	message []byte
+	packet  []byte
+}
+
+func createMqttClient(conn *websocket.Conn, hub *Hub, info client.ConnectionInfo) *mqttClient {
+	return &mqttClient{
+		connection:   conn,
+		hub:          hub,
+		write:        make(chan mqttOutgoing, hub.config.Websocket.SendQueueSize),
+		clientState:  createClientState(info, codec.Json),
+		topics:       make(map[string]bool),
+		closeRequest: make(chan closeFrame, 1),
+		received:     make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+}
+
+func (c *mqttClient) GetConnection() *websocket.Conn {
+	return c.connection
+}
+
+func (c *mqttClient) GetUserInfo() (string, string) {
+	return utils.GetUsernameAndResourceFromUser(c.user)
+}
+
+func (c *mqttClient) WriteToChannel(data *[]byte) {
+	c.queue(mqttOutgoing{message: *data})
+}
+
+func (c *mqttClient) queue(outgoing mqttOutgoing) {
+	select {
+	case c.write <- outgoing:
+	case <-c.done:
+	}
+}
+
+// Close asks writer to send the queued messages and close the connection, mqtt has no packet for it
+// so client only gets the websocket close frame
+func (c *mqttClient) Close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.closeRequest <- closeFrame{
+			code:   code,
+			reason: reason,
+		}
+	})
+}
+
+// connectMqtt serves upgraded connection which negotiated mqtt subprotocol
+// client is added to hub once it sends CONNECT packet, resource query param is used as resource
+// and client identifier is used if it is not set
+func (h *Hub) connectMqtt(conn *websocket.Conn, username, resource string, info client.ConnectionInfo, negotiate func(user string)) {
+	c := createMqttClient(conn, h, info)
+
+	if polled, ok := conn.NetConn().(*pollConn); ok {
+		// netpoll connections are read by the client goroutine same as goroutine transport
+		writeWait := time.Duration(h.config.Websocket.WriteWait)
+		polled.onControl = func(opcode int, payload []byte) error {
+			return answerControl(conn, writeWait, opcode, payload)
+		}
+	}
+
+	go c.readMessage(username, resource, negotiate)
+}
+
+// readMessage reads packets till connection is closed or client disconnects
+func (c *mqttClient) readMessage(username, resource string, negotiate func(user string)) {
+	registered := false
+	defer func() {
+		close(c.received)
+		if registered {
+			c.hub.removeClient(c)
+		} else {
+			_ = c.connection.Close()
+		}
+	}()
+
+	limit := c.hub.config.Websocket.IncomingPayloadLimit
+	keepAlive := time.Duration(c.hub.config.Websocket.PongWait)
+	c.connection.SetReadLimit(limit)
+	reader := bufio.NewReader(&mqttStream{conn: c.connection})
+
+	// first packet must be CONNECT
+	_ = c.connection.SetReadDeadline(time.Now().Add(keepAlive))
+	packet, err := readMqttPacket(reader, limit)
+	if err != nil || packet.kind != mqttConnect {
+		return
+	}
+
+	connect, err := parseMqttConnect(packet.body)
+	if err != nil {
+		return
+	}
+
+	if resource == "" {
+		resource = connect.clientId
+	}
+	if returnCode := c.hub.acceptMqtt(connect, username, resource); returnCode != mqttConnectionAccepted {
+		_ = c.connection.SetWriteDeadline(time.Now().Add(time.Duration(c.hub.config.Websocket.WriteWait)))
+		_ = c.connection.WriteMessage(websocket.BinaryMessage, createMqttConnack(returnCode).encode())
+		return
+	}
+
+	if resource == "" {
+		resource = utils.RandomString()
+	}
+	if connect.keepAlive > 0 {
+		// client is disconnected after one and a half keep alive without any packet
+		keepAlive = time.Duration(connect.keepAlive) * time.Second * 3 / 2
+	}
+
+	c.user = utils.CreateUserFromUsernameAndResource(username, resource)
+
+	// writer is counted before client is visible to shutdown
+	c.hub.writers.Add(1)
+	go c.writeMessage()
+	c.queue(mqttOutgoing{packet: createMqttConnack(mqttConnectionAccepted).encode()})
+
+	c.hub.addClient(c.user, c)
+	registered = true
+
+	// sending my initial online presence
+	c.hub.sendPresence(true, username)
+	negotiate(c.user)
+
+	for {
+		_ = c.connection.SetReadDeadline(time.Now().Add(keepAlive))
+		packet, err := readMqttPacket(reader, limit)
+		if err != nil {
+			return
+		}
+
+		if err := c.handlePacket(packet, username, resource); err != nil {
+			return
+		}
+	}
+}
+
+// acceptMqtt returns connack return code for the CONNECT packet of authenticated user
+func (h *Hub) acceptMqtt(connect mqttConnectPacket, username, resource string) byte {
+	if connect.protocol != mqttProtocolName || connect.level != mqttProtocolLevel {
+		return mqttUnacceptableProtocol
+	}
+
+	// sessions are never kept, so client must have an identifier to ask for one
+	if connect.clientId == "" && connect.flags&mqttCleanSessionFlag == 0 {
+		return mqttIdentifierRejected
+	}
+	if strings.Contains(resource, "@") {
+		return mqttIdentifierRejected
+	}
+
+	// username is optional as token already identifies the user
+	if connect.username != "" && connect.username != username {
+		return mqttNotAuthorized
+	}
+
+	if h.shuttingDown.Load() {
+		return mqttServerUnavailable
+	}
+
+	return mqttConnectionAccepted
+}
+
+// handlePacket handles packets after CONNECT, returned error closes the connection
+func (c *mqttClient) handlePacket(packet mqttPacket, username, resource string) error {
+	switch packet.kind {
+	case mqttPublish:
+		publish, err := parseMqttPublish(packet.flags, packet.body)
+		if err != nil {
+			return err
+		}
+		if publish.qos > 1 {
+			return errMqttUnsupportedQos
+		}
+
+		c.publish(publish, username, resource)
+		if publish.qos == 1 {
+			// publish is acknowledged even if it is dropped as there is no negative acknowledgement
+			c.queue(mqttOutgoing{packet: createMqttAck(mqttPuback, publish.packetId).encode()})
+		}
+
+	case mqttSubscribe, mqttUnsubscribe:
+		packetId, filters, err := parseMqttSubscribe(packet.kind, packet.body)
+		if err != nil {
+			return err
+		}
+
+		if packet.kind == mqttUnsubscribe {
+			for _, filter := range filters {
+				c.unsubscribe(filter.topic)
+			}
+			c.queue(mqttOutgoing{packet: createMqttAck(mqttUnsuback, packetId).encode()})
+			return nil
+		}
+
+		returnCodes := make([]byte, 0, len(filters))
+		for _, filter := range filters {
+			returnCodes = append(returnCodes, c.subscribe(filter.topic, username))
+		}
+		c.queue(mqttOutgoing{packet: createMqttAck(mqttSuback, packetId, returnCodes...).encode()})
+
+	case mqttPingreq:
+		c.queue(mqttOutgoing{packet: mqttPacket{kind: mqttPingresp}.encode()})
+
+	case mqttDisconnect:
+		return io.EOF
+
+	default:
+		return errMqttMalformedPacket
+	}
+
+	return nil
+}
+
+// subscribe adds topic and returns suback return code, qos 0 is granted for every topic
+// node and presence topics are subscribed in hub, inbox can be subscribed only by its user
+func (c *mqttClient) subscribe(topic, username string) byte {
+	kind, name, ok := parseMqttTopic(topic)
+	if !ok || (kind == mqttInboxTopic && name != username) {
+		return mqttSubscriptionFailure
+	}
+
+	// topic is added first so that initial presence is not dropped
+	c.topicsLock.Lock()
+	c.topics[topic] = true
+	c.topicsLock.Unlock()
+
+	switch kind {
+	case mqttNodeTopic:
+		c.hub.Subscribe(name, c.user, false)
+	case mqttPresenceTopic:
+		c.hub.Subscribe(name, c.user, true)
+	}
+
+	return 0
+}
+
+func (c *mqttClient) unsubscribe(topic string) {
+	c.topicsLock.Lock()
+	subscribed := c.topics[topic]
+	delete(c.topics, topic)
+	c.topicsLock.Unlock()
+
+	kind, name, ok := parseMqttTopic(topic)
+	if !subscribed || !ok || kind == mqttInboxTopic {
+		return
+	}
+
+	c.hub.Unsubscribe(name, c.user)
+}
+
+func (c *mqttClient) subscribed(topic string) bool {
+	c.topicsLock.RLock()
+	defer c.topicsLock.RUnlock()
+
+	return c.topics[topic]
+}
+
+// publish sends the payload if it belongs to the topic
+// inbox payloads must be sent to the inbox user, payloads without recipient can be published
+// only on own inbox, node payloads must be for the node and presence is published by server only
+func (c *mqttClient) publish(publish mqttPublishPacket, username, resource string) {
+	kind, name, ok := parseMqttTopic(publish.topic)
+	if !ok {
+		return
+	}
+
+	var base struct {
+		To     string `json:"to"`
+		PollId string `json:"pollId"`
+		NodeId string `json:"nodeId"`
+	}
+	if err := json.Unmarshal(publish.payload, &base); err != nil {
+		return
+	}
+
+	switch kind {
+	case mqttInboxTopic:
+		if (base.To != "" && base.To != name) || (base.To == "" && name != username) {
+			return
+		}
+	case mqttNodeTopic:
+		if base.PollId != name && base.NodeId != name {
+			return
+		}
+	default:
+		return
+	}
+
+	if err := c.hub.receive(publish.payload, username, resource, codec.Json); err != nil {
+		//log.Println(err.Error())
+	}
+}
+
+// writeMessage writes queued packets and messages till connection is closed
+func (c *mqttClient) writeMessage() {
+	defer func() {
+		close(c.done)
+		_ = c.connection.Close()
+		c.hub.writers.Done()
+	}()
+
+	for {
+		select {
+		case outgoing := <-c.write:
+			if err := c.send(outgoing); err != nil {
+				return
+			}
+
+		case frame := <-c.closeRequest:
+			c.drainQueue()
+
+			_ = c.connection.SetWriteDeadline(time.Now().Add(time.Duration(c.hub.config.Websocket.WriteWait)))
+			closeMessage := websocket.FormatCloseMessage(frame.code, frame.reason)
+			_ = c.connection.WriteMessage(websocket.CloseMessage, closeMessage)
+			return
+
+		case <-c.received:
+			return
+		}
+	}
+}
+
+// send writes a single packet, messages are published on their topic in client protocol version
+func (c *mqttClient) send(outgoing mqttOutgoing) error {
+	data := outgoing.packet
+	if outgoing.message != nil {
+		username, _ := c.GetUserInfo()
+		topic := messageTopic(outgoing.message, username)
+		if !c.subscribed(topic) {
+			return nil
+		}
+
+		frame, err := encodeFrame(outgoing.message, codec.Json, c.protocolVersion())
+		if err != nil || frame == nil {
+			return nil
+		}
+		data = createMqttPublish(topic, frame).encode()
+	}
+
+	_ = c.connection.SetWriteDeadline(time.Now().Add(time.Duration(c.hub.config.Websocket.WriteWait)))
+	return c.connection.WriteMessage(websocket.BinaryMessage, data)
+}
+
+func (c *mqttClient) drainQueue() {
+	for {
+		select {
+		case outgoing := <-c.write:
+			if err := c.send(outgoing); err != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// messageTopic returns topic on which message is published to the user
+func messageTopic(message []byte, username string) string {
+	var base struct {
+		Type   string `json:"type"`
+		User   string `json:"user"`
+		PollId string `json:"pollId"`
+	}
+	_ = json.Unmarshal(message, &base)
+
+	switch base.Type {
+	case mqttPresenceInfoType:
+		return mqttPresenceTopic + "/" + base.User
+	case mqttPollVotesUpdateType:
+		return mqttNodeTopic + "/" + base.PollId
+	}
+
+	return mqttInboxTopic + "/" + username + "/inbox"
+}
+
+// parseMqttTopic returns kind and name of topic, wildcards are not supported
+func parseMqttTopic(topic string) (string, string, bool) {
+	if strings.ContainsAny(topic, "+#") {
+		return "", "", false
+	}
+
+	parts := strings.Split(topic, "/")
+	switch {
+	case len(parts) == 3 && parts[0] == mqttInboxTopic && parts[2] == "inbox":
+	case len(parts) == 2 && (parts[0] == mqttNodeTopic || parts[0] == mqttPresenceTopic):
+	default:
+		return "", "", false
+	}
+
+	if parts[1] == "" {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
+
+// mqttStream reads packets as a stream as single websocket message can have multiple or partial packets
+type mqttStream struct {
+	conn    *websocket.Conn
+	current io.Reader
+}
+
+func (s *mqttStream) Read(p []byte) (int, error) {
+	for {
+		if s.current == nil {
+			messageType, reader, err := s.conn.NextReader()
+			if err != nil {
+				return 0, err
+			}
+			if messageType != websocket.BinaryMessage {
+				return 0, errMqttUnexpectedMessage
+			}
+			s.current = reader
+		}
+
+		n, err := s.current.Read(p)
+		if errors.Is(err, io.EOF) {
+			s.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+
+		return n, err
+	}
+}
@@ -0,0 +1,281 @@
+package hub
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// mqtt 3.1.1 control packet types
+const (
+	mqttConnect     = 1
+	mqttConnack     = 2
+	mqttPublish     = 3
+	mqttPuback      = 4
+	mqttSubscribe   = 8
+	mqttSuback      = 9
+	mqttUnsubscribe = 10
+	mqttUnsuback    = 11
+	mqttPingreq     = 12
+	mqttPingresp    = 13
+	mqttDisconnect  = 14
+)
+
+// connack return codes
+const (
+	mqttConnectionAccepted   = 0
+	mqttUnacceptableProtocol = 1
+	mqttIdentifierRejected   = 2
+	mqttServerUnavailable    = 3
+	mqttNotAuthorized        = 5
+
+	// mqttSubscriptionFailure is suback return code of a topic which can't be subscribed
+	mqttSubscriptionFailure = 0x80
+)
+
+const (
+	mqttProtocolName  = "MQTT"
+	mqttProtocolLevel = 4
+
+	mqttMaxRemainingLengthSize = 4
+)
+
+var (
+	errMqttMalformedPacket = errors.New("malformed mqtt packet")
+	errMqttPacketTooLarge  = errors.New("mqtt packet too large")
+)
+
+// mqttPacket is a single control packet, flags are the lower bits of fixed header
+type mqttPacket struct {
+	kind  byte
+	flags byte
+	body  []byte
+}
+
+// readMqttPacket reads the next packet, remaining length can't be more than limit
+func readMqttPacket(r *bufio.Reader, limit int64) (mqttPacket, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return mqttPacket{}, err
+	}
+
+	var length int64
+	for i, multiplier := 0, int64(1); ; i, multiplier = i+1, multiplier*128 {
+		if i == mqttMaxRemainingLengthSize {
+			return mqttPacket{}, errMqttMalformedPacket
+		}
+
+		encoded, err := r.ReadByte()
+		if err != nil {
+			return mqttPacket{}, err
+		}
+
+		length += int64(encoded&0x7f) * multiplier
+		if encoded&0x80 == 0 {
+			break
+		}
+	}
+
+	if length > limit {
+		return mqttPacket{}, errMqttPacketTooLarge
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return mqttPacket{}, err
+	}
+
+	return mqttPacket{
+		kind:  header >> 4,
+		flags: header & 0x0f,
+		body:  body,
+	}, nil
+}
+
+// encode returns the packet with fixed header
+func (p mqttPacket) encode() []byte {
+	data := make([]byte, 0, len(p.body)+1+mqttMaxRemainingLengthSize)
+	data = append(data, p.kind<<4|p.flags)
+
+	length := len(p.body)
+	for {
+		encoded := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			encoded |= 0x80
+		}
+		data = append(data, encoded)
+		if length == 0 {
+			break
+		}
+	}
+
+	return append(data, p.body...)
+}
+
+// mqttReader reads fields of a packet body in order
+// first error is kept and every later read returns zero value
+type mqttReader struct {
+	body []byte
+	err  error
+}
+
+func (r *mqttReader) uint16() uint16 {
+	if r.err != nil || len(r.body) < 2 {
+		r.err = errMqttMalformedPacket
+		return 0
+	}
+
+	value := binary.BigEndian.Uint16(r.body)
+	r.body = r.body[2:]
+	return value
+}
+
+func (r *mqttReader) bytes() []byte {
+	length := int(r.uint16())
+	if r.err != nil || len(r.body) < length {
+		r.err = errMqttMalformedPacket
+		return nil
+	}
+
+	value := r.body[:length]
+	r.body = r.body[length:]
+	return value
+}
+
+func (r *mqttReader) string() string {
+	return string(r.bytes())
+}
+
+func (r *mqttReader) byte() byte {
+	if r.err != nil || len(r.body) < 1 {
+		r.err = errMqttMalformedPacket
+		return 0
+	}
+
+	value := r.body[0]
+	r.body = r.body[1:]
+	return value
+}
+
+func appendMqttString(data []byte, value string) []byte {
+	data = binary.BigEndian.AppendUint16(data, uint16(len(value)))
+	return append(data, value...)
+}
+
+// connect flags
+const (
+	mqttUsernameFlag     = 0x80
+	mqttPasswordFlag     = 0x40
+	mqttWillFlag         = 0x04
+	mqttCleanSessionFlag = 0x02
+	mqttReservedFlag     = 0x01
+)
+
+type mqttConnectPacket struct {
+	protocol  string
+	level     byte
+	flags     byte
+	keepAlive uint16
+	clientId  string
+	username  string
+}
+
+func parseMqttConnect(body []byte) (mqttConnectPacket, error) {
+	r := &mqttReader{body: body}
+	connect := mqttConnectPacket{
+		protocol: r.string(),
+		level:    r.byte(),
+		flags:    r.byte(),
+	}
+	connect.keepAlive = r.uint16()
+	connect.clientId = r.string()
+
+	if connect.flags&mqttWillFlag != 0 {
+		// will topic and message
+		r.bytes()
+		r.bytes()
+	}
+	if connect.flags&mqttUsernameFlag != 0 {
+		connect.username = r.string()
+	}
+	if connect.flags&mqttPasswordFlag != 0 {
+		// token is already checked while upgrading the connection
+		r.bytes()
+	}
+
+	if connect.flags&mqttReservedFlag != 0 {
+		return connect, errMqttMalformedPacket
+	}
+
+	return connect, r.err
+}
+
+type mqttPublishPacket struct {
+	topic    string
+	qos      byte
+	packetId uint16
+	payload  []byte
+}
+
+func parseMqttPublish(flags byte, body []byte) (mqttPublishPacket, error) {
+	r := &mqttReader{body: body}
+	publish := mqttPublishPacket{
+		topic: r.string(),
+		qos:   flags >> 1 & 0x03,
+	}
+	if publish.qos > 0 {
+		publish.packetId = r.uint16()
+	}
+	publish.payload = r.body
+
+	return publish, r.err
+}
+
+// mqttTopicFilter is a topic of subscribe packet with requested qos
+type mqttTopicFilter struct {
+	topic string
+	qos   byte
+}
+
+// parseMqttSubscribe returns packet identifier and filters, qos is 0 for unsubscribe packet
+func parseMqttSubscribe(kind byte, body []byte) (uint16, []mqttTopicFilter, error) {
+	r := &mqttReader{body: body}
+	packetId := r.uint16()
+
+	var filters []mqttTopicFilter
+	for r.err == nil && len(r.body) > 0 {
+		filter := mqttTopicFilter{topic: r.string()}
+		if kind == mqttSubscribe {
+			filter.qos = r.byte()
+		}
+		filters = append(filters, filter)
+	}
+
+	if r.err == nil && len(filters) == 0 {
+		// packet must have at least one topic
+		return packetId, nil, errMqttMalformedPacket
+	}
+
+	return packetId, filters, r.err
+}
+
+func createMqttConnack(returnCode byte) mqttPacket {
+	return mqttPacket{kind: mqttConnack, body: []byte{0, returnCode}}
+}
+
+// createMqttPublish creates qos 0 publish packet
+func createMqttPublish(topic string, payload []byte) mqttPacket {
+	body := make([]byte, 0, 2+len(topic)+len(payload))
+	body = appendMqttString(body, topic)
+
+	return mqttPacket{kind: mqttPublish, body: append(body, payload...)}
+}
+
+// createMqttAck creates packet acknowledging the packet identifier
+// returnCodes are used by suback only
+func createMqttAck(kind byte, packetId uint16, returnCodes ...byte) mqttPacket {
+	body := binary.BigEndian.AppendUint16(nil, packetId)
+	return mqttPacket{kind: kind, body: append(body, returnCodes...)}
+}
@@ -0,0 +1,232 @@
+package hub
+
+import (
+	"bufio"
+	"context"
+	"doki.co.in/doki_real_time_service/config"
+	"doki.co.in/doki_real_time_service/payload"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// createWebsocketTestServer serves websocket endpoint, username is taken from the query instead of the token
+func createWebsocketTestServer(t *testing.T, testConfig *config.Config) (*Hub, *httptest.Server) {
+	payload.InitPayload()
+	h := CreateHub(testConfig, nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version, declared, _ := parseProtocolVersion(r)
+		h.connect(w, r, r.URL.Query().Get("user"), version, declared)
+	}))
+	t.Cleanup(server.Close)
+
+	return h, server
+}
+
+type testMqttClient struct {
+	t      *testing.T
+	conn   *websocket.Conn
+	reader *bufio.Reader
+}
+
+func dialMqtt(t *testing.T, server *httptest.Server, user string) *testMqttClient {
+	dialer := websocket.Dialer{Subprotocols: []string{mqttSubprotocol}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"?user="+user, nil)
+	require.NoError(t, err)
+	require.Equal(t, mqttSubprotocol, conn.Subprotocol())
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return &testMqttClient{
+		t:      t,
+		conn:   conn,
+		reader: bufio.NewReader(&mqttStream{conn: conn}),
+	}
+}
+
+func (c *testMqttClient) send(packets ...mqttPacket) {
+	var data []byte
+	for _, packet := range packets {
+		data = append(data, packet.encode()...)
+	}
+	require.NoError(c.t, c.conn.WriteMessage(websocket.BinaryMessage, data))
+}
+
+func (c *testMqttClient) next() mqttPacket {
+	require.NoError(c.t, c.conn.SetReadDeadline(time.Now().Add(time.Second)))
+	packet, err := readMqttPacket(c.reader, 1<<20)
+	require.NoError(c.t, err)
+
+	return packet
+}
+
+// nextPublish returns topic and payload of the next publish packet
+func (c *testMqttClient) nextPublish() (string, map[string]any) {
+	packet := c.next()
+	require.EqualValues(c.t, mqttPublish, packet.kind)
+
+	publish, err := parseMqttPublish(packet.flags, packet.body)
+	require.NoError(c.t, err)
+
+	var message map[string]any
+	require.NoError(c.t, json.Unmarshal(publish.payload, &message))
+	return publish.topic, message
+}
+
+func createTestMqttConnect(clientId, username string, level byte) mqttPacket {
+	body := appendMqttString(nil, mqttProtocolName)
+	flags := byte(mqttCleanSessionFlag)
+	if username != "" {
+		flags |= mqttUsernameFlag
+	}
+	body = append(body, level, flags, 0, 30)
+	body = appendMqttString(body, clientId)
+	if username != "" {
+		body = appendMqttString(body, username)
+	}
+
+	return mqttPacket{kind: mqttConnect, body: body}
+}
+
+func createTestMqttSubscribe(packetId uint16, topics ...string) mqttPacket {
+	body := []byte{byte(packetId >> 8), byte(packetId)}
+	for _, topic := range topics {
+		body = append(appendMqttString(body, topic), 1)
+	}
+
+	return mqttPacket{kind: mqttSubscribe, flags: 0x02, body: body}
+}
+
+func createTestMqttPublish(topic string, packetId uint16, message string) mqttPacket {
+	body := appendMqttString(nil, topic)
+	if packetId == 0 {
+		return mqttPacket{kind: mqttPublish, body: append(body, message...)}
+	}
+
+	body = append(body, byte(packetId>>8), byte(packetId))
+	return mqttPacket{kind: mqttPublish, flags: 1 << 1, body: append(body, message...)}
+}
+
+func readJson(t *testing.T, conn *websocket.Conn) map[string]any {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+
+	var message map[string]any
+	require.NoError(t, conn.ReadJSON(&message))
+	return message
+}
+
+func TestMqttBridge(t *testing.T) {
+	testConfig := config.Default()
+	// writer of disconnected websocket client exits on the next ping, shutdown waits for it
+	testConfig.Websocket.PingInterval = config.Duration(50 * time.Millisecond)
+
+	testMqttBridge(t, testConfig)
+}
+
+func testMqttBridge(t *testing.T, testConfig *config.Config) {
+	h, server := createWebsocketTestServer(t, testConfig)
+
+	alice, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"?user=alice&resource=phone", nil)
+	require.NoError(t, err)
+
+	bob := dialMqtt(t, server, "bob")
+
+	// connect packet split between messages is read as a stream
+	connect := createTestMqttConnect("companion", "bob", mqttProtocolLevel).encode()
+	require.NoError(t, bob.conn.WriteMessage(websocket.BinaryMessage, connect[:5]))
+	require.NoError(t, bob.conn.WriteMessage(websocket.BinaryMessage, connect[5:]))
+
+	connack := bob.next()
+	assert.EqualValues(t, mqttConnack, connack.kind)
+	assert.Equal(t, []byte{0, mqttConnectionAccepted}, connack.body)
+	assert.Contains(t, h.ConnectedResources("bob"), "companion")
+
+	bob.send(createTestMqttSubscribe(1, "users/bob/inbox", "presence/alice", "nodes/poll_01", "users/alice/inbox", "users/+/inbox"))
+
+	// server may publish initial presence before acknowledging the subscription
+	topic, presence := bob.nextPublish()
+	assert.Equal(t, "presence/alice", topic)
+	assert.Equal(t, true, presence["online"])
+
+	suback := bob.next()
+	assert.EqualValues(t, mqttSuback, suback.kind)
+	assert.Equal(t, []byte{0, 1, 0, 0, 0, mqttSubscriptionFailure, mqttSubscriptionFailure}, suback.body)
+
+	require.NoError(t, alice.WriteMessage(websocket.TextMessage,
+		[]byte(`{"type":"chat_message","from":"alice","to":"bob","id":"1","subject":"text","body":"hello device","sendAt":"2025-01-20T10:15:30Z"}`)))
+	topic, chat := bob.nextPublish()
+	assert.Equal(t, "users/bob/inbox", topic)
+	assert.Equal(t, "hello device", chat["body"])
+
+	// payload for someone else than inbox user is dropped, qos 1 is acknowledged anyway
+	bob.send(
+		createTestMqttPublish("users/alice/inbox", 7, `{"type":"chat_message","from":"bob","to":"carol","id":"2","subject":"text","body":"wrong inbox","sendAt":"2025-01-20T10:15:30Z"}`),
+		createTestMqttPublish("users/alice/inbox", 8, `{"type":"chat_message","from":"bob","to":"alice","id":"3","subject":"text","body":"hello phone","sendAt":"2025-01-20T10:15:30Z"}`),
+	)
+	assert.Equal(t, createMqttAck(mqttPuback, 7), bob.next())
+	assert.Equal(t, createMqttAck(mqttPuback, 8), bob.next())
+	assert.Equal(t, "hello phone", readJson(t, alice)["body"])
+
+	require.NoError(t, alice.WriteMessage(websocket.TextMessage, []byte(`{"type":"poll_votes_update","from":"alice","pollId":"poll_01","votes":[3,1]}`)))
+	topic, votes := bob.nextPublish()
+	assert.Equal(t, "nodes/poll_01", topic)
+	assert.Equal(t, []any{3.0, 1.0}, votes["votes"])
+
+	bob.send(mqttPacket{kind: mqttPingreq})
+	assert.Equal(t, mqttPacket{kind: mqttPingresp, body: []byte{}}, bob.next())
+
+	_ = alice.Close()
+	topic, presence = bob.nextPublish()
+	assert.Equal(t, "presence/alice", topic)
+	assert.Equal(t, false, presence["online"])
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, h.Shutdown(ctx))
+
+	_, err = bob.reader.ReadByte()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseServiceRestart))
+}
+
+func TestMqttConnectRejected(t *testing.T) {
+	h, server := createWebsocketTestServer(t, config.Default())
+
+	tests := map[string]struct {
+		connect    mqttPacket
+		returnCode byte
+	}{
+		"other username": {
+			connect:    createTestMqttConnect("companion", "mallory", mqttProtocolLevel),
+			returnCode: mqttNotAuthorized,
+		},
+		"mqtt 3.1": {
+			connect:    createTestMqttConnect("companion", "", 3),
+			returnCode: mqttUnacceptableProtocol,
+		},
+		"identifier with resource separator": {
+			connect:    createTestMqttConnect("companion@phone", "", mqttProtocolLevel),
+			returnCode: mqttIdentifierRejected,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			bob := dialMqtt(t, server, "bob")
+			bob.send(test.connect)
+
+			connack := bob.next()
+			assert.EqualValues(t, mqttConnack, connack.kind)
+			assert.Equal(t, []byte{0, test.returnCode}, connack.body)
+
+			_, err := bob.reader.ReadByte()
+			assert.Error(t, err)
+			assert.Empty(t, h.ConnectedResources("bob"))
+		})
+	}
+}
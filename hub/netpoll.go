@@ -0,0 +1,424 @@
+package hub
+
+import (
+	"doki.co.in/doki_real_time_service/client"
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/utils"
+	"errors"
+	"github.com/gorilla/websocket"
+	"io"
+	"log"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// eventPoller tells which connections are ready to read
+// every registered connection is reported once and must be re-armed after it is read
+type eventPoller interface {
+	add(fd int) error
+	rearm(fd int) error
+	remove(fd int) error
+
+	// wait blocks till some connections are ready and calls ready for each of them
+	// errPollerClosed is returned once close is called, the poller is released by then
+	wait(ready func(fd int)) error
+
+	// close wakes up wait, it must be called only once
+	close() error
+}
+
+var errPollerClosed = errors.New("netpoll is closed")
+
+// poller is the netpoll transport, connections don't have their own goroutines
+// instead a fixed pool of workers reads connections which are ready and writes queued messages
+type poller struct {
+	sync.Mutex
+	hub     *Hub
+	events  eventPoller
+	tasks   chan func()
+	clients map[int]*pollClient
+
+	// done stops the workers, poll and keep alive
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func createPoller(h *Hub) (*poller, error) {
+	events, err := createEventPoller()
+	if err != nil {
+		return nil, err
+	}
+
+	workers := h.config.Websocket.NetpollWorkers
+	if workers == 0 {
+		workers = runtime.NumCPU() * 8
+	}
+
+	p := &poller{
+		hub:     h,
+		events:  events,
+		tasks:   make(chan func(), workers*64),
+		clients: make(map[int]*pollClient),
+		done:    make(chan struct{}),
+	}
+
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	go p.poll()
+	go p.keepAlive()
+
+	return p, nil
+}
+
+// close stops the goroutines of the poller and releases the event poller
+// connections are not closed, hub shutdown closes them before
+func (p *poller) close() {
+	p.closeOnce.Do(func() {
+		close(p.done)
+		if err := p.events.close(); err != nil {
+			log.Printf("error closing netpoll: %v\n", err)
+		}
+	})
+}
+
+func (p *poller) work() {
+	for {
+		select {
+		case task := <-p.tasks:
+			task()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// submit runs task on worker pool
+// task runs on its own goroutine if pool is backed up so that workers never wait on each other
+// same happens once the poller is closed as nobody takes tasks from the pool anymore
+func (p *poller) submit(task func()) {
+	select {
+	case p.tasks <- task:
+	default:
+		go task()
+	}
+}
+
+func (p *poller) poll() {
+	for {
+		err := p.events.wait(func(fd int) {
+			p.Lock()
+			c := p.clients[fd]
+			p.Unlock()
+
+			if c != nil {
+				p.submit(c.onReadable)
+			}
+		})
+		if errors.Is(err, errPollerClosed) {
+			return
+		}
+		if err != nil {
+			log.Printf("error waiting for netpoll events: %v\n", err)
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+// keepAlive pings every connection and closes the ones which were not heard from in pong wait
+func (p *poller) keepAlive() {
+	ticker := time.NewTicker(time.Duration(p.hub.config.Websocket.PingInterval))
+	defer ticker.Stop()
+
+	pongWait := time.Duration(p.hub.config.Websocket.PongWait)
+	for {
+		select {
+		case <-ticker.C:
+		case <-p.done:
+			return
+		}
+
+		p.Lock()
+		clients := make([]*pollClient, 0, len(p.clients))
+		for _, c := range p.clients {
+			clients = append(clients, c)
+		}
+		p.Unlock()
+
+		for _, c := range clients {
+			if time.Since(time.Unix(0, c.lastSeen.Load())) > pongWait {
+				p.submit(c.shutdown)
+			} else {
+				p.submit(c.ping)
+			}
+		}
+	}
+}
+
+func (p *poller) add(c *pollClient) error {
+	p.Lock()
+	p.clients[c.conn.fd] = c
+	p.Unlock()
+
+	if err := p.events.add(c.conn.fd); err != nil {
+		p.Lock()
+		delete(p.clients, c.conn.fd)
+		p.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+func (p *poller) remove(c *pollClient) {
+	p.Lock()
+	if p.clients[c.conn.fd] == c {
+		delete(p.clients, c.conn.fd)
+		// connection must leave epoll before it is closed, else fd can be reused by a new connection
+		_ = p.events.remove(c.conn.fd)
+	}
+	p.Unlock()
+}
+
+// pollClient is client of netpoll transport
+type pollClient struct {
+	connection *websocket.Conn
+	conn       *pollConn
+	hub        *Hub
+	poller     *poller
+
+	// user is complete user with resource part
+	user string
+
+	clientState
+
+	// lastSeen is unix nano time when anything was last read from the client
+	lastSeen atomic.Int64
+
+	queueLock sync.Mutex
+	queue     [][]byte
+	flushing  bool
+	closing   *closeFrame
+	closed    bool
+
+	// readLock orders reads of the workers, one shot events already make sure they don't overlap
+	// but go memory model doesn't see the ordering given by epoll
+	readLock sync.Mutex
+
+	// writeLock makes sure only one worker writes to connection at a time
+	writeLock    sync.Mutex
+	shutdownOnce sync.Once
+}
+
+func createPollClient(conn *websocket.Conn, polled *pollConn, p *poller, user string, info client.ConnectionInfo, clientCodec codec.Codec) *pollClient {
+	c := &pollClient{
+		connection:  conn,
+		conn:        polled,
+		hub:         p.hub,
+		poller:      p,
+		user:        user,
+		clientState: createClientState(info, clientCodec),
+	}
+
+	c.lastSeen.Store(time.Now().UnixNano())
+	conn.SetReadLimit(p.hub.config.Websocket.IncomingPayloadLimit)
+	polled.onControl = c.onControl
+
+	return c
+}
+
+func (c *pollClient) GetConnection() *websocket.Conn {
+	return c.connection
+}
+
+func (c *pollClient) GetUserInfo() (string, string) {
+	return utils.GetUsernameAndResourceFromUser(c.user)
+}
+
+// WriteToChannel queues the message and schedules a flush
+// sender writes the queue itself when it is full, same as waiting on writer in goroutine transport
+func (c *pollClient) WriteToChannel(data *[]byte) {
+	c.queueLock.Lock()
+	if c.closed || c.closing != nil {
+		c.queueLock.Unlock()
+		return
+	}
+
+	c.queue = append(c.queue, *data)
+	full := len(c.queue) >= c.hub.config.Websocket.SendQueueSize
+	schedule := !c.flushing
+	c.flushing = true
+	c.queueLock.Unlock()
+
+	if full {
+		c.flush()
+	} else if schedule {
+		c.poller.submit(c.flush)
+	}
+}
+
+// Close sends the queued messages and closes the connection with given code and reason
+func (c *pollClient) Close(code int, reason string) {
+	c.queueLock.Lock()
+	if c.closed || c.closing != nil {
+		c.queueLock.Unlock()
+		return
+	}
+
+	c.closing = &closeFrame{
+		code:   code,
+		reason: reason,
+	}
+	schedule := !c.flushing
+	c.flushing = true
+	c.queueLock.Unlock()
+
+	if schedule {
+		c.poller.submit(c.flush)
+	}
+}
+
+func (c *pollClient) flush() {
+	if !c.drainQueue() {
+		c.shutdown()
+	}
+}
+
+// drainQueue writes queued messages and close frame if close is requested
+// returns false if connection should be shut down
+func (c *pollClient) drainQueue() bool {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	writeWait := time.Duration(c.hub.config.Websocket.WriteWait)
+	for {
+		c.queueLock.Lock()
+		if len(c.queue) == 0 {
+			// idle connections don't keep the queue memory
+			c.queue = nil
+			c.flushing = false
+			closing := c.closing
+			c.queueLock.Unlock()
+
+			if closing != nil {
+				closeMessage := websocket.FormatCloseMessage(closing.code, closing.reason)
+				_ = c.connection.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(writeWait))
+				return false
+			}
+
+			return true
+		}
+
+		// messages waiting behind the first one are sent along in a batch frame if the client supports it
+		size := c.hub.batchSize(&c.clientState, len(c.queue)-1)
+		messages := make([][]byte, size)
+		copy(messages, c.queue)
+		clear(c.queue[:size])
+		c.queue = c.queue[size:]
+		c.queueLock.Unlock()
+
+		var err error
+		if size == 1 {
+			err = c.hub.writeFrame(c.connection, messages[0], c.codec, c.protocolVersion())
+		} else {
+			username, _ := c.GetUserInfo()
+			err = c.hub.writeBatch(c.connection, username, messages, c.codec, c.protocolVersion())
+		}
+		if err != nil {
+			//log.Printf("error sending message: %v\n", err)
+			return false
+		}
+	}
+}
+
+func (c *pollClient) ping() {
+	deadline := time.Now().Add(time.Duration(c.hub.config.Websocket.WriteWait))
+	if err := c.connection.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+		c.shutdown()
+	}
+}
+
+// onControl handles control frames read by pollConn
+func (c *pollClient) onControl(opcode int, payload []byte) error {
+	c.lastSeen.Store(time.Now().UnixNano())
+	return answerControl(c.connection, time.Duration(c.hub.config.Websocket.WriteWait), opcode, payload)
+}
+
+// answerControl replies to control frame read by pollConn same as websocket reader would have done
+// io.EOF is returned for close frame
+func answerControl(conn *websocket.Conn, writeWait time.Duration, opcode int, payload []byte) error {
+	deadline := time.Now().Add(writeWait)
+
+	switch opcode {
+	case wsOpPing:
+		return conn.WriteControl(websocket.PongMessage, payload, deadline)
+
+	case wsOpClose:
+		// echoing the close code
+		closeMessage := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+		if len(payload) >= 2 {
+			closeMessage = payload[:2]
+		}
+		_ = conn.WriteControl(websocket.CloseMessage, closeMessage, deadline)
+		return io.EOF
+	}
+
+	return nil
+}
+
+// onReadable reads all the messages available without blocking and re-arms the connection
+func (c *pollClient) onReadable() {
+	c.readLock.Lock()
+	defer c.readLock.Unlock()
+
+	c.lastSeen.Store(time.Now().UnixNano())
+
+	// a frame which has started arriving must complete in time, else worker would be stuck on it
+	_ = c.conn.SetReadDeadline(time.Now().Add(time.Duration(c.hub.config.Websocket.WriteWait)))
+
+	username, resource := c.GetUserInfo()
+	canRead := true
+	for {
+		ready, err := c.conn.dataFrameReady(canRead)
+		if err != nil {
+			c.shutdown()
+			return
+		}
+		if !ready {
+			break
+		}
+		canRead = false
+
+		_, data, err := c.connection.ReadMessage()
+		if err != nil {
+			c.shutdown()
+			return
+		}
+
+		if err := c.hub.receive(data, username, resource, c.codec); err != nil {
+			//log.Println(err.Error())
+		}
+	}
+
+	if err := c.poller.events.rearm(c.conn.fd); err != nil {
+		c.shutdown()
+	}
+}
+
+// shutdown closes the connection and removes client from the hub
+func (c *pollClient) shutdown() {
+	c.shutdownOnce.Do(func() {
+		c.queueLock.Lock()
+		c.closed = true
+		c.queue = nil
+		c.queueLock.Unlock()
+
+		c.poller.remove(c)
+		_ = c.connection.Close()
+
+		c.hub.removeClient(c)
+		c.hub.writers.Done()
+	})
+}
@@ -0,0 +1,110 @@
+//go:build linux
+
+package hub
+
+import (
+	"errors"
+	"sync"
+	"syscall"
+)
+
+// epollEvents are level triggered and one shot so that only one worker reads a connection at a time
+const epollEvents = syscall.EPOLLIN | syscall.EPOLLRDHUP | syscall.EPOLLONESHOT
+
+type epoll struct {
+	// lock keeps the fds from being used after release, closed fd numbers are reused by new files
+	lock     sync.RWMutex
+	released bool
+	fd       int
+
+	// wake is a pipe whose read end is watched by epoll, close writes to it to wake up wait
+	wake   [2]int
+	events []syscall.EpollEvent
+}
+
+func createEventPoller() (eventPoller, error) {
+	fd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &epoll{
+		fd:     fd,
+		events: make([]syscall.EpollEvent, 256),
+	}
+	if err := syscall.Pipe2(e.wake[:], syscall.O_NONBLOCK|syscall.O_CLOEXEC); err != nil {
+		_ = syscall.Close(fd)
+		return nil, err
+	}
+	if err := syscall.EpollCtl(fd, syscall.EPOLL_CTL_ADD, e.wake[0], &syscall.EpollEvent{Events: syscall.EPOLLIN, Fd: int32(e.wake[0])}); err != nil {
+		e.release()
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (e *epoll) add(fd int) error {
+	return e.control(syscall.EPOLL_CTL_ADD, fd, &syscall.EpollEvent{Events: epollEvents, Fd: int32(fd)})
+}
+
+func (e *epoll) rearm(fd int) error {
+	return e.control(syscall.EPOLL_CTL_MOD, fd, &syscall.EpollEvent{Events: epollEvents, Fd: int32(fd)})
+}
+
+func (e *epoll) remove(fd int) error {
+	return e.control(syscall.EPOLL_CTL_DEL, fd, &syscall.EpollEvent{})
+}
+
+func (e *epoll) control(op int, fd int, event *syscall.EpollEvent) error {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	if e.released {
+		return errPollerClosed
+	}
+	return syscall.EpollCtl(e.fd, op, fd, event)
+}
+
+func (e *epoll) wait(ready func(fd int)) error {
+	n, err := syscall.EpollWait(e.fd, e.events, -1)
+	if err != nil {
+		if errors.Is(err, syscall.EINTR) {
+			return nil
+		}
+		return err
+	}
+
+	closed := false
+	for i := 0; i < n; i++ {
+		fd := int(e.events[i].Fd)
+		if fd == e.wake[0] {
+			closed = true
+			continue
+		}
+		ready(fd)
+	}
+
+	// epoll is released here as wait is the last one using it
+	if closed {
+		e.release()
+		return errPollerClosed
+	}
+
+	return nil
+}
+
+func (e *epoll) close() error {
+	_, err := syscall.Write(e.wake[1], []byte{1})
+	return err
+}
+
+func (e *epoll) release() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.released = true
+	_ = syscall.Close(e.wake[0])
+	_ = syscall.Close(e.wake[1])
+	_ = syscall.Close(e.fd)
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package hub
+
+import "errors"
+
+func createEventPoller() (eventPoller, error) {
+	return nil, errors.New("netpoll transport is only supported on linux")
+}
@@ -0,0 +1,235 @@
+//go:build linux
+
+package hub
+
+import (
+	"context"
+	"doki.co.in/doki_real_time_service/config"
+	"doki.co.in/doki_real_time_service/payload"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// createTransportServer serves websocket connections on given transport
+// username is taken from the query instead of the token
+func createTransportServer(tb testing.TB, transport string) (*Hub, *httptest.Server) {
+	payload.InitPayload()
+
+	testConfig := config.Default()
+	testConfig.Websocket.Transport = transport
+	h := CreateHub(testConfig, nil)
+	if transport == config.TransportNetpoll {
+		require.NotNil(tb, h.poller)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.connect(w, r, r.URL.Query().Get("user"), 0, false)
+	}))
+
+	return h, server
+}
+
+func dialTransportServer(t *testing.T, server *httptest.Server, user string) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "?user=" + user + "&resource=phone"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+
+	return conn
+}
+
+func TestNetpollTransport(t *testing.T) {
+	h, server := createTransportServer(t, config.TransportNetpoll)
+	defer server.Close()
+
+	alice := dialTransportServer(t, server, "alice")
+	bob := dialTransportServer(t, server, "bob")
+	defer bob.Close()
+
+	assert.Eventually(t, func() bool {
+		return len(h.ConnectedResources("alice")) == 1 && len(h.ConnectedResources("bob")) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	pong := make(chan string, 1)
+	alice.SetPongHandler(func(data string) error {
+		pong <- data
+		return nil
+	})
+	go func() {
+		for {
+			if _, _, err := alice.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// control frames in between data frames are answered by netpoll connection
+	require.NoError(t, alice.WriteControl(websocket.PingMessage, []byte("ping"), time.Now().Add(time.Second)))
+	message := benchmarkPayloads["chat"]
+	message = []byte(strings.Replace(strings.Replace(string(message), "rohan_verma__", "alice", 1), "doki_user_01", "bob", 1))
+	require.NoError(t, alice.WriteMessage(websocket.TextMessage, message))
+	require.NoError(t, alice.WriteMessage(websocket.TextMessage, message))
+
+	select {
+	case data := <-pong:
+		assert.Equal(t, "ping", data)
+	case <-time.After(time.Second):
+		t.Fatal("pong not received")
+	}
+
+	_ = bob.SetReadDeadline(time.Now().Add(time.Second))
+	for i := 0; i < 2; i++ {
+		_, data, err := bob.ReadMessage()
+		require.NoError(t, err)
+
+		var received map[string]any
+		require.NoError(t, json.Unmarshal(data, &received))
+		assert.Equal(t, "chat_message", received["type"])
+		assert.Equal(t, "alice", received["from"])
+	}
+
+	_ = alice.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	assert.Eventually(t, func() bool {
+		return len(h.ConnectedResources("alice")) == 0
+	}, time.Second, 10*time.Millisecond)
+	_ = alice.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, h.Shutdown(ctx))
+
+	var closeErr *websocket.CloseError
+	for {
+		if _, _, err := bob.ReadMessage(); err != nil {
+			require.ErrorAs(t, err, &closeErr)
+			break
+		}
+	}
+	assert.Equal(t, websocket.CloseServiceRestart, closeErr.Code)
+}
+
+// TestNetpollShutdownStopsPoller checks that workers, poll and keep alive don't outlive the hub
+func TestNetpollShutdownStopsPoller(t *testing.T) {
+	payload.InitPayload()
+	before := runtime.NumGoroutine()
+
+	testConfig := config.Default()
+	testConfig.Websocket.Transport = config.TransportNetpoll
+	testConfig.Websocket.NetpollWorkers = 4
+	h := CreateHub(testConfig, nil)
+	require.NotNil(t, h.poller)
+	assert.GreaterOrEqual(t, runtime.NumGoroutine(), before+6)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, h.Shutdown(ctx))
+
+	// eventually can't be used as it checks the condition on its own goroutine
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+
+	events := h.poller.events.(*epoll)
+	assert.Eventually(t, func() bool {
+		events.lock.RLock()
+		defer events.lock.RUnlock()
+		return events.released
+	}, time.Second, 10*time.Millisecond)
+	assert.ErrorIs(t, events.add(0), errPollerClosed)
+}
+
+// TestNetpollMqtt checks mqtt clients which are read by their own goroutine on netpoll connections
+func TestNetpollMqtt(t *testing.T) {
+	testConfig := config.Default()
+	testConfig.Websocket.Transport = config.TransportNetpoll
+
+	testMqttBridge(t, testConfig)
+}
+
+// dialIdleConnection opens websocket connection with a raw tcp handshake
+// so that client side holds nothing but the socket
+func dialIdleConnection(tb testing.TB, address string, i int) net.Conn {
+	conn, err := net.Dial("tcp", address)
+	require.NoError(tb, err)
+
+	request := fmt.Sprintf("GET /ws?user=user_%d&resource=phone HTTP/1.1\r\nHost: %s\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n"+
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n", i, address)
+	_, err = conn.Write([]byte(request))
+	require.NoError(tb, err)
+
+	var response []byte
+	buffer := make([]byte, 256)
+	for !strings.HasSuffix(string(response), "\r\n\r\n") {
+		n, err := conn.Read(buffer)
+		require.NoError(tb, err)
+		response = append(response, buffer[:n]...)
+	}
+	require.True(tb, strings.HasPrefix(string(response), "HTTP/1.1 101"))
+
+	return conn
+}
+
+func memoryInUse() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapInuse + stats.StackInuse
+}
+
+// BenchmarkIdleConnections measures server memory and goroutines held by idle connections
+// numbers include the client side sockets which are same for both transports
+//
+// number of connections is read from DOKI_IDLE_CONNECTIONS, default is kept low for the file limit
+// go test ./hub -run ^$ -bench IdleConnections -benchtime 1x
+// ulimit -n 250000 && DOKI_IDLE_CONNECTIONS=100000 go test ./hub -run ^$ -bench IdleConnections -benchtime 1x
+func BenchmarkIdleConnections(b *testing.B) {
+	connections := 1000
+	if value := os.Getenv("DOKI_IDLE_CONNECTIONS"); value != "" {
+		n, err := strconv.Atoi(value)
+		require.NoError(b, err)
+		connections = n
+	}
+
+	for _, transport := range []string{config.TransportGoroutine, config.TransportNetpoll} {
+		b.Run(transport, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				h, server := createTransportServer(b, transport)
+				address := server.Listener.Addr().String()
+
+				goroutines := runtime.NumGoroutine()
+				memory := memoryInUse()
+
+				clients := make([]net.Conn, connections)
+				for j := range clients {
+					clients[j] = dialIdleConnection(b, address, j)
+				}
+				require.Eventually(b, func() bool {
+					h.RLock()
+					defer h.RUnlock()
+					return len(h.clients) == connections
+				}, time.Minute, 10*time.Millisecond)
+
+				b.ReportMetric(float64(memoryInUse()-memory)/float64(connections), "B/conn")
+				b.ReportMetric(float64(runtime.NumGoroutine()-goroutines)/float64(connections), "goroutines/conn")
+
+				for _, conn := range clients {
+					_ = conn.Close()
+				}
+				server.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package hub
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// originPolicy decides which browser origins can open websocket connection
+// this prevents cross site websocket hijacking where a malicious page
+// uses victim browser's credentials to connect
+type originPolicy struct {
+	allowAny bool
+
+	// exactOrigins contains host or scheme://host
+	exactOrigins map[string]bool
+
+	// wildcardSuffixes contains ".doki.co.in" for "*.doki.co.in"
+	wildcardSuffixes []string
+}
+
+func createOriginPolicy(allowedOrigins []string) *originPolicy {
+	policy := &originPolicy{
+		exactOrigins: make(map[string]bool),
+	}
+
+	for _, origin := range allowedOrigins {
+		origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+
+		switch {
+		case origin == "*":
+			policy.allowAny = true
+		case strings.HasPrefix(origin, "*."):
+			policy.wildcardSuffixes = append(policy.wildcardSuffixes, origin[1:])
+		default:
+			policy.exactOrigins[origin] = true
+		}
+	}
+
+	return policy
+}
+
+// isAllowed checks origin header of the request against the policy
+// requests without origin header are not sent by browsers (e.g. native clients) and are allowed
+// if no origins are configured only same origin requests are allowed
+func (p *originPolicy) isAllowed(r *http.Request) bool {
+	originHeader := r.Header.Get("Origin")
+	if originHeader == "" {
+		return true
+	}
+
+	if p.allowAny {
+		return true
+	}
+
+	origin, err := url.Parse(originHeader)
+	if err != nil || origin.Host == "" {
+		// this covers "null" origin sent by sandboxed iframes and file urls
+		return false
+	}
+
+	host := strings.ToLower(origin.Host)
+	scheme := strings.ToLower(origin.Scheme)
+
+	if len(p.exactOrigins) == 0 && len(p.wildcardSuffixes) == 0 {
+		return strings.EqualFold(host, r.Host)
+	}
+
+	if p.exactOrigins[host] || p.exactOrigins[scheme+"://"+host] {
+		return true
+	}
+
+	hostname := strings.ToLower(origin.Hostname())
+	for _, suffix := range p.wildcardSuffixes {
+		if strings.HasSuffix(hostname, suffix) || strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkOrigin applies origin policy to the request and counts the rejections
+func (h *Hub) checkOrigin(r *http.Request) bool {
+	if h.originPolicy.isAllowed(r) {
+		return true
+	}
+
+	h.rejectedOrigins.Add(1)
+	log.Printf("rejected websocket connection from origin %q, remote address %v\n", r.Header.Get("Origin"), r.RemoteAddr)
+	return false
+}
@@ -0,0 +1,72 @@
+package hub
+
+import (
+	"doki.co.in/doki_real_time_service/config"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func originRequest(origin string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "http://rt.doki.co.in/ws", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+
+	return req
+}
+
+func TestOriginPolicy(t *testing.T) {
+	policy := createOriginPolicy([]string{"https://app.doki.co.in", "admin.doki.co.in", "*.web.doki.co.in"})
+
+	tests := []struct {
+		name    string
+		origin  string
+		allowed bool
+	}{
+		{"native client without origin", "", true},
+		{"exact origin with scheme", "https://app.doki.co.in", true},
+		{"exact origin with wrong scheme", "http://app.doki.co.in", false},
+		{"exact host any scheme", "http://admin.doki.co.in", true},
+		{"wildcard subdomain", "https://beta.web.doki.co.in", true},
+		{"nested wildcard subdomain", "https://a.b.web.doki.co.in", true},
+		{"wildcard doesn't match apex", "https://web.doki.co.in", false},
+		{"attacker site", "https://evil.com", false},
+		{"attacker suffix trick", "https://app.doki.co.in.evil.com", false},
+		{"attacker prefix trick", "https://evilweb.doki.co.in", false},
+		{"sandboxed iframe null origin", "null", false},
+		{"case is ignored", "https://APP.doki.co.in", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.allowed, policy.isAllowed(originRequest(test.origin)))
+		})
+	}
+}
+
+func TestOriginPolicySameOriginByDefault(t *testing.T) {
+	policy := createOriginPolicy(nil)
+
+	assert.True(t, policy.isAllowed(originRequest("https://rt.doki.co.in")))
+	assert.False(t, policy.isAllowed(originRequest("https://evil.com")))
+}
+
+func TestOriginPolicyAllowAny(t *testing.T) {
+	policy := createOriginPolicy([]string{"*"})
+
+	assert.True(t, policy.isAllowed(originRequest("https://evil.com")))
+}
+
+func TestServeWSRejectsOriginBeforeAuth(t *testing.T) {
+	testConfig := config.Default()
+	testConfig.Websocket.AllowedOrigins = []string{"app.doki.co.in"}
+	h := CreateHub(testConfig, nil)
+
+	rec := httptest.NewRecorder()
+	h.ServeWS(rec, originRequest("https://evil.com"))
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, int64(1), h.rejectedOrigins.Load())
+}
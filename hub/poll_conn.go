@@ -0,0 +1,261 @@
+package hub
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+)
+
+const (
+	wsOpcodeMask = 0x0f
+	wsMaskBit    = 0x80
+	wsLengthMask = 0x7f
+
+	wsOpClose = 8
+	wsOpPing  = 9
+	wsOpPong  = 10
+
+	wsMaxControlPayload = 125
+
+	// pollReadBufferSize fits any frame header and control frame
+	pollReadBufferSize = 4096
+)
+
+var errInvalidControlFrame = errors.New("invalid control frame")
+
+// pollReadBuffers are shared between netpoll connections, a connection holds one
+// only while it has bytes which are not yet read by websocket reader
+var pollReadBuffers = sync.Pool{
+	New: func() any {
+		buffer := make([]byte, pollReadBufferSize)
+		return &buffer
+	},
+}
+
+// pollConn wraps hijacked connection of netpoll transport
+//
+// websocket reader on top of it never gets bytes past the data frame being read, so once a
+// message is read nothing is left inside websocket reader buffer and epoll readiness or
+// buffered bytes here tell if there is more to read
+//
+// control frames are handled here so that reading a message returns as soon as its data frames are read
+type pollConn struct {
+	net.Conn
+	fd int
+
+	// buffer holds bytes read from connection but not yet passed to websocket reader
+	buffer     *[]byte
+	start, end int
+
+	// remaining is bytes of current data frame not yet passed to websocket reader
+	remaining int64
+
+	onControl func(opcode int, payload []byte) error
+}
+
+// createPollConn wraps connection if it is backed by a file descriptor
+func createPollConn(conn net.Conn) (*pollConn, bool) {
+	syscallConn, ok := conn.(syscall.Conn)
+	if !ok {
+		return nil, false
+	}
+
+	rawConn, err := syscallConn.SyscallConn()
+	if err != nil {
+		return nil, false
+	}
+
+	fd := -1
+	if err := rawConn.Control(func(f uintptr) { fd = int(f) }); err != nil || fd < 0 {
+		return nil, false
+	}
+
+	return &pollConn{
+		Conn: conn,
+		fd:   fd,
+	}, true
+}
+
+func (p *pollConn) buffered() int {
+	return p.end - p.start
+}
+
+// fill reads from the connection once
+func (p *pollConn) fill() error {
+	if p.buffer == nil {
+		p.buffer = pollReadBuffers.Get().(*[]byte)
+		p.start, p.end = 0, 0
+	}
+
+	buffer := *p.buffer
+	if p.end == len(buffer) {
+		copy(buffer, buffer[p.start:p.end])
+		p.end -= p.start
+		p.start = 0
+	}
+
+	n, err := p.Conn.Read(buffer[p.end:])
+	p.end += n
+	if n > 0 {
+		return nil
+	}
+
+	return err
+}
+
+// ensure reads till n bytes are buffered
+func (p *pollConn) ensure(n int) error {
+	for p.buffered() < n {
+		if err := p.fill(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// release returns the buffer to pool once everything is read
+func (p *pollConn) release() {
+	if p.buffer != nil && p.buffered() == 0 {
+		pollReadBuffers.Put(p.buffer)
+		p.buffer = nil
+	}
+}
+
+// readFrameHeader reads the header of next frame
+// data frames are left to websocket reader, control frames are consumed and handled
+func (p *pollConn) readFrameHeader() error {
+	if err := p.ensure(2); err != nil {
+		return err
+	}
+
+	frame := (*p.buffer)[p.start:p.end]
+	masked := frame[1]&wsMaskBit != 0
+	length := int64(frame[1] & wsLengthMask)
+
+	headerLength := 2
+	switch length {
+	case 126:
+		headerLength += 2
+	case 127:
+		headerLength += 8
+	}
+	if masked {
+		headerLength += 4
+	}
+
+	if err := p.ensure(headerLength); err != nil {
+		return err
+	}
+
+	frame = (*p.buffer)[p.start:p.end]
+	switch length {
+	case 126:
+		length = int64(binary.BigEndian.Uint16(frame[2:4]))
+	case 127:
+		length = int64(binary.BigEndian.Uint64(frame[2:10]) & (1<<63 - 1))
+	}
+
+	opcode := int(frame[0] & wsOpcodeMask)
+	if opcode < wsOpClose {
+		p.remaining = int64(headerLength) + length
+		return nil
+	}
+
+	if length > wsMaxControlPayload {
+		return errInvalidControlFrame
+	}
+
+	frameLength := headerLength + int(length)
+	if err := p.ensure(frameLength); err != nil {
+		return err
+	}
+
+	frame = (*p.buffer)[p.start:p.end]
+	payload := make([]byte, length)
+	copy(payload, frame[headerLength:frameLength])
+	if masked {
+		maskKey := frame[headerLength-4 : headerLength]
+		for i := range payload {
+			payload[i] ^= maskKey[i%4]
+		}
+	}
+
+	p.start += frameLength
+	p.release()
+
+	return p.onControl(opcode, payload)
+}
+
+func (p *pollConn) Read(b []byte) (int, error) {
+	for p.remaining == 0 {
+		if err := p.readFrameHeader(); err != nil {
+			return 0, err
+		}
+	}
+
+	limit := int64(len(b))
+	if p.remaining < limit {
+		limit = p.remaining
+	}
+
+	if p.buffered() == 0 {
+		// rest of the frame is read directly without buffering
+		n, err := p.Conn.Read(b[:limit])
+		p.remaining -= int64(n)
+		return n, err
+	}
+
+	n := copy(b[:limit], (*p.buffer)[p.start:p.end])
+	p.start += n
+	p.remaining -= int64(n)
+	p.release()
+
+	return n, nil
+}
+
+// dataFrameReady handles control frames till a data frame is next to read
+// connection is read only when nothing is buffered and canRead is set
+// returns false if there is nothing more to read without blocking
+func (p *pollConn) dataFrameReady(canRead bool) (bool, error) {
+	for p.remaining == 0 {
+		if p.buffered() == 0 {
+			if !canRead {
+				return false, nil
+			}
+
+			canRead = false
+			if err := p.fill(); err != nil {
+				return false, err
+			}
+		}
+
+		if err := p.readFrameHeader(); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// pollHijacker gives websocket upgrader the connection wrapped in pollConn
+type pollHijacker struct {
+	http.ResponseWriter
+}
+
+func (w pollHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, readWriter, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if polled, ok := createPollConn(conn); ok {
+		return polled, readWriter, nil
+	}
+
+	return conn, readWriter, nil
+}
@@ -3,18 +3,12 @@ package hub
 import (
 	"doki.co.in/doki_real_time_service/payload"
 	"doki.co.in/doki_real_time_service/utils"
-	"maps"
 )
 
 // sendPresence sends user presence updates to all the subscribed users
 func (h *Hub) sendPresence(online bool, username string) {
 	// find user in subscription and send status change
-	usersSubscribed, ok := h.subscription.subscriptions[username]
-	if !ok {
-		return
-	}
-
-	for completeUser := range maps.Keys(usersSubscribed) {
+	for completeUser := range h.GetSubscribers(username) {
 		conn := h.GetIndividualClient(completeUser)
 		if conn == nil {
 			h.Unsubscribe(username, completeUser)
@@ -33,7 +27,9 @@ func (h *Hub) sendPresence(online bool, username string) {
 
 // sendInitialPresence sends the given user presence on initial subscription
 func (h *Hub) sendInitialPresence(userPresence string, completeUser string) {
+	h.RLock()
 	_, ok := h.clients[userPresence]
+	h.RUnlock()
 
 	username, resource := utils.GetUsernameAndResourceFromUser(completeUser)
 	presencePayload := payload.CreatePresencePayload(userPresence, username, ok)
@@ -0,0 +1,151 @@
+package hub
+
+import (
+	"doki.co.in/doki_real_time_service/payload"
+	"doki.co.in/doki_real_time_service/utils"
+	"errors"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServerVersion is reported to clients in welcome frame, it is set at build time
+// -ldflags "-X doki.co.in/doki_real_time_service/hub.ServerVersion=1.0.0"
+var ServerVersion = "dev"
+
+const (
+	featureMsgPack     = "msgpack"
+	featureCompression = "compression"
+	featurePresence    = "presence"
+	featurePolls       = "polls"
+	featureBatch       = "batch"
+	featureUnread      = payload.CapabilityUnread
+)
+
+// protocol is agreed with the client either through query params while connecting
+// or through the hello frame
+type protocol struct {
+	version      int
+	capabilities map[string]bool
+}
+
+// negotiateVersion returns the version both client and server speak
+func negotiateVersion(version int) (int, bool) {
+	if version < payload.LegacyProtocolVersion {
+		return 0, false
+	}
+
+	return min(version, payload.CurrentProtocolVersion), true
+}
+
+// parseProtocolVersion returns version declared in query params
+func parseProtocolVersion(r *http.Request) (int, bool, error) {
+	return parseVersion(r.URL.Query().Get("version"))
+}
+
+// parseVersion returns the declared version, empty value means version is not declared
+func parseVersion(value string) (int, bool, error) {
+	if value == "" {
+		return 0, false, nil
+	}
+
+	version, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false, errors.New("invalid protocol version")
+	}
+
+	if _, ok := negotiateVersion(version); !ok {
+		return 0, false, errors.New("unsupported protocol version")
+	}
+
+	return version, true, nil
+}
+
+// parseCapabilities returns comma separated capabilities declared in query params
+func parseCapabilities(r *http.Request) []string {
+	if !r.URL.Query().Has("capabilities") {
+		return nil
+	}
+
+	return splitCapabilities(r.URL.Query().Get("capabilities"))
+}
+
+// splitCapabilities returns comma separated capabilities, empty value means no capabilities
+func splitCapabilities(value string) []string {
+	capabilities := make([]string, 0)
+	for _, capability := range strings.Split(value, ",") {
+		if capability = strings.TrimSpace(capability); capability != "" {
+			capabilities = append(capabilities, capability)
+		}
+	}
+
+	return capabilities
+}
+
+// features returns all the features enabled on the server
+func (h *Hub) features() []string {
+	features := []string{featurePresence, featurePolls, featureMsgPack, featureBatch, featureUnread}
+	if h.config.Websocket.Compression.Enabled {
+		features = append(features, featureCompression)
+	}
+
+	return features
+}
+
+// enabledFeatures returns server features the client is capable of
+// clients not declaring any capabilities get all the features except unread
+// as unread frames are new frame types which clients must ask for
+func (h *Hub) enabledFeatures(capabilities []string) []string {
+	if capabilities == nil {
+		return slices.DeleteFunc(h.features(), func(feature string) bool {
+			return feature == featureUnread
+		})
+	}
+
+	var enabled []string
+	for _, feature := range h.features() {
+		if slices.Contains(capabilities, feature) {
+			enabled = append(enabled, feature)
+		}
+	}
+
+	return enabled
+}
+
+// Negotiate sets protocol version and capabilities of the complete user and sends welcome frame
+// protocol can be negotiated only once per connection
+func (h *Hub) Negotiate(completeUser string, version int, capabilities []string) {
+	conn := h.GetIndividualClient(completeUser)
+	if conn == nil {
+		return
+	}
+
+	negotiated, ok := negotiateVersion(version)
+	if !ok {
+		conn.Close(websocket.CloseProtocolError, "unsupported protocol version")
+		return
+	}
+
+	features := h.enabledFeatures(capabilities)
+	if !conn.Negotiate(negotiated, features) {
+		return
+	}
+
+	username, resource := utils.GetUsernameAndResourceFromUser(completeUser)
+	welcomePayload := payload.CreateWelcomePayload(username, resource, payload.ServerInfo{
+		ServerVersion:        ServerVersion,
+		ProtocolVersion:      negotiated,
+		IncomingPayloadLimit: h.config.Websocket.IncomingPayloadLimit,
+		PingInterval:         time.Duration(h.config.Websocket.PingInterval),
+		PongWait:             time.Duration(h.config.Websocket.PongWait),
+		Features:             features,
+	})
+
+	data := utils.PayloadToJson(welcomePayload)
+	if data != nil {
+		welcomePayload.SendPayload(data, h, resource)
+	}
+}
@@ -0,0 +1,123 @@
+package hub
+
+import (
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/config"
+	"doki.co.in/doki_real_time_service/payload"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNegotiateVersion(t *testing.T) {
+	version, ok := negotiateVersion(payload.CurrentProtocolVersion + 5)
+	assert.True(t, ok)
+	assert.Equal(t, payload.CurrentProtocolVersion, version)
+
+	_, ok = negotiateVersion(0)
+	assert.False(t, ok)
+}
+
+func TestParseProtocolVersion(t *testing.T) {
+	_, declared, err := parseProtocolVersion(httptest.NewRequest("GET", "/ws", nil))
+	assert.NoError(t, err)
+	assert.False(t, declared)
+
+	version, declared, err := parseProtocolVersion(httptest.NewRequest("GET", "/ws?version=2", nil))
+	assert.NoError(t, err)
+	assert.True(t, declared)
+	assert.Equal(t, 2, version)
+
+	_, _, err = parseProtocolVersion(httptest.NewRequest("GET", "/ws?version=abc", nil))
+	assert.Error(t, err)
+}
+
+func TestEnabledFeatures(t *testing.T) {
+	h := createTestHub()
+
+	assert.Equal(t, []string{featurePresence, featurePolls, featureMsgPack, featureBatch}, h.enabledFeatures(nil))
+	assert.Equal(t, []string{featureUnread}, h.enabledFeatures([]string{featureUnread}))
+	assert.Equal(t, []string{featurePresence}, h.enabledFeatures([]string{featurePresence, "unknown"}))
+	assert.Nil(t, h.enabledFeatures([]string{}))
+}
+
+func TestLegacyClientsDontGetNewFrames(t *testing.T) {
+	shutdown := []byte(`{"type":"server_shutdown","to":"alice","reconnectAfter":5000}`)
+	chat := []byte(`{"type":"chat_message","from":"bob","to":"alice"}`)
+
+	frame, err := encodeFrame(shutdown, codec.Json, payload.LegacyProtocolVersion)
+	assert.NoError(t, err)
+	assert.Nil(t, frame)
+
+	frame, err = encodeFrame(shutdown, codec.Json, payload.CurrentProtocolVersion)
+	assert.NoError(t, err)
+	assert.Equal(t, shutdown, frame)
+
+	frame, err = encodeFrame(chat, codec.Json, payload.LegacyProtocolVersion)
+	assert.NoError(t, err)
+	assert.Equal(t, chat, frame)
+}
+
+func TestRelayedPayloadsAreStamped(t *testing.T) {
+	testConfig := config.Default()
+	testConfig.Websocket.VerifiedSender = true
+	h, server := createWebsocketTestServer(t, testConfig)
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	alice, _, err := websocket.DefaultDialer.Dial(url+"?user=alice&resource=phone", nil)
+	require.NoError(t, err)
+	defer alice.Close()
+	bob, _, err := websocket.DefaultDialer.Dial(url+"?user=bob&resource=laptop&version=3", nil)
+	require.NoError(t, err)
+	defer bob.Close()
+	legacyBob, _, err := websocket.DefaultDialer.Dial(url+"?user=bob&resource=watch", nil)
+	require.NoError(t, err)
+	defer legacyBob.Close()
+
+	assert.Equal(t, "welcome", readJson(t, bob)["type"])
+	assert.Eventually(t, func() bool {
+		return len(h.ConnectedResources("bob")) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	// metadata sent by the client is replaced by the server
+	chat := `{"type":"chat_message","from":"alice","to":"bob","id":"1","subject":"text","body":"hi","sendAt":"2025-01-20T10:15:30Z",` +
+		`"meta":{"serverTs":1,"resource":"spoofed","eventId":"spoofed"}}`
+	sentAt := time.Now().UnixMilli()
+	require.NoError(t, alice.WriteMessage(websocket.TextMessage, []byte(chat)))
+
+	meta := readJson(t, bob)["meta"].(map[string]any)
+	assert.Equal(t, "phone", meta["resource"])
+	assert.Equal(t, "alice", meta["verifiedFrom"])
+	assert.Len(t, meta["eventId"], 36)
+	assert.GreaterOrEqual(t, meta["serverTs"], float64(sentAt))
+
+	// older versions get the payload without metadata
+	require.NoError(t, legacyBob.SetReadDeadline(time.Now().Add(time.Second)))
+	_, data, err := legacyBob.ReadMessage()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"chat_message","from":"alice","to":"bob","id":"1","subject":"text","body":"hi","sendAt":"2025-01-20T10:15:30Z"}`, string(data))
+}
+
+func TestStripMetadataKeepsPayloadBytes(t *testing.T) {
+	chat := []byte(`{"type":"chat_message","from":"alice","to":"bob","body":"\",\"meta\":{"}`)
+	stamped := payload.StampMetadata(chat, payload.Metadata{ServerTs: 1, Resource: `phone","meta":{`, EventId: "1"})
+	assert.NotEqual(t, chat, stamped)
+
+	assert.Equal(t, chat, payload.AdaptForVersion(stamped, 2))
+	assert.Equal(t, stamped, payload.AdaptForVersion(stamped, payload.MetadataProtocolVersion))
+
+	// metadata sent by the client is cut out without reordering the other members
+	forged := []byte(`{"type":"chat_message","meta":{"verifiedFrom":"bob"},"from":"alice","to":"bob","body":"hi"}`)
+	stamped = payload.StampMetadata(forged, payload.Metadata{ServerTs: 1, Resource: "phone", EventId: "1"})
+	assert.Equal(t, `{"type":"chat_message","from":"alice","to":"bob","body":"hi","meta":{"serverTs":1,"resource":"phone","eventId":"1"}}`, string(stamped))
+	assert.Equal(t, `{"type":"chat_message","from":"alice","to":"bob","body":"hi"}`, string(payload.AdaptForVersion(stamped, 2)))
+
+	// escaped name is the same member, it can't be kept to override the stamped one
+	escaped := []byte(`{"type":"chat_message","from":"alice","to":"bob","body":"hi","\u006deta":{"verifiedFrom":"bob"}}`)
+	stamped = payload.StampMetadata(escaped, payload.Metadata{ServerTs: 1, Resource: "phone", EventId: "1"})
+	assert.Equal(t, `{"type":"chat_message","from":"alice","to":"bob","body":"hi","meta":{"serverTs":1,"resource":"phone","eventId":"1"}}`, string(stamped))
+}
@@ -0,0 +1,122 @@
+package hub
+
+import (
+	"context"
+	"doki.co.in/doki_real_time_service/push"
+	"errors"
+	"log"
+	"time"
+)
+
+// pushJob is a notification waiting for a push worker
+type pushJob struct {
+	notifier     push.PushNotifier
+	user         string
+	device       push.Device
+	notification push.Notification
+}
+
+// SetPushNotifiers enables push notifications to offline users through the notifiers by platform
+// it must be called before serving clients
+func (h *Hub) SetPushNotifiers(notifiers map[string]push.PushNotifier, templates push.Templates) {
+	h.notifiers = notifiers
+	h.templates = templates
+	if len(notifiers) == 0 || h.pushQueue != nil {
+		return
+	}
+
+	h.pushQueue = make(chan pushJob, h.config.Push.QueueSize)
+	for range h.config.Push.Workers {
+		go h.pushWorker(h.pushQueue)
+	}
+}
+
+// SetDeviceStore replaces the in memory device tokens, it must be called before serving clients
+func (h *Hub) SetDeviceStore(store push.DeviceStore) {
+	h.devices = store
+}
+
+// Devices returns push notification tokens of every user
+func (h *Hub) Devices() push.DeviceStore {
+	return h.devices
+}
+
+// NotifyOffline sends push notification of the json payload to users who have no connected resource
+// notifications are queued for push workers so that routing never waits for fcm or apns
+func (h *Hub) NotifyOffline(data []byte, users []string) {
+	if len(h.notifiers) == 0 || len(users) == 0 {
+		return
+	}
+
+	notification, ok := h.templates.Render(data)
+	if !ok {
+		return
+	}
+
+	for _, user := range users {
+		// any active resource gets the payload itself, so notification is suppressed
+		if len(h.GetAllConnectedClients(user)) > 0 {
+			continue
+		}
+
+		for _, device := range h.devices.Devices(user) {
+			notifier, ok := h.notifiers[device.Platform]
+			if !ok {
+				continue
+			}
+
+			h.queuePush(pushJob{notifier: notifier, user: user, device: device, notification: notification})
+		}
+	}
+}
+
+// queuePush queues the notification without waiting, it is dropped when the queue is full or closed
+func (h *Hub) queuePush(job pushJob) {
+	h.pushLock.RLock()
+	defer h.pushLock.RUnlock()
+
+	if h.pushQueue == nil {
+		return
+	}
+
+	h.pushes.Add(1)
+	select {
+	case h.pushQueue <- job:
+	default:
+		h.pushes.Done()
+		log.Printf("push queue is full, dropping notification to %v\n", job.user)
+	}
+}
+
+// closePushes stops queueing notifications, workers exit once the queued ones are sent
+func (h *Hub) closePushes() {
+	h.pushLock.Lock()
+	defer h.pushLock.Unlock()
+
+	if h.pushQueue != nil {
+		close(h.pushQueue)
+		h.pushQueue = nil
+	}
+}
+
+func (h *Hub) pushWorker(queue <-chan pushJob) {
+	for job := range queue {
+		h.notify(job)
+	}
+}
+
+func (h *Hub) notify(job pushJob) {
+	defer h.pushes.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(h.config.Push.Timeout))
+	defer cancel()
+
+	err := job.notifier.Notify(ctx, job.device.Token, job.notification)
+	if errors.Is(err, push.ErrUnregistered) {
+		_ = h.devices.Unregister(job.user, job.device.Token)
+		return
+	}
+	if err != nil {
+		log.Printf("error sending push notification to %v: %v\n", job.device.Platform, err)
+	}
+}
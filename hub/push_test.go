@@ -0,0 +1,156 @@
+package hub
+
+import (
+	"context"
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/push"
+	"doki.co.in/doki_real_time_service/relation"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNotifier records notifications instead of sending them
+type fakeNotifier struct {
+	sync.Mutex
+	sent map[string][]push.Notification
+	err  error
+}
+
+func (n *fakeNotifier) Notify(_ context.Context, token string, notification push.Notification) error {
+	n.Lock()
+	defer n.Unlock()
+
+	if n.sent == nil {
+		n.sent = make(map[string][]push.Notification)
+	}
+	n.sent[token] = append(n.sent[token], notification)
+	return n.err
+}
+
+// gatedNotifier blocks every notification until release is closed
+type gatedNotifier struct {
+	fakeNotifier
+	started chan string
+	release chan struct{}
+}
+
+func (n *gatedNotifier) Notify(ctx context.Context, token string, notification push.Notification) error {
+	n.started <- token
+	<-n.release
+	return n.fakeNotifier.Notify(ctx, token, notification)
+}
+
+func createPushHub(t *testing.T, users ...string) (*Hub, map[string]*fakeClient, *fakeNotifier) {
+	h, clients := createRoutingHub(users...)
+
+	templates, err := push.NewTemplates(nil)
+	require.NoError(t, err)
+	notifier := &fakeNotifier{}
+	h.SetPushNotifiers(map[string]push.PushNotifier{push.PlatformFcm: notifier}, templates)
+	t.Cleanup(h.closePushes)
+
+	return h, clients, notifier
+}
+
+func TestPushToOfflineRecipient(t *testing.T) {
+	h, _, notifier := createPushHub(t, "alice@phone")
+
+	require.NoError(t, h.receive([]byte(`{"type":"register_device","from":"bob","token":"bob-token","platform":"fcm"}`), "bob", "phone", codec.Json))
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "hi"), "alice", "phone", codec.Json))
+	h.pushes.Wait()
+
+	require.Len(t, notifier.sent["bob-token"], 1)
+	notification := notifier.sent["bob-token"][0]
+	assert.Equal(t, "alice", notification.Title)
+	assert.Equal(t, "hi", notification.Body)
+	assert.Equal(t, "chat_alice", notification.CollapseKey)
+	assert.Equal(t, "chat_message", notification.Data["type"])
+
+	// sender never gets notified of own message
+	assert.Len(t, notifier.sent, 1)
+}
+
+func TestPushSuppressed(t *testing.T) {
+	h, _, notifier := createPushHub(t, "alice@phone", "bob@web")
+	require.NoError(t, h.Devices().Register("bob", push.Device{Token: "bob-token", Platform: push.PlatformFcm}))
+	require.NoError(t, h.Devices().Register("carol", push.Device{Token: "carol-token", Platform: push.PlatformFcm}))
+
+	// bob has an active resource
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "hi"), "alice", "phone", codec.Json))
+
+	// carol muted the conversation
+	require.NoError(t, h.BlockStore().Mute("carol", "alice"))
+	require.NoError(t, h.receive(chatFrom("alice", "carol", "hi"), "alice", "phone", codec.Json))
+
+	// typing status has no notification
+	require.NoError(t, h.BlockStore().Unmute("carol", "alice"))
+	require.NoError(t, h.receive([]byte(`{"type":"typing_status","from":"alice","to":"carol"}`), "alice", "phone", codec.Json))
+
+	h.pushes.Wait()
+	assert.Empty(t, notifier.sent)
+}
+
+func TestPushMessageRequest(t *testing.T) {
+	h, _, notifier := createPushHub(t, "alice@phone")
+	h.SetMessagePolicy(relation.FriendsAndRequests(h.Relations()))
+	require.NoError(t, h.Devices().Register("bob", push.Device{Token: "bob-token", Platform: push.PlatformFcm}))
+
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "hi"), "alice", "phone", codec.Json))
+	h.pushes.Wait()
+
+	require.Len(t, notifier.sent["bob-token"], 1)
+	assert.Equal(t, "message_request", notifier.sent["bob-token"][0].Data["type"])
+}
+
+func TestPushUnregisteredToken(t *testing.T) {
+	h, _, notifier := createPushHub(t, "alice@phone")
+	notifier.err = push.ErrUnregistered
+	require.NoError(t, h.Devices().Register("bob", push.Device{Token: "bob-token", Platform: push.PlatformFcm}))
+	require.NoError(t, h.Devices().Register("bob", push.Device{Token: "bob-ios", Platform: push.PlatformApns}))
+
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "hi"), "alice", "phone", codec.Json))
+	h.pushes.Wait()
+
+	// apns is not enabled so its token is kept
+	assert.Equal(t, []push.Device{{Token: "bob-ios", Platform: push.PlatformApns}}, h.Devices().Devices("bob"))
+
+	require.NoError(t, h.receive([]byte(`{"type":"unregister_device","from":"bob","token":"bob-ios"}`), "bob", "phone", codec.Json))
+	assert.Empty(t, h.Devices().Devices("bob"))
+}
+
+func TestPushQueue(t *testing.T) {
+	h, _ := createRoutingHub("alice@phone")
+	h.config.Push.Workers = 1
+	h.config.Push.QueueSize = 1
+	for _, user := range []string{"bob", "carol", "dave"} {
+		require.NoError(t, h.Devices().Register(user, push.Device{Token: user + "-token", Platform: push.PlatformFcm}))
+	}
+
+	templates, err := push.NewTemplates(nil)
+	require.NoError(t, err)
+	notifier := &gatedNotifier{started: make(chan string, 3), release: make(chan struct{})}
+	h.SetPushNotifiers(map[string]push.PushNotifier{push.PlatformFcm: notifier}, templates)
+
+	// single worker is busy with bob, carol waits in the queue and dave doesn't fit
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "hi"), "alice", "phone", codec.Json))
+	assert.Equal(t, "bob-token", <-notifier.started)
+	require.NoError(t, h.receive(chatFrom("alice", "carol", "hi"), "alice", "phone", codec.Json))
+	require.NoError(t, h.receive(chatFrom("alice", "dave", "hi"), "alice", "phone", codec.Json))
+
+	// shutdown waits for the queued notifications
+	shutdownDone := make(chan error, 1)
+	go func() { shutdownDone <- h.Shutdown(context.Background()) }()
+	close(notifier.release)
+	require.NoError(t, <-shutdownDone)
+
+	assert.Len(t, notifier.sent, 2)
+	assert.Contains(t, notifier.sent, "carol-token")
+
+	// notifications after shutdown are not queued
+	require.NoError(t, h.receive(chatFrom("alice", "dave", "hi"), "alice", "phone", codec.Json))
+	h.pushes.Wait()
+	assert.Len(t, notifier.sent, 2)
+}
@@ -0,0 +1,62 @@
+package hub
+
+import (
+	"doki.co.in/doki_real_time_service/config"
+	"doki.co.in/doki_real_time_service/utils"
+	"time"
+)
+
+// rateBucket is the token bucket of a rate limit class
+type rateBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimited is a client whose payloads are rate limited, every transport is one through clientState
+type rateLimited interface {
+	allow(class string, limit config.RateLimit, now time.Time) bool
+}
+
+// AllowRate takes a payload of the class from the limit of connected resource
+// payloads of classes without a limit and of resources which are not connected are always allowed
+func (h *Hub) AllowRate(username, resource, class string) bool {
+	limit, ok := h.config.RateLimits[class]
+	if !ok {
+		return true
+	}
+
+	limited, ok := h.GetIndividualClient(utils.CreateUserFromUsernameAndResource(username, resource)).(rateLimited)
+	if !ok {
+		return true
+	}
+
+	return limited.allow(class, limit, time.Now())
+}
+
+// allow takes a token from bucket of the class, bucket starts full and is refilled at the rate of the limit
+func (c *clientState) allow(class string, limit config.RateLimit, now time.Time) bool {
+	c.rateLock.Lock()
+	defer c.rateLock.Unlock()
+
+	if c.rates == nil {
+		c.rates = make(map[string]*rateBucket)
+	}
+
+	bucket, ok := c.rates[class]
+	if !ok {
+		bucket = &rateBucket{
+			tokens: float64(limit.Burst),
+			last:   now,
+		}
+		c.rates[class] = bucket
+	}
+
+	bucket.tokens = min(float64(limit.Burst), bucket.tokens+now.Sub(bucket.last).Seconds()*limit.Rate)
+	bucket.last = now
+	if bucket.tokens < 1 {
+		return false
+	}
+
+	bucket.tokens--
+	return true
+}
@@ -0,0 +1,88 @@
+package hub
+
+import (
+	"doki.co.in/doki_real_time_service/client"
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/config"
+	"doki.co.in/doki_real_time_service/payload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// limitedClient is a fake client which is rate limited like the clients of every transport
+type limitedClient struct {
+	*fakeClient
+	state clientState
+}
+
+func (c *limitedClient) allow(class string, limit config.RateLimit, now time.Time) bool {
+	return c.state.allow(class, limit, now)
+}
+
+func TestRateLimit(t *testing.T) {
+	h, clients := createRoutingHub("bob@phone")
+	h.config.RateLimits = map[string]config.RateLimit{payload.RateLimitTyping: {Rate: 0.001, Burst: 2}}
+	for _, user := range []string{"alice@phone", "alice@web"} {
+		clients[user] = &fakeClient{user: user}
+		h.addClient(user, &limitedClient{fakeClient: clients[user], state: createClientState(client.ConnectionInfo{}, codec.Json)})
+	}
+	typing := []byte(`{"type":"typing_status","from":"alice","to":"bob","id":"t1"}`)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, h.receive(typing, "alice", "phone", codec.Json))
+	}
+	assert.Len(t, clients["bob@phone"].written, 2)
+
+	frame := lastFrame(t, clients["alice@phone"])
+	assert.Equal(t, "error", frame["type"])
+	assert.Equal(t, "rate_limited", frame["code"])
+	assert.Equal(t, "t1", frame["id"])
+
+	// every resource has its own limit and classes without a limit are not limited
+	require.NoError(t, h.receive(typing, "alice", "web", codec.Json))
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "hi"), "alice", "phone", codec.Json))
+	assert.Len(t, clients["bob@phone"].written, 4)
+}
+
+func TestRateBucketRefills(t *testing.T) {
+	state := createClientState(client.ConnectionInfo{}, codec.Json)
+	limit := config.RateLimit{Rate: 2, Burst: 2}
+	now := time.Unix(0, 0)
+
+	assert.True(t, state.allow(payload.RateLimitTyping, limit, now))
+	assert.True(t, state.allow(payload.RateLimitTyping, limit, now))
+	assert.False(t, state.allow(payload.RateLimitTyping, limit, now))
+	assert.True(t, state.allow(payload.RateLimitMessaging, limit, now))
+
+	assert.True(t, state.allow(payload.RateLimitTyping, limit, now.Add(500*time.Millisecond)))
+	assert.False(t, state.allow(payload.RateLimitTyping, limit, now.Add(500*time.Millisecond)))
+
+	// idle time doesn't fill the bucket over its burst
+	later := now.Add(time.Hour)
+	assert.True(t, state.allow(payload.RateLimitTyping, limit, later))
+	assert.True(t, state.allow(payload.RateLimitTyping, limit, later))
+	assert.False(t, state.allow(payload.RateLimitTyping, limit, later))
+}
+
+func TestDefaultRateLimits(t *testing.T) {
+	h, clients := createRoutingHub("bob@phone")
+	clients["alice@phone"] = &fakeClient{user: "alice@phone"}
+	h.addClient("alice@phone", &limitedClient{fakeClient: clients["alice@phone"], state: createClientState(client.ConnectionInfo{}, codec.Json)})
+
+	// every class of the service payloads is limited by default
+	classes := []string{payload.RateLimitDefault, payload.RateLimitProtocol, payload.RateLimitMessaging, payload.RateLimitTyping,
+		payload.RateLimitSocial, payload.RateLimitNodes, payload.RateLimitSubscription}
+	for _, class := range classes {
+		assert.Contains(t, h.config.RateLimits, class)
+	}
+
+	limit := h.config.RateLimits[payload.RateLimitTyping]
+	typing := []byte(`{"type":"typing_status","from":"alice","to":"bob","id":"t1"}`)
+	for i := 0; i <= limit.Burst; i++ {
+		require.NoError(t, h.receive(typing, "alice", "phone", codec.Json))
+	}
+	assert.Len(t, clients["bob@phone"].written, limit.Burst)
+	assert.Equal(t, "rate_limited", lastFrame(t, clients["alice@phone"])["code"])
+}
@@ -0,0 +1,133 @@
+package hub
+
+import (
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/relation"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reactionFrom returns reaction on message "1" of the direct conversation
+func reactionFrom(from, to, action, reaction string) []byte {
+	return []byte(`{"type":"message_reaction","from":"` + from + `","to":"` + to + `","id":"1","reaction":"` + reaction + `","action":"` + action + `"}`)
+}
+
+func TestMessageReaction(t *testing.T) {
+	h, clients := createRoutingHub("alice@phone", "alice@web", "bob@phone", "carol@phone")
+
+	// routed like edited message, to the recipient and other resources of the sender
+	require.NoError(t, h.receive(reactionFrom("alice", "bob", "add", "👍"), "alice", "phone", codec.Json))
+	assert.Equal(t, map[string]int{"alice@web": 1, "bob@phone": 1}, received(clients))
+	frame := lastFrame(t, clients["bob@phone"])
+	assert.Equal(t, "message_reaction", frame["type"])
+	assert.Equal(t, "👍", frame["reaction"])
+
+	// same reaction again doesn't change anything
+	require.NoError(t, h.receive(reactionFrom("alice", "bob", "add", "👍"), "alice", "phone", codec.Json))
+	assert.Equal(t, map[string]int{"alice@web": 1, "bob@phone": 1}, received(clients))
+
+	// reactions of both users are on the same message
+	require.NoError(t, h.receive(reactionFrom("bob", "alice", "add", "❤️"), "bob", "phone", codec.Json))
+	assert.Equal(t, map[string][]string{"alice": {"👍"}, "bob": {"❤️"}}, h.Reactions().Reactions("direct:alice:bob", "1"))
+
+	require.NoError(t, h.receive(reactionFrom("alice", "bob", "remove", "👍"), "alice", "web", codec.Json))
+	assert.Equal(t, "remove", lastFrame(t, clients["bob@phone"])["action"])
+	assert.Equal(t, "remove", lastFrame(t, clients["alice@phone"])["action"])
+
+	// removing reaction which is not there is dropped
+	before := received(clients)
+	require.NoError(t, h.receive(reactionFrom("alice", "bob", "remove", "👍"), "alice", "web", codec.Json))
+	assert.Equal(t, before, received(clients))
+}
+
+func TestMessageReactionLimit(t *testing.T) {
+	h, clients := createRoutingHub("alice@phone", "alice@web", "bob@phone")
+
+	for _, reaction := range []string{"👍", "😂", "🎉"} {
+		require.NoError(t, h.receive(reactionFrom("alice", "bob", "add", reaction), "alice", "phone", codec.Json))
+	}
+	require.NoError(t, h.receive(reactionFrom("alice", "bob", "add", "🔥"), "alice", "phone", codec.Json))
+
+	// only the sending resource gets the error
+	assert.Equal(t, map[string]any{"type": "error", "to": "alice", "id": "1", "code": "reaction_limit", "reason": "Reaction limit of the message is reached."}, lastFrame(t, clients["alice@phone"]))
+	assert.Equal(t, map[string]int{"alice@phone": 1, "alice@web": 3, "bob@phone": 3}, received(clients))
+
+	// limit is per user, removing a reaction makes room for another
+	require.NoError(t, h.receive(reactionFrom("bob", "alice", "add", "🔥"), "bob", "phone", codec.Json))
+	require.NoError(t, h.receive(reactionFrom("alice", "bob", "remove", "👍"), "alice", "phone", codec.Json))
+	require.NoError(t, h.receive(reactionFrom("alice", "bob", "add", "🔥"), "alice", "phone", codec.Json))
+	assert.Equal(t, "🔥", lastFrame(t, clients["bob@phone"])["reaction"])
+}
+
+func TestInvalidMessageReaction(t *testing.T) {
+	h, clients := createRoutingHub("alice@phone", "bob@phone")
+
+	for _, reaction := range []string{"", "ok", "👍 ", "<b>", "^", "👍👍👍👍👍👍👍👍👍"} {
+		assert.Error(t, h.receive(reactionFrom("alice", "bob", "add", reaction), "alice", "phone", codec.Json), reaction)
+	}
+	assert.Error(t, h.receive(reactionFrom("alice", "bob", "like", "👍"), "alice", "phone", codec.Json))
+
+	// emoji sequences are single reactions
+	for _, reaction := range []string{"👍🏽", "1️⃣", "🇮🇳", "👩‍👩‍👧", "🏴󠁧󠁢󠁳󠁣󠁴󠁿"} {
+		assert.NoError(t, h.receive(reactionFrom("alice", "bob", "add", reaction), "alice", "phone", codec.Json), reaction)
+		assert.NoError(t, h.receive(reactionFrom("alice", "bob", "remove", reaction), "alice", "phone", codec.Json), reaction)
+	}
+
+	// group reaction needs members and can't have a recipient
+	assert.Error(t, h.receive([]byte(`{"type":"message_reaction","from":"alice","groupId":"g1","id":"1","reaction":"👍","action":"add"}`), "alice", "phone", codec.Json))
+	assert.Error(t, h.receive([]byte(`{"type":"message_reaction","from":"alice","to":"bob","groupId":"g1","members":["bob"],"id":"1","reaction":"👍","action":"add"}`), "alice", "phone", codec.Json))
+	assert.Len(t, clients["bob@phone"].written, 10)
+}
+
+func TestGroupMessageReaction(t *testing.T) {
+	h, clients := createRoutingHub("alice@phone", "alice@web", "bob@phone", "carol@phone", "dave@phone")
+	require.NoError(t, h.BlockStore().Block("carol", "alice"))
+
+	group := []byte(`{"type":"message_reaction","from":"alice","groupId":"g1","members":["alice","bob","carol"],"id":"1","reaction":"👍","action":"add"}`)
+	require.NoError(t, h.receive(group, "alice", "phone", codec.Json))
+
+	// members who haven't blocked the sender get it, message policy is not checked for groups
+	assert.Equal(t, map[string]int{"alice@web": 1, "bob@phone": 1}, received(clients))
+	assert.Equal(t, map[string][]string{"alice": {"👍"}}, h.Reactions().Reactions("group:g1", "1"))
+
+	// message ids of a group don't clash with direct conversations
+	require.NoError(t, h.receive(reactionFrom("alice", "bob", "add", "👍"), "alice", "phone", codec.Json))
+	assert.Equal(t, map[string]int{"alice@web": 2, "bob@phone": 2}, received(clients))
+}
+
+func TestMessageReactionPolicy(t *testing.T) {
+	h, clients := createRoutingHub("alice@phone", "bob@phone", "carol@phone")
+	h.SetMessagePolicy(relation.FriendsOnly(h.Relations()))
+	require.NoError(t, h.Relations().AddFriendRequest("alice", "bob"))
+	_, err := h.Relations().AcceptFriendRequest("bob", "alice")
+	require.NoError(t, err)
+
+	require.NoError(t, h.receive(reactionFrom("alice", "carol", "add", "👍"), "alice", "phone", codec.Json))
+	assert.Equal(t, "message_policy_rejected", lastFrame(t, clients["alice@phone"])["code"])
+
+	// group with a member who doesn't accept messages from the sender reaches nobody
+	group := `{"type":"message_reaction","from":"alice","groupId":"g1","members":["alice","bob","carol"],"id":"1","reaction":"👍","action":"add"}`
+	require.NoError(t, h.receive([]byte(group), "alice", "phone", codec.Json))
+	assert.Equal(t, map[string]int{"alice@phone": 2}, received(clients))
+	assert.Empty(t, h.Reactions().Reactions("group:g1", "1"))
+
+	group = `{"type":"message_reaction","from":"alice","groupId":"g1","members":["alice","bob"],"id":"1","reaction":"👍","action":"add"}`
+	require.NoError(t, h.receive([]byte(group), "alice", "phone", codec.Json))
+	assert.Equal(t, map[string]int{"alice@phone": 2, "bob@phone": 1}, received(clients))
+}
+
+func TestGroupMessageReactionMembersLimit(t *testing.T) {
+	h, _ := createRoutingHub("alice@phone")
+
+	members := make([]string, 257)
+	for index := range members {
+		members[index] = `"user` + strconv.Itoa(index) + `"`
+	}
+	group := `{"type":"message_reaction","from":"alice","groupId":"g1","members":[` + strings.Join(members, ",") + `],"id":"1","reaction":"👍","action":"add"}`
+	assert.Error(t, h.receive([]byte(group), "alice", "phone", codec.Json))
+	assert.NoError(t, h.receive([]byte(strings.Replace(group, `"user0",`, "", 1)), "alice", "phone", codec.Json))
+}
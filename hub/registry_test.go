@@ -0,0 +1,90 @@
+package hub
+
+import (
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/payload"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// nudge is a custom payload registered from outside of the payload package
+type nudge struct {
+	Type string `json:"type" validate:"required"`
+	From string `json:"from" validate:"required"`
+	To   string `json:"to" validate:"required"`
+}
+
+var registerNudge sync.Once
+
+func createNudgeHub(t *testing.T) *Hub {
+	registerNudge.Do(func() {
+		require.NoError(t, payload.Register(payload.Registration{
+			Type:           "test_nudge",
+			New:            func() any { return &nudge{} },
+			Rule:           payload.ConversationRule(),
+			RateLimitClass: payload.RateLimitMessaging,
+		}))
+	})
+
+	return createTestHub()
+}
+
+func TestRegisterRejectsInvalidPayloads(t *testing.T) {
+	err := payload.Register(payload.Registration{Type: "chat_message", New: func() any { return &nudge{} }, Rule: payload.RecipientRule()})
+	assert.ErrorIs(t, err, payload.ErrDuplicatePayloadType)
+
+	assert.Error(t, payload.Register(payload.Registration{Type: "test_no_factory"}))
+	assert.Error(t, payload.Register(payload.Registration{Type: " ", New: func() any { return &nudge{} }, Rule: payload.RecipientRule()}))
+	assert.Error(t, payload.Register(payload.Registration{Type: "test_no_routing", New: func() any { return &nudge{} }}))
+
+	_, ok := payload.Lookup("test_no_factory")
+	assert.False(t, ok)
+}
+
+func TestRegisteredPayloadIsRouted(t *testing.T) {
+	h := createNudgeHub(t)
+	alicePhone := &fakeClient{user: "alice@phone"}
+	aliceWeb := &fakeClient{user: "alice@web"}
+	bob := &fakeClient{user: "bob@phone"}
+	h.addClient("alice@phone", alicePhone)
+	h.addClient("alice@web", aliceWeb)
+	h.addClient("bob@phone", bob)
+
+	require.NoError(t, h.receive([]byte(`{"type":"test_nudge","from":"alice","to":"bob"}`), "alice", "phone", codec.Json))
+	assert.Len(t, bob.written, 1)
+	assert.Len(t, aliceWeb.written, 1)
+	assert.Empty(t, alicePhone.written)
+
+	assert.Error(t, h.receive([]byte(`{"type":"test_nudge","from":"alice"}`), "alice", "phone", codec.Json))
+}
+
+func TestServerOnlyPayloadIsRejected(t *testing.T) {
+	h := createNudgeHub(t)
+
+	err := h.receive([]byte(`{"type":"server_shutdown","from":"alice","to":"bob","reconnectAfter":1}`), "alice", "phone", codec.Json)
+	assert.Error(t, err)
+}
+
+func TestAdminListPayloads(t *testing.T) {
+	h := createNudgeHub(t)
+
+	rec := adminRequest(h, http.MethodGet, "/admin/payloads", testAdminKey)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var registrations []payload.Registration
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&registrations))
+
+	types := make(map[string]payload.Registration)
+	for _, registration := range registrations {
+		types[registration.Type] = registration
+	}
+	assert.Equal(t, payload.ConversationRule(), types["test_nudge"].Rule)
+	assert.Nil(t, types["hello"].Rule)
+	assert.Equal(t, payload.AuthSender, types["test_nudge"].Auth)
+	assert.Equal(t, payload.AuthServerOnly, types["welcome"].Auth)
+	assert.Equal(t, payload.RateLimitMessaging, types["chat_message"].RateLimitClass)
+}
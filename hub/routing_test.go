@@ -0,0 +1,224 @@
+package hub
+
+import (
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/payload"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// createRoutingHub creates hub with fake clients connected for the given complete users
+func createRoutingHub(users ...string) (*Hub, map[string]*fakeClient) {
+	payload.InitPayload()
+	h := createTestHub()
+
+	clients := make(map[string]*fakeClient)
+	for _, user := range users {
+		clients[user] = &fakeClient{user: user}
+		h.addClient(user, clients[user])
+	}
+
+	return h, clients
+}
+
+// received returns number of frames written to every client
+func received(clients map[string]*fakeClient) map[string]int {
+	counts := make(map[string]int)
+	for user, c := range clients {
+		if len(c.written) > 0 {
+			counts[user] = len(c.written)
+		}
+	}
+
+	return counts
+}
+
+func TestBuiltinRoutingRules(t *testing.T) {
+	users := []string{"alice@phone", "alice@web", "bob@phone", "bob@web", "carol@phone"}
+
+	tests := []struct {
+		name     string
+		data     string
+		expected map[string]int
+	}{
+		{
+			name:     "chat message echoes to sender",
+			data:     `{"type":"chat_message","from":"alice","to":"bob","id":"1","subject":"text","body":"hi","sendAt":"2025-01-20T10:15:30Z"}`,
+			expected: map[string]int{"alice@web": 1, "bob@phone": 1, "bob@web": 1},
+		},
+		{
+			name:     "self chat message reaches other resources once",
+			data:     `{"type":"chat_message","from":"alice","to":"alice","id":"1","subject":"text","body":"hi","sendAt":"2025-01-20T10:15:30Z"}`,
+			expected: map[string]int{"alice@web": 1},
+		},
+		{
+			name:     "typing status is not echoed",
+			data:     `{"type":"typing_status","from":"alice","to":"bob"}`,
+			expected: map[string]int{"bob@phone": 1, "bob@web": 1},
+		},
+		{
+			name:     "delete for self only stays with sender",
+			data:     `{"type":"delete_message","from":"alice","to":"bob","id":["1"]}`,
+			expected: map[string]int{"alice@web": 1},
+		},
+		{
+			name:     "delete for everyone",
+			data:     `{"type":"delete_message","from":"alice","to":"bob","id":["1"],"everyone":true}`,
+			expected: map[string]int{"alice@web": 1, "bob@phone": 1, "bob@web": 1},
+		},
+		{
+			name:     "self friend request is dropped",
+			data:     `{"type":"user_send_friend_request","from":"alice","to":"alice","requestedBy":"alice","addedOn":"2025-01-20T10:15:30Z"}`,
+			expected: map[string]int{},
+		},
+		{
+			name:     "profile update stays with sender",
+			data:     `{"type":"user_update_profile","from":"alice","name":"Alice"}`,
+			expected: map[string]int{"alice@web": 1},
+		},
+		{
+			name:     "tagged users get every resource",
+			data:     `{"type":"user_create_root_node","from":"alice","id":"n1","nodeType":"post","usersTagged":["bob","carol"]}`,
+			expected: map[string]int{"alice@web": 1, "bob@phone": 1, "bob@web": 1, "carol@phone": 1},
+		},
+		{
+			name: "mention of reply target is delivered once",
+			data: `{"type":"user_create_secondary_node","from":"alice","to":"bob","nodeId":"c1","nodeType":"comment",` +
+				`"mentions":["carol","carol"],"replyOnNodeCreatedBy":"carol","parents":[{"nodeId":"n1","nodeType":"post"}]}`,
+			expected: map[string]int{"alice@web": 1, "bob@phone": 1, "bob@web": 1, "carol@phone": 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, clients := createRoutingHub(users...)
+
+			require.NoError(t, h.receive([]byte(test.data), "alice", "phone", codec.Json))
+			assert.Equal(t, test.expected, received(clients))
+		})
+	}
+}
+
+func TestNodeRoutingRule(t *testing.T) {
+	h, clients := createRoutingHub("alice@phone", "alice@web", "bob@phone")
+	h.Subscribe("poll-1", "alice@phone", false)
+	h.Subscribe("poll-1", "alice@web", false)
+	h.Subscribe("poll-1", "bob@phone", false)
+	h.Subscribe("poll-1", "carol@phone", false)
+
+	data := `{"type":"poll_votes_update","from":"alice","pollId":"poll-1","votes":[1,2]}`
+	require.NoError(t, h.receive([]byte(data), "alice", "phone", codec.Json))
+
+	assert.Equal(t, map[string]int{"alice@web": 1, "bob@phone": 1}, received(clients))
+	assert.NotContains(t, h.GetSubscribers("poll-1"), "carol@phone")
+}
+
+func TestConfiguredPayloadIsRouted(t *testing.T) {
+	h, clients := createRoutingHub("alice@phone", "bob@phone", "carol@phone")
+
+	rule := payload.RoutingRule{Users: []string{"to", "cc"}, When: "urgent"}
+	if _, ok := payload.Lookup("test_page"); !ok {
+		require.NoError(t, payload.RegisterRouted("test_page", rule, ""))
+	}
+
+	require.NoError(t, h.receive([]byte(`{"type":"test_page","from":"alice","to":"bob","cc":["carol","bob"]}`), "alice", "phone", codec.Json))
+	assert.Empty(t, received(clients))
+
+	require.NoError(t, h.receive([]byte(`{"type":"test_page","from":"alice","to":"bob","cc":["carol","bob"],"urgent":true}`), "alice", "phone", codec.Json))
+	assert.Equal(t, map[string]int{"bob@phone": 1, "carol@phone": 1}, received(clients))
+}
+
+// audienceUsers are the users a node payload can be addressed to, alice is always the sender
+var audienceUsers = []string{"alice", "bob", "carol"}
+
+// subsets returns every subset of the users and one more with bob repeated
+func subsets(users []string) [][]string {
+	all := [][]string{{}}
+	for _, user := range users {
+		for _, subset := range all {
+			all = append(all, append(subset[:len(subset):len(subset)], user))
+		}
+	}
+
+	return append(all, []string{"bob", "bob"})
+}
+
+// expectedAudience returns frames every resource must receive when alice sends from phone
+// alice gets the echo on web and every other user in the audience gets it on both resources
+func expectedAudience(audience ...[]string) map[string]int {
+	expected := map[string]int{"alice@web": 1}
+	for _, users := range audience {
+		for _, user := range users {
+			if user != "alice" && user != "" {
+				expected[user+"@phone"] = 1
+				expected[user+"@web"] = 1
+			}
+		}
+	}
+
+	return expected
+}
+
+func TestRootNodeAudienceIsDeduplicated(t *testing.T) {
+	for _, tagged := range subsets(audienceUsers) {
+		t.Run(fmt.Sprintf("tagged=%v", tagged), func(t *testing.T) {
+			h, clients := createRoutingHub("alice@phone", "alice@web", "bob@phone", "bob@web", "carol@phone", "carol@web")
+
+			data, err := json.Marshal(map[string]any{
+				"type":        "user_create_root_node",
+				"from":        "alice",
+				"id":          "n1",
+				"nodeType":    "post",
+				"usersTagged": tagged,
+			})
+			require.NoError(t, err)
+
+			require.NoError(t, h.receive(data, "alice", "phone", codec.Json))
+			assert.Equal(t, expectedAudience(tagged), received(clients))
+		})
+	}
+}
+
+func TestSecondaryNodeAudienceIsDeduplicated(t *testing.T) {
+	for _, to := range audienceUsers {
+		for _, mentions := range subsets(audienceUsers) {
+			for _, replyOn := range append([]string{""}, audienceUsers...) {
+				t.Run(fmt.Sprintf("to=%v/mentions=%v/replyOn=%v", to, mentions, replyOn), func(t *testing.T) {
+					h, clients := createRoutingHub("alice@phone", "alice@web", "bob@phone", "bob@web", "carol@phone", "carol@web")
+
+					data, err := json.Marshal(map[string]any{
+						"type":                 "user_create_secondary_node",
+						"from":                 "alice",
+						"to":                   to,
+						"nodeId":               "c1",
+						"nodeType":             "comment",
+						"mentions":             mentions,
+						"replyOnNodeCreatedBy": replyOn,
+						"parents":              []map[string]string{{"nodeId": "n1", "nodeType": "post"}},
+					})
+					require.NoError(t, err)
+
+					require.NoError(t, h.receive(data, "alice", "phone", codec.Json))
+					assert.Equal(t, expectedAudience([]string{to}, mentions, []string{replyOn}), received(clients))
+				})
+			}
+		}
+	}
+}
+
+func TestNodeSubscriberInAudienceIsDeduplicated(t *testing.T) {
+	h, clients := createRoutingHub("alice@phone", "alice@web", "bob@phone")
+	h.Subscribe("poll-1", "alice@web", false)
+	h.Subscribe("poll-1", "bob@phone", false)
+
+	rule := payload.RoutingRule{Users: []string{"to"}, Nodes: []string{"pollId"}, EchoToSender: true}
+	if _, ok := payload.Lookup("test_poll_nudge"); !ok {
+		require.NoError(t, payload.RegisterRouted("test_poll_nudge", rule, ""))
+	}
+
+	require.NoError(t, h.receive([]byte(`{"type":"test_poll_nudge","from":"alice","to":"bob","pollId":"poll-1"}`), "alice", "phone", codec.Json))
+	assert.Equal(t, map[string]int{"alice@web": 1, "bob@phone": 1}, received(clients))
+}
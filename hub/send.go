@@ -0,0 +1,51 @@
+package hub
+
+import (
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/utils"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// ServeSend receives a payload from clients of http transports
+// resource must be connected through one of the transports, the payload is routed same as a websocket message
+func (h *Hub) ServeSend(w http.ResponseWriter, r *http.Request) {
+	username, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	h.send(w, r, username)
+}
+
+func (h *Hub) send(w http.ResponseWriter, r *http.Request, username string) {
+	resource := r.URL.Query().Get("resource")
+	if resource == "" {
+		http.Error(w, "resource is required", http.StatusBadRequest)
+		return
+	}
+
+	if h.GetIndividualClient(utils.CreateUserFromUsernameAndResource(username, resource)) == nil {
+		http.Error(w, "resource not connected", http.StatusNotFound)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.config.Websocket.IncomingPayloadLimit))
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.receive(data, username, resource, codec.Json); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
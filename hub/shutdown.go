@@ -0,0 +1,83 @@
+package hub
+
+import (
+	"context"
+	"doki.co.in/doki_real_time_service/client"
+	"doki.co.in/doki_real_time_service/payload"
+	"doki.co.in/doki_real_time_service/utils"
+	"github.com/gorilla/websocket"
+	"math/rand/v2"
+	"time"
+)
+
+const shutdownCloseReason = "server shutting down"
+
+// Shutdown stops accepting new connections, asks every connected client to reconnect later
+// and waits for their send queues and queued push notifications to drain
+// connections still open when ctx is done are closed without draining
+// netpoll transport is stopped in both cases
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.shuttingDown.Store(true)
+
+	h.RLock()
+	var connectedClients []client.Client
+	for _, resources := range h.clients {
+		for _, conn := range resources {
+			connectedClients = append(connectedClients, conn)
+		}
+	}
+	h.RUnlock()
+
+	reconnectAfter := time.Duration(h.config.Websocket.ShutdownReconnectAfter)
+	reconnectJitter := time.Duration(h.config.Websocket.ShutdownReconnectJitter)
+
+	for _, conn := range connectedClients {
+		username, _ := conn.GetUserInfo()
+
+		// jitter spreads the reconnects so that all the clients don't hit the remaining instances at once
+		reconnectAfter := reconnectAfter
+		if reconnectJitter > 0 {
+			reconnectAfter += rand.N(reconnectJitter)
+		}
+
+		data := utils.PayloadToJson(payload.CreateServerShutdownPayload(username, reconnectAfter))
+		if data != nil {
+			conn.WriteToChannel(data)
+		}
+
+		conn.Close(websocket.CloseServiceRestart, shutdownCloseReason)
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		h.writers.Wait()
+
+		// clients can't trigger notifications anymore
+		h.closePushes()
+		h.pushes.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		h.closePoller()
+		return nil
+
+	case <-ctx.Done():
+		h.closePushes()
+		for _, conn := range connectedClients {
+			if conn.GetConnection() != nil {
+				_ = conn.GetConnection().Close()
+			}
+		}
+		h.closePoller()
+		return ctx.Err()
+	}
+}
+
+// closePoller stops netpoll transport once its connections are closed
+func (h *Hub) closePoller() {
+	if h.poller != nil {
+		h.poller.close()
+	}
+}
@@ -0,0 +1,88 @@
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/MicahParks/keyfunc/v3"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadiness(t *testing.T) {
+	h, _ := createRoutingHub()
+
+	rec := httptest.NewRecorder()
+	h.ServeReady(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	jwks, err := keyfunc.NewJWKSetJSON(json.RawMessage(`{"keys":[{"kty":"oct","kid":"test","k":"c2VjcmV0"}]}`))
+	require.NoError(t, err)
+	h.jwks = &jwks
+
+	rec = httptest.NewRecorder()
+	h.ServeReady(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	require.NoError(t, h.Shutdown(context.Background()))
+
+	// load balancer stops sending traffic while process is still healthy
+	rec = httptest.NewRecorder()
+	h.ServeReady(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.ServeHealth(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestShutdownDrainsClients(t *testing.T) {
+	h, clients := createRoutingHub("alice@phone", "bob@web")
+
+	// writer of a client which still has queued messages
+	h.writers.Add(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	shutdownDone := make(chan error, 1)
+	go func() { shutdownDone <- h.Shutdown(ctx) }()
+
+	select {
+	case err := <-shutdownDone:
+		t.Fatalf("shutdown returned before the send queue drained: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	h.writers.Done()
+	require.NoError(t, <-shutdownDone)
+
+	for user, c := range clients {
+		frame := lastFrame(t, c)
+		assert.Equal(t, "server_shutdown", frame["type"], user)
+		assert.Equal(t, &closeFrame{code: websocket.CloseServiceRestart, reason: shutdownCloseReason}, c.closedWith, user)
+	}
+
+	// clients reconnect to other instances
+	rec := httptest.NewRecorder()
+	_, ok := h.authenticate(rec, httptest.NewRequest(http.MethodGet, "/ws", nil))
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	h, clients := createRoutingHub("alice@phone")
+
+	// writer which never drains its send queue
+	h.writers.Add(1)
+	defer h.writers.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, h.Shutdown(ctx), context.DeadlineExceeded)
+	assert.NotNil(t, clients["alice@phone"].closedWith)
+	assert.False(t, h.IsReady(context.Background()))
+}
@@ -0,0 +1,215 @@
+package hub
+
+import (
+	"bytes"
+	"context"
+	"doki.co.in/doki_real_time_service/client"
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/utils"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// sseCloseEvent is the last event sent on the stream, same as websocket close frame
+const sseCloseEvent = "close"
+
+// sseClient is client of server sent events transport
+// server to client messages are streamed as events and client sends its payloads through [Hub.ServeSend]
+type sseClient struct {
+	hub *Hub
+
+	// user is complete user with resource part
+	user string
+
+	write chan []byte
+
+	clientState
+
+	closeRequest chan closeFrame
+	closeOnce    sync.Once
+
+	// done is closed when stream ends, nothing is written after that
+	done chan struct{}
+}
+
+func createSSEClient(hub *Hub, user string, info client.ConnectionInfo) *sseClient {
+	return &sseClient{
+		hub:          hub,
+		user:         user,
+		write:        make(chan []byte, hub.config.Websocket.SendQueueSize),
+		clientState:  createClientState(info, codec.Json),
+		closeRequest: make(chan closeFrame, 1),
+		done:         make(chan struct{}),
+	}
+}
+
+// GetConnection returns nil as there is no websocket connection
+func (c *sseClient) GetConnection() *websocket.Conn {
+	return nil
+}
+
+func (c *sseClient) GetUserInfo() (string, string) {
+	return utils.GetUsernameAndResourceFromUser(c.user)
+}
+
+func (c *sseClient) WriteToChannel(data *[]byte) {
+	select {
+	case c.write <- *data:
+	case <-c.done:
+	}
+}
+
+// Close asks stream to send the queued messages and end with close event
+func (c *sseClient) Close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.closeRequest <- closeFrame{
+			code:   code,
+			reason: reason,
+		}
+	})
+}
+
+// stream writes queued messages as events till request is done or close is requested
+func (c *sseClient) stream(ctx context.Context, w http.ResponseWriter) {
+	controller := http.NewResponseController(w)
+	ticker := time.NewTicker(time.Duration(c.hub.config.Websocket.PingInterval))
+	defer func() {
+		ticker.Stop()
+		close(c.done)
+		c.hub.writers.Done()
+	}()
+
+	for {
+		select {
+		case message := <-c.write:
+			if err := c.sendMessage(controller, w, message); err != nil {
+				return
+			}
+
+		case frame := <-c.closeRequest:
+			c.drainQueue(controller, w)
+
+			data, _ := json.Marshal(map[string]any{"code": frame.code, "reason": frame.reason})
+			_ = c.writeEvent(controller, w, sseCloseEvent, data)
+			return
+
+		case <-ticker.C:
+			// comment line keeps proxies from closing idle stream
+			if err := c.writeRaw(controller, w, []byte(": ping\n\n")); err != nil {
+				return
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *sseClient) sendMessage(controller *http.ResponseController, w io.Writer, message []byte) error {
+	frame, err := encodeFrame(message, codec.Json, c.protocolVersion())
+	if err != nil || frame == nil {
+		// message which can't be sent to this client doesn't end the stream
+		return nil
+	}
+
+	return c.writeEvent(controller, w, "", frame)
+}
+
+func (c *sseClient) drainQueue(controller *http.ResponseController, w io.Writer) {
+	for {
+		select {
+		case message := <-c.write:
+			if err := c.sendMessage(controller, w, message); err != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// writeEvent writes data as a single event, every line of data goes in its own data field
+func (c *sseClient) writeEvent(controller *http.ResponseController, w io.Writer, event string, data []byte) error {
+	var buffer bytes.Buffer
+	if event != "" {
+		_, _ = fmt.Fprintf(&buffer, "event: %s\n", event)
+	}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		buffer.WriteString("data: ")
+		buffer.Write(line)
+		buffer.WriteByte('\n')
+	}
+	buffer.WriteByte('\n')
+
+	return c.writeRaw(controller, w, buffer.Bytes())
+}
+
+func (c *sseClient) writeRaw(controller *http.ResponseController, w io.Writer, data []byte) error {
+	_ = controller.SetWriteDeadline(time.Now().Add(time.Duration(c.hub.config.Websocket.WriteWait)))
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+
+	return controller.Flush()
+}
+
+// ServeSSE streams messages to the client as server sent events
+// it is the fallback for networks which block websocket, client sends its payloads through [Hub.ServeSend]
+func (h *Hub) ServeSSE(w http.ResponseWriter, r *http.Request) {
+	username, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	version, declared, err := parseProtocolVersion(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.streamSSE(w, r, username, version, declared)
+}
+
+func (h *Hub) streamSSE(w http.ResponseWriter, r *http.Request, username string, version int, declared bool) {
+	resource := r.URL.Query().Get("resource")
+	if resource == "" {
+		resource = utils.RandomString()
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// disables response buffering of nginx
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.Header().Set("X-Doki-Resource", resource)
+	w.WriteHeader(http.StatusOK)
+	if err := http.NewResponseController(w).Flush(); err != nil {
+		return
+	}
+
+	user := utils.CreateUserFromUsernameAndResource(username, resource)
+	info := client.ConnectionInfo{
+		ConnectedAt: time.Now(),
+		RemoteAddr:  r.RemoteAddr,
+		UserAgent:   r.UserAgent(),
+	}
+	newClient := createSSEClient(h, user, info)
+
+	// writer is counted before client is visible to shutdown
+	h.writers.Add(1)
+	h.addClient(user, newClient)
+	defer h.removeClient(newClient)
+
+	// sending my initial online presence
+	h.sendPresence(true, username)
+
+	if declared {
+		h.Negotiate(user, version, parseCapabilities(r))
+	}
+
+	newClient.stream(r.Context(), w)
+}
@@ -0,0 +1,175 @@
+package hub
+
+import (
+	"bufio"
+	"context"
+	"doki.co.in/doki_real_time_service/config"
+	"doki.co.in/doki_real_time_service/payload"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// createHttpTransportServer serves http transports, username is taken from the query instead of the token
+func createHttpTransportServer() (*Hub, *httptest.Server) {
+	payload.InitPayload()
+	h := CreateHub(config.Default(), nil)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sse", func(w http.ResponseWriter, r *http.Request) {
+		version, declared, _ := parseProtocolVersion(r)
+		h.streamSSE(w, r, r.URL.Query().Get("user"), version, declared)
+	})
+	mux.HandleFunc("GET /poll", func(w http.ResponseWriter, r *http.Request) {
+		version, declared, _ := parseProtocolVersion(r)
+		h.longPoll(w, r, r.URL.Query().Get("user"), version, declared)
+	})
+	mux.HandleFunc("POST /send", func(w http.ResponseWriter, r *http.Request) {
+		h.send(w, r, r.URL.Query().Get("user"))
+	})
+
+	return h, httptest.NewServer(mux)
+}
+
+type sseEvent struct {
+	event string
+	data  string
+}
+
+func openSSEStream(t *testing.T, server *httptest.Server, query string) (*http.Response, *bufio.Reader) {
+	response, err := http.Get(server.URL + "/sse?" + query)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+	return response, bufio.NewReader(response.Body)
+}
+
+// readSSEEvent reads the next event skipping comments
+func readSSEEvent(t *testing.T, reader *bufio.Reader) sseEvent {
+	var event sseEvent
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "":
+			if event.data != "" {
+				return event
+			}
+		case strings.HasPrefix(line, "event: "):
+			event.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.data += strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func postPayload(t *testing.T, server *httptest.Server, query, body string) int {
+	response, err := http.Post(server.URL+"/send?"+query, "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	_ = response.Body.Close()
+
+	return response.StatusCode
+}
+
+func TestSSETransport(t *testing.T) {
+	h, server := createHttpTransportServer()
+	defer server.Close()
+
+	aliceResponse, _ := openSSEStream(t, server, "user=alice&resource=laptop")
+	defer aliceResponse.Body.Close()
+	bobResponse, bob := openSSEStream(t, server, "user=bob&resource=laptop&version=2")
+	defer bobResponse.Body.Close()
+
+	welcome := readSSEEvent(t, bob)
+	assert.Contains(t, welcome.data, `"type":"welcome"`)
+
+	assert.Eventually(t, func() bool {
+		return len(h.ConnectedResources("alice")) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	message := `{"type":"chat_message","from":"alice","to":"bob","id":"0193b0c4-7d4e-7c3a-9f1e-2a6b1c8d9e0f",` +
+		`"subject":"text","body":"hello over sse","sendAt":"2025-01-20T10:15:30.123Z"}`
+	assert.Equal(t, http.StatusAccepted, postPayload(t, server, "user=alice&resource=laptop", message))
+	assert.Equal(t, http.StatusBadRequest, postPayload(t, server, "user=alice&resource=laptop", `{"type":"unknown"}`))
+	assert.Equal(t, http.StatusNotFound, postPayload(t, server, "user=alice&resource=phone", message))
+	assert.Equal(t, http.StatusBadRequest, postPayload(t, server, "user=alice", message))
+
+	var received map[string]any
+	require.NoError(t, json.Unmarshal([]byte(readSSEEvent(t, bob).data), &received))
+	assert.Equal(t, "chat_message", received["type"])
+	assert.Equal(t, "hello over sse", received["body"])
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, h.Shutdown(ctx))
+
+	assert.Contains(t, readSSEEvent(t, bob).data, `"type":"server_shutdown"`)
+	closeEvent := readSSEEvent(t, bob)
+	assert.Equal(t, sseCloseEvent, closeEvent.event)
+
+	var frame map[string]any
+	require.NoError(t, json.Unmarshal([]byte(closeEvent.data), &frame))
+	assert.EqualValues(t, websocket.CloseServiceRestart, frame["code"])
+
+	assert.Eventually(t, func() bool {
+		return len(h.ConnectedResources("bob")) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestSendDuringConnectAndDisconnect(t *testing.T) {
+	h, server := createHttpTransportServer()
+	defer server.Close()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			carol := &fakeClient{user: "carol@phone"}
+			h.addClient(carol.user, carol)
+			h.removeClient(carol)
+		}
+	}()
+
+	var senders sync.WaitGroup
+	for range 4 {
+		senders.Add(1)
+		go func() {
+			defer senders.Done()
+			for range 2000 {
+				// unknown payload is rejected after the lookup, both statuses mean the lookup has returned
+				recorder := httptest.NewRecorder()
+				request := httptest.NewRequest(http.MethodPost, "/send?resource=phone", strings.NewReader(`{"type":"unknown"}`))
+				h.send(recorder, request, "carol")
+				assert.Contains(t, []int{http.StatusBadRequest, http.StatusNotFound}, recorder.Code)
+			}
+		}()
+	}
+
+	sent := make(chan struct{})
+	go func() {
+		senders.Wait()
+		close(sent)
+	}()
+
+	select {
+	case <-sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("send did not finish while clients were connecting and disconnecting")
+	}
+}
@@ -1,6 +1,9 @@
 package hub
 
-import "sync"
+import (
+	"maps"
+	"sync"
+)
 
 type subscribers map[string]bool
 
@@ -21,13 +24,13 @@ type subscription struct {
 // userPresence determines if nodeIdentifier is username or not
 func (h *Hub) Subscribe(nodeIdentifier, subscriber string, userPresence bool) {
 	h.subscription.Lock()
-	defer h.subscription.Unlock()
-
 	if h.subscription.subscriptions[nodeIdentifier] == nil {
 		h.subscription.subscriptions[nodeIdentifier] = make(subscribers)
 	}
-
 	h.subscription.subscriptions[nodeIdentifier][subscriber] = true
+	h.subscription.Unlock()
+
+	// clients are looked up without holding the subscription lock
 	if userPresence {
 		h.sendInitialPresence(nodeIdentifier, subscriber)
 	}
@@ -54,6 +57,10 @@ func (h *Hub) Unsubscribe(nodeIdentifier, subscriber string) {
 	}
 }
 
+// GetSubscribers returns snapshot of the complete users subscribed to the node
 func (h *Hub) GetSubscribers(nodeIdentifier string) map[string]bool {
-	return h.subscription.subscriptions[nodeIdentifier]
+	h.subscription.RLock()
+	defer h.subscription.RUnlock()
+
+	return maps.Clone(h.subscription.subscriptions[nodeIdentifier])
 }
\ No newline at end of file
@@ -0,0 +1,79 @@
+package hub
+
+import (
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/payload"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unreadFrames returns unread frames written to the client
+func unreadFrames(t *testing.T, c *fakeClient) []map[string]any {
+	var frames []map[string]any
+	for _, data := range c.written {
+		var frame map[string]any
+		require.NoError(t, json.Unmarshal(data, &frame))
+		if frame["type"] == "unread_update" || frame["type"] == "unread_counts" {
+			frames = append(frames, frame)
+		}
+	}
+
+	return frames
+}
+
+func TestUnreadCounts(t *testing.T) {
+	h, clients := createRoutingHub("alice@phone", "bob@phone", "bob@web", "bob@legacy", "carol@phone")
+	for _, user := range []string{"alice@phone", "bob@phone", "bob@web", "carol@phone"} {
+		clients[user].capabilities = []string{payload.CapabilityUnread}
+	}
+
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "one"), "alice", "phone", codec.Json))
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "two"), "alice", "phone", codec.Json))
+	require.NoError(t, h.receive(chatFrom("carol", "bob", "three"), "carol", "phone", codec.Json))
+
+	// every resource of the recipient gets the same counts, sender doesn't get any
+	for _, resource := range []string{"bob@phone", "bob@web"} {
+		frames := unreadFrames(t, clients[resource])
+		require.Len(t, frames, 3, resource)
+		assert.Equal(t, map[string]any{"type": "unread_update", "to": "bob", "conversation": "carol", "unread": float64(1), "total": float64(3)}, frames[2])
+	}
+	assert.Empty(t, unreadFrames(t, clients["alice@phone"]))
+	assert.Empty(t, unreadFrames(t, clients["bob@legacy"]))
+	assert.Equal(t, map[string]int{"alice": 2, "carol": 1}, h.Unread().Unread("bob"))
+
+	// read on web resets the badge on phone too and tells alice which message was read
+	receipt := []byte(`{"type":"read_receipt","from":"bob","to":"alice","messageId":"1","readAt":"2025-01-20T10:16:30Z"}`)
+	require.NoError(t, h.receive(receipt, "bob", "web", codec.Json))
+	assert.Equal(t, "read_receipt", lastFrame(t, clients["alice@phone"])["type"])
+	assert.Equal(t, "read_receipt", lastFrame(t, clients["bob@phone"])["type"])
+	for _, resource := range []string{"bob@phone", "bob@web"} {
+		frames := unreadFrames(t, clients[resource])
+		require.Len(t, frames, 4, resource)
+		assert.Equal(t, map[string]any{"type": "unread_update", "to": "bob", "conversation": "alice", "unread": float64(0), "total": float64(1)}, frames[3])
+	}
+
+	// conversation already read doesn't send another update
+	require.NoError(t, h.receive(receipt, "bob", "web", codec.Json))
+	assert.Len(t, unreadFrames(t, clients["bob@web"]), 4)
+
+	require.NoError(t, h.receive([]byte(`{"type":"unread_sync","from":"bob"}`), "bob", "phone", codec.Json))
+	assert.Equal(t, map[string]any{"type": "unread_counts", "to": "bob", "conversations": map[string]any{"carol": float64(1)}, "total": float64(1)}, lastFrame(t, clients["bob@phone"]))
+	assert.Len(t, unreadFrames(t, clients["bob@web"]), 4)
+}
+
+func TestUnreadCountsOfBlockedSender(t *testing.T) {
+	h, clients := createRoutingHub("alice@phone", "bob@phone")
+	clients["bob@phone"].capabilities = []string{payload.CapabilityUnread}
+	require.NoError(t, h.BlockStore().Block("bob", "alice"))
+
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "hi"), "alice", "phone", codec.Json))
+	assert.Empty(t, clients["bob@phone"].written)
+	assert.Empty(t, h.Unread().Unread("bob"))
+
+	// offline users still get counts for their next connection
+	require.NoError(t, h.receive(chatFrom("alice", "dave", "hi"), "alice", "phone", codec.Json))
+	assert.Equal(t, map[string]int{"alice": 1}, h.Unread().Unread("dave"))
+}
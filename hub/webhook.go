@@ -0,0 +1,18 @@
+package hub
+
+import (
+	"doki.co.in/doki_real_time_service/webhook"
+)
+
+// SetWebhooks sends payloads received from clients to the webhook endpoints
+// it must be called before serving clients
+func (h *Hub) SetWebhooks(dispatcher *webhook.Dispatcher) {
+	h.webhooks = dispatcher
+}
+
+// NotifyWebhooks queues the json payload for webhook endpoints of its type without waiting for them
+func (h *Hub) NotifyWebhooks(data []byte) {
+	if h.webhooks != nil {
+		h.webhooks.Dispatch(data)
+	}
+}
@@ -0,0 +1,113 @@
+package hub
+
+import (
+	"context"
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/config"
+	"doki.co.in/doki_real_time_service/relation"
+	"doki.co.in/doki_real_time_service/webhook"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webhookReceiver sends webhooks of the types to a local server, bodies returns what it got once the queue is drained
+func webhookReceiver(t *testing.T, h *Hub, types ...string) (bodies func() []map[string]any) {
+	var lock sync.Mutex
+	var received []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var frame map[string]any
+		assert.NoError(t, json.Unmarshal(body, &frame))
+
+		lock.Lock()
+		defer lock.Unlock()
+		received = append(received, frame)
+	}))
+	t.Cleanup(server.Close)
+
+	webhookConfig := config.Default().Webhooks
+	webhookConfig.Secret = "secret"
+	webhookConfig.Endpoints = []config.WebhookEndpoint{{Url: server.URL, Types: types}}
+	dispatcher, err := webhook.NewDispatcher(webhookConfig)
+	require.NoError(t, err)
+	h.SetWebhooks(dispatcher)
+
+	return func() []map[string]any {
+		require.NoError(t, dispatcher.Close(context.Background()))
+
+		lock.Lock()
+		defer lock.Unlock()
+		return received
+	}
+}
+
+// webhookMembers returns the member of every webhook body
+func webhookMembers(bodies []map[string]any, name string) []any {
+	members := make([]any, 0, len(bodies))
+	for _, body := range bodies {
+		members = append(members, body[name])
+	}
+
+	return members
+}
+
+func TestWebhooksOfReceivedPayloads(t *testing.T) {
+	h, clients := createRoutingHub("alice@phone", "bob@phone")
+	bodies := webhookReceiver(t, h, "chat_message", "user_node_like_action")
+
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "hi"), "alice", "phone", codec.Json))
+	require.NoError(t, h.receive([]byte(`{"type":"typing_status","from":"alice","to":"bob"}`), "alice", "phone", codec.Json))
+	batch := `{"type":"batch","from":"alice","payloads":[` + string(chatFrom("alice", "bob", "again")) + `]}`
+	require.NoError(t, h.receive([]byte(batch), "alice", "phone", codec.Json))
+
+	assert.Equal(t, []any{"chat_message", "chat_message"}, webhookMembers(bodies(), "type"))
+	assert.Equal(t, "again", lastFrame(t, clients["bob@phone"])["body"])
+}
+
+func TestWebhooksOfUndeliveredPayloads(t *testing.T) {
+	h, clients := createModerationHub(t, "alice@phone", "bob@phone", "carol@phone", "dave@phone")
+	bodies := webhookReceiver(t, h, "chat_message")
+	require.NoError(t, h.BlockStore().Block("carol", "alice"))
+	h.SetMessagePolicy(relation.PolicyFunc(func(sender, recipient string) relation.Decision {
+		if recipient == "dave" {
+			return relation.Reject
+		}
+		return relation.Allow
+	}))
+
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "spam"), "alice", "phone", codec.Json))
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "review"), "alice", "phone", codec.Json))
+	require.NoError(t, h.receive(chatFrom("alice", "carol", "blocked"), "alice", "phone", codec.Json))
+	require.NoError(t, h.receive(chatFrom("alice", "dave", "rejected"), "alice", "phone", codec.Json))
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "darn"), "alice", "phone", codec.Json))
+	assert.Empty(t, clients["carol@phone"].written)
+	assert.Empty(t, clients["dave@phone"].written)
+
+	// held message goes to webhooks once, when it is released
+	held := h.review.List()
+	require.Len(t, held, 1)
+	rec := adminRequest(h, http.MethodPost, "/admin/moderation/held/"+held[0].Id+"/release", testAdminKey)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// webhooks get the redacted body that was delivered
+	assert.ElementsMatch(t, []any{"****", "review"}, webhookMembers(bodies(), "body"))
+}
+
+func TestWebhooksOfAcceptedPayloadsWithoutReceivers(t *testing.T) {
+	h, clients := createRoutingHub("alice@phone")
+	bodies := webhookReceiver(t, h, "chat_message", "delete_message")
+
+	// offline recipient and delete for the sender alone reach no connection, backend still gets them
+	require.NoError(t, h.receive(chatFrom("alice", "bob", "offline"), "alice", "phone", codec.Json))
+	require.NoError(t, h.receive([]byte(`{"type":"delete_message","from":"alice","to":"bob","id":["1"],"everyone":false}`), "alice", "phone", codec.Json))
+	assert.Empty(t, clients["alice@phone"].written)
+
+	assert.Equal(t, []any{"chat_message", "delete_message"}, webhookMembers(bodies(), "type"))
+}
@@ -1,14 +1,28 @@
 package main
 
 import (
+	"context"
+	"doki.co.in/doki_real_time_service/block"
+	"doki.co.in/doki_real_time_service/config"
 	"doki.co.in/doki_real_time_service/hub"
+	"doki.co.in/doki_real_time_service/moderation"
 	"doki.co.in/doki_real_time_service/payload"
+	"doki.co.in/doki_real_time_service/push"
+	"doki.co.in/doki_real_time_service/relation"
+	"doki.co.in/doki_real_time_service/webhook"
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/MicahParks/keyfunc/v3"
 	"github.com/joho/godotenv"
+	"google.golang.org/grpc"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
@@ -17,15 +31,15 @@ func main() {
 		//log.Printf("error loading env file: %v\n", err)
 	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	appConfig, err := config.Load(os.Args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.Fatalf("Failed to load config.\nError: %s", err)
 	}
-	userPoolID := os.Getenv("USER_POOL_ID")
-	region := os.Getenv("REGION")
-	jwksURL := fmt.Sprintf("https://cognito-idp.%s.amazonaws.com/%s/.well-known/jwks.json", region, userPoolID)
 
-	jwks, err := keyfunc.NewDefault([]string{jwksURL})
+	jwks, err := keyfunc.NewDefault([]string{appConfig.GetJwksURL()})
 	if err != nil {
 		log.Fatalf("Failed to create JWK Set from resource at the given URL.\nError: %s", err)
 	}
@@ -33,7 +47,130 @@ func main() {
 	fmt.Println("Doki real time service")
 	// init payloads that can be received
 	payload.InitPayload()
-	newHub := hub.CreateHub(&jwks)
-	http.HandleFunc("/ws", newHub.ServeWS)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
-}
\ No newline at end of file
+	for _, configured := range appConfig.Payloads {
+		rule := payload.RoutingRule{
+			Users:             configured.Users,
+			Nodes:             configured.Nodes,
+			When:              configured.When,
+			EchoToSender:      configured.EchoToSender,
+			DropSelfAddressed: configured.DropSelfAddressed,
+		}
+		if err := payload.RegisterRouted(configured.Type, rule, configured.RateLimitClass); err != nil {
+			log.Fatalf("Failed to register payload from config.\nError: %s", err)
+		}
+	}
+	newHub := hub.CreateHub(appConfig, &jwks)
+	notifiers, err := push.NewNotifiers(appConfig.Push)
+	if err != nil {
+		log.Fatalf("Failed to create push notifiers.\nError: %s", err)
+	}
+	templates, err := push.NewTemplates(appConfig.Push.Templates)
+	if err != nil {
+		log.Fatalf("Failed to parse push templates.\nError: %s", err)
+	}
+	newHub.SetPushNotifiers(notifiers, templates)
+
+	var webhooks *webhook.Dispatcher
+	if len(appConfig.Webhooks.Endpoints) > 0 {
+		webhooks, err = webhook.NewDispatcher(appConfig.Webhooks)
+		if err != nil {
+			log.Fatalf("Failed to create webhooks.\nError: %s", err)
+		}
+		newHub.SetWebhooks(webhooks)
+	}
+
+	pipeline, err := moderation.NewPipeline(appConfig.Moderation)
+	if err != nil {
+		log.Fatalf("Failed to create moderation pipeline.\nError: %s", err)
+	}
+	if len(pipeline) > 0 {
+		moderationConfig := appConfig.Moderation
+		strikes := moderation.NewStrikes(moderationConfig.StrikeLimit, time.Duration(moderationConfig.StrikeWindow), time.Duration(moderationConfig.MuteDuration))
+		newHub.SetModeration(pipeline, strikes)
+	}
+
+	if appConfig.BlockListFile != "" {
+		blocks, err := block.OpenFileStore(appConfig.BlockListFile)
+		if err != nil {
+			log.Fatalf("Failed to open block list.\nError: %s", err)
+		}
+		newHub.SetBlockStore(blocks)
+	}
+
+	if appConfig.RelationsFile != "" {
+		relations, err := relation.OpenFileStore(appConfig.RelationsFile)
+		if err != nil {
+			log.Fatalf("Failed to open relations.\nError: %s", err)
+		}
+		newHub.SetRelationStore(relations)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", newHub.ServeWS)
+	mux.HandleFunc("GET /sse", newHub.ServeSSE)
+	mux.HandleFunc("GET /poll", newHub.ServePoll)
+	mux.HandleFunc("POST /send", newHub.ServeSend)
+	mux.HandleFunc("/healthz", newHub.ServeHealth)
+	mux.HandleFunc("/readyz", newHub.ServeReady)
+
+	servers := []*http.Server{{Addr: ":" + appConfig.Port, Handler: mux}}
+	if appConfig.AdminPort == "" {
+		mux.Handle("/admin/", newHub.AdminHandler())
+	} else {
+		servers = append(servers, &http.Server{Addr: ":" + appConfig.AdminPort, Handler: newHub.AdminHandler()})
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	for _, server := range servers {
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatal(err)
+			}
+		}()
+	}
+
+	var grpcServer *grpc.Server
+	if appConfig.GrpcPort != "" {
+		listener, err := net.Listen("tcp", ":"+appConfig.GrpcPort)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		grpcServer = grpc.NewServer()
+		newHub.RegisterGrpc(grpcServer)
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Fatal(err)
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	log.Println("shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(appConfig.ShutdownTimeout))
+	defer cancel()
+
+	// hub first so that upgrades are rejected and clients are told to reconnect
+	// http server does not track hijacked websocket connections
+	if err := newHub.Shutdown(shutdownCtx); err != nil {
+		log.Printf("error draining clients: %v\n", err)
+	}
+	for _, server := range servers {
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("error shutting down server: %v\n", err)
+		}
+	}
+	if grpcServer != nil {
+		// streams are already closed by hub, this stops the listener
+		grpcServer.Stop()
+	}
+	if webhooks != nil {
+		// deliveries still waiting when timeout is over are written to dead letter file
+		if err := webhooks.Close(shutdownCtx); err != nil {
+			log.Printf("error sending queued webhooks: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// httpClassifier asks an http endpoint for the verdict
+type httpClassifier struct {
+	client *http.Client
+	url    string
+}
+
+// NewHttpClassifier returns moderator which posts the message as json to the url
+// and expects a verdict like {"action":"redact","text":"...","reason":"..."} in response
+func NewHttpClassifier(client *http.Client, url string) Moderator {
+	return &httpClassifier{client: client, url: url}
+}
+
+func (c *httpClassifier) Moderate(ctx context.Context, message Message) (Verdict, error) {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return Verdict{}, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return Verdict{}, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("error calling moderation classifier: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return Verdict{}, fmt.Errorf("moderation classifier failed with status %d", response.StatusCode)
+	}
+
+	var verdict Verdict
+	if err := json.NewDecoder(io.LimitReader(response.Body, 1<<20)).Decode(&verdict); err != nil {
+		return Verdict{}, fmt.Errorf("error parsing moderation classifier verdict: %w", err)
+	}
+
+	switch verdict.Action {
+	case Allow:
+		verdict.Text = message.Text
+	case Redact, Hold, Reject:
+	default:
+		return Verdict{}, fmt.Errorf("moderation classifier returned unknown action %q", verdict.Action)
+	}
+
+	return verdict, nil
+}
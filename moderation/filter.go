@@ -0,0 +1,130 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// linkReplacement replaces redacted links
+const linkReplacement = "[link removed]"
+
+// wordFilter matches blocked words and regular expressions
+type wordFilter struct {
+	patterns []*regexp.Regexp
+	action   Action
+}
+
+// NewWordFilter returns moderator taking the action when text has any of the words or matches any of the patterns
+// words are matched as whole words ignoring case, redaction replaces every match with asterisks
+func NewWordFilter(words, patterns []string, action Action) (Moderator, error) {
+	filter := &wordFilter{action: action}
+
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	if len(quoted) > 0 {
+		filter.patterns = append(filter.patterns, regexp.MustCompile(`(?i)\b(?:`+strings.Join(quoted, "|")+`)\b`))
+	}
+
+	for _, pattern := range patterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing moderation pattern %q: %w", pattern, err)
+		}
+		filter.patterns = append(filter.patterns, compiled)
+	}
+
+	return filter, nil
+}
+
+func (f *wordFilter) Moderate(_ context.Context, message Message) (Verdict, error) {
+	text := message.Text
+	matched := false
+	for _, pattern := range f.patterns {
+		if !pattern.MatchString(text) {
+			continue
+		}
+
+		matched = true
+		if f.action != Redact {
+			break
+		}
+		text = pattern.ReplaceAllStringFunc(text, func(match string) string {
+			return strings.Repeat("*", utf8.RuneCountInString(match))
+		})
+	}
+
+	if !matched {
+		return Verdict{Action: Allow, Text: message.Text}, nil
+	}
+
+	return Verdict{Action: f.action, Text: text, Reason: "Message has blocked words."}, nil
+}
+
+// linkPattern finds links with a scheme, starting with www. or a domain followed by a path
+// punctuation at the end belongs to the sentence, not to the link
+var linkPattern = regexp.MustCompile(`(?i)\b(?:[a-z][a-z0-9+.-]*://|www\.|(?:[a-z0-9-]+\.)+[a-z]{2,}/)(?:[^\s<>"]*[^\s<>".,;:!?')\]])?`)
+
+// linkFilter matches links other than to allowed domains
+type linkFilter struct {
+	allowedDomains []string
+	action         Action
+}
+
+// NewLinkFilter returns moderator taking the action when text has links to domains other than
+// allowedDomains and their subdomains, redaction replaces every such link
+func NewLinkFilter(allowedDomains []string, action Action) Moderator {
+	filter := &linkFilter{action: action}
+	for _, domain := range allowedDomains {
+		if domain = strings.ToLower(strings.TrimSpace(domain)); domain != "" {
+			filter.allowedDomains = append(filter.allowedDomains, domain)
+		}
+	}
+
+	return filter
+}
+
+func (f *linkFilter) Moderate(_ context.Context, message Message) (Verdict, error) {
+	matched := false
+	text := linkPattern.ReplaceAllStringFunc(message.Text, func(link string) string {
+		if f.allowed(link) {
+			return link
+		}
+
+		matched = true
+		return linkReplacement
+	})
+
+	if !matched {
+		return Verdict{Action: Allow, Text: message.Text}, nil
+	}
+
+	return Verdict{Action: f.action, Text: text, Reason: "Message has links which are not allowed."}, nil
+}
+
+// allowed returns whether host of the link is an allowed domain or its subdomain
+func (f *linkFilter) allowed(link string) bool {
+	if !strings.Contains(link, "://") {
+		link = "http://" + link
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	for _, domain := range f.allowedDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,110 @@
+package moderation
+
+import (
+	"context"
+	"doki.co.in/doki_real_time_service/config"
+	"errors"
+	"net/http"
+)
+
+// Action is what happens to a message after moderation
+type Action string
+
+const (
+	// Allow sends the message as it is
+	Allow Action = "allow"
+
+	// Redact sends the message with text of the verdict
+	Redact Action = "redact"
+
+	// Hold keeps the message for review, it is sent only if it is released
+	Hold Action = "hold"
+
+	// Reject drops the message and sender gets an error
+	Reject Action = "reject"
+)
+
+// Message is the text of a payload checked before the payload is routed
+type Message struct {
+	Type string `json:"type"`
+	From string `json:"from"`
+	To   string `json:"to"`
+	Id   string `json:"id"`
+	Text string `json:"text"`
+}
+
+// Verdict is the decision of a moderator, Text is the redacted text when Action is Redact
+type Verdict struct {
+	Action Action `json:"action"`
+	Text   string `json:"text,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Moderator decides what happens to a message
+type Moderator interface {
+	Moderate(ctx context.Context, message Message) (Verdict, error)
+}
+
+// ModeratorFunc is a function used as Moderator
+type ModeratorFunc func(ctx context.Context, message Message) (Verdict, error)
+
+func (f ModeratorFunc) Moderate(ctx context.Context, message Message) (Verdict, error) {
+	return f(ctx, message)
+}
+
+// Pipeline runs moderators in order, redacted text is given to the next moderator
+// first hold or reject stops the pipeline, moderator which fails is skipped so that messages
+// are not lost when a classifier is down
+type Pipeline []Moderator
+
+func (p Pipeline) Moderate(ctx context.Context, message Message) (Verdict, error) {
+	verdict := Verdict{Action: Allow, Text: message.Text}
+
+	var errs []error
+	for _, moderator := range p {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+
+		next, err := moderator.Moderate(ctx, message)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		switch next.Action {
+		case Redact:
+			message.Text = next.Text
+			verdict = next
+		case Hold, Reject:
+			return next, errors.Join(errs...)
+		}
+	}
+
+	return verdict, errors.Join(errs...)
+}
+
+// NewPipeline returns the filters and classifier enabled in config, in that order
+func NewPipeline(moderationConfig config.ModerationConfig) (Pipeline, error) {
+	var pipeline Pipeline
+
+	if len(moderationConfig.Words) > 0 || len(moderationConfig.Patterns) > 0 {
+		filter, err := NewWordFilter(moderationConfig.Words, moderationConfig.Patterns, Action(moderationConfig.WordAction))
+		if err != nil {
+			return nil, err
+		}
+		pipeline = append(pipeline, filter)
+	}
+
+	if moderationConfig.LinkAction != "" {
+		pipeline = append(pipeline, NewLinkFilter(moderationConfig.AllowedDomains, Action(moderationConfig.LinkAction)))
+	}
+
+	if moderationConfig.ClassifierUrl != "" {
+		// pipeline timeout is applied by the caller through ctx
+		pipeline = append(pipeline, NewHttpClassifier(&http.Client{}, moderationConfig.ClassifierUrl))
+	}
+
+	return pipeline, nil
+}
@@ -0,0 +1,139 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func moderate(t *testing.T, moderator Moderator, text string) Verdict {
+	verdict, err := moderator.Moderate(context.Background(), Message{Type: "chat_message", From: "alice", To: "bob", Id: "1", Text: text})
+	require.NoError(t, err)
+	return verdict
+}
+
+func TestWordFilter(t *testing.T) {
+	filter, err := NewWordFilter([]string{"darn", "heck"}, []string{`\d{4}-\d{4}-\d{4}-\d{4}`}, Redact)
+	require.NoError(t, err)
+
+	assert.Equal(t, Verdict{Action: Allow, Text: "darnation is fine"}, moderate(t, filter, "darnation is fine"))
+
+	verdict := moderate(t, filter, "Darn it, card 1234-5678-9012-3456 HECK")
+	assert.Equal(t, Redact, verdict.Action)
+	assert.Equal(t, "**** it, card ******************* ****", verdict.Text)
+
+	filter, err = NewWordFilter([]string{"darn"}, nil, Reject)
+	require.NoError(t, err)
+	assert.Equal(t, Reject, moderate(t, filter, "darn").Action)
+
+	_, err = NewWordFilter(nil, []string{"("}, Reject)
+	assert.Error(t, err)
+}
+
+func TestLinkFilter(t *testing.T) {
+	filter := NewLinkFilter([]string{"doki.co.in"}, Redact)
+
+	for _, text := range []string{"no links here", "see https://doki.co.in/post/1", "www.app.doki.co.in", "version 1.2 is out"} {
+		assert.Equal(t, Allow, moderate(t, filter, text).Action, text)
+	}
+
+	verdict := moderate(t, filter, "buy at http://spam.example/x or www.spam.example and evil.com/free, not doki.co.in/ok")
+	assert.Equal(t, Redact, verdict.Action)
+	assert.Equal(t, "buy at [link removed] or [link removed] and [link removed], not doki.co.in/ok", verdict.Text)
+
+	// lookalike domain is not a subdomain
+	assert.Equal(t, Hold, moderate(t, NewLinkFilter([]string{"doki.co.in"}, Hold), "https://notdoki.co.in").Action)
+}
+
+func TestHttpClassifier(t *testing.T) {
+	var received Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		switch received.Text {
+		case "bad":
+			_, _ = w.Write([]byte(`{"action":"reject","reason":"Message is abusive."}`))
+		case "broken":
+			_, _ = w.Write([]byte(`{"action":"explode"}`))
+		case "down":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			_, _ = w.Write([]byte(`{"action":"allow"}`))
+		}
+	}))
+	defer server.Close()
+
+	classifier := NewHttpClassifier(server.Client(), server.URL)
+
+	assert.Equal(t, Verdict{Action: Allow, Text: "hello"}, moderate(t, classifier, "hello"))
+	assert.Equal(t, Message{Type: "chat_message", From: "alice", To: "bob", Id: "1", Text: "hello"}, received)
+	assert.Equal(t, Verdict{Action: Reject, Reason: "Message is abusive."}, moderate(t, classifier, "bad"))
+
+	for _, text := range []string{"broken", "down"} {
+		_, err := classifier.Moderate(context.Background(), Message{Text: text})
+		assert.Error(t, err, text)
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	words, err := NewWordFilter([]string{"darn"}, nil, Redact)
+	require.NoError(t, err)
+	failing := ModeratorFunc(func(context.Context, Message) (Verdict, error) {
+		return Verdict{}, errors.New("classifier is down")
+	})
+	var checked string
+	holdLinks := ModeratorFunc(func(_ context.Context, message Message) (Verdict, error) {
+		checked = message.Text
+		return Verdict{Action: Hold, Reason: "Message has a link."}, nil
+	})
+
+	// failing moderator is skipped and redacted text is given to the next one
+	verdict, err := Pipeline{words, failing}.Moderate(context.Background(), Message{Text: "darn"})
+	assert.Error(t, err)
+	assert.Equal(t, Verdict{Action: Redact, Text: "****", Reason: "Message has blocked words."}, verdict)
+
+	verdict, _ = Pipeline{words, holdLinks, failing}.Moderate(context.Background(), Message{Text: "darn"})
+	assert.Equal(t, Hold, verdict.Action)
+	assert.Equal(t, "****", checked)
+
+	verdict, err = Pipeline{}.Moderate(context.Background(), Message{Text: "hi"})
+	assert.NoError(t, err)
+	assert.Equal(t, Verdict{Action: Allow, Text: "hi"}, verdict)
+}
+
+func TestStrikes(t *testing.T) {
+	now := time.Date(2025, 1, 20, 10, 0, 0, 0, time.UTC)
+	strikes := NewStrikes(3, time.Hour, 15*time.Minute)
+	strikes.now = func() time.Time { return now }
+
+	_, muted := strikes.Add("alice")
+	assert.False(t, muted)
+
+	// strikes older than the window don't count
+	now = now.Add(2 * time.Hour)
+	strikes.Add("alice")
+	_, muted = strikes.Add("alice")
+	assert.False(t, muted)
+
+	until, muted := strikes.Add("alice")
+	assert.True(t, muted)
+	assert.Equal(t, now.Add(15*time.Minute), until)
+
+	_, muted = strikes.MutedUntil("alice")
+	assert.True(t, muted)
+	_, muted = strikes.MutedUntil("bob")
+	assert.False(t, muted)
+
+	now = now.Add(15 * time.Minute)
+	_, muted = strikes.MutedUntil("alice")
+	assert.False(t, muted)
+
+	_, muted = NewStrikes(0, time.Hour, time.Minute).Add("alice")
+	assert.False(t, muted)
+}
@@ -0,0 +1,71 @@
+package moderation
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Held is a message waiting for review with the payload sent once it is released
+type Held struct {
+	Id       string          `json:"id"`
+	Message  Message         `json:"message"`
+	Reason   string          `json:"reason,omitempty"`
+	Resource string          `json:"resource"`
+	HeldAt   time.Time       `json:"heldAt"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+// ReviewQueue keeps held messages in memory, they are lost on restart
+type ReviewQueue struct {
+	sync.Mutex
+	size int
+	held map[string]Held
+}
+
+// NewReviewQueue returns queue holding up to size messages
+func NewReviewQueue(size int) *ReviewQueue {
+	return &ReviewQueue{
+		size: size,
+		held: make(map[string]Held),
+	}
+}
+
+// Hold adds the message, it returns false when the queue is full
+func (q *ReviewQueue) Hold(held Held) bool {
+	q.Lock()
+	defer q.Unlock()
+
+	if len(q.held) >= q.size {
+		return false
+	}
+	q.held[held.Id] = held
+	return true
+}
+
+// List returns held messages, oldest first
+func (q *ReviewQueue) List() []Held {
+	q.Lock()
+	defer q.Unlock()
+
+	list := make([]Held, 0, len(q.held))
+	for _, held := range q.held {
+		list = append(list, held)
+	}
+	slices.SortFunc(list, func(a, b Held) int {
+		return a.HeldAt.Compare(b.HeldAt)
+	})
+
+	return list
+}
+
+// Take removes the message from the queue so that it can be released or discarded
+func (q *ReviewQueue) Take(id string) (Held, bool) {
+	q.Lock()
+	defer q.Unlock()
+
+	held, ok := q.held[id]
+	delete(q.held, id)
+	return held, ok
+}
@@ -0,0 +1,91 @@
+package moderation
+
+import (
+	"sync"
+	"time"
+)
+
+// Strikes counts moderated messages of every user and mutes the user who gets too many
+type Strikes struct {
+	sync.Mutex
+	limit   int
+	window  time.Duration
+	muteFor time.Duration
+
+	// strikes has time of every strike within the window by user
+	strikes map[string][]time.Time
+
+	// muted has time until which user can't send messages
+	muted map[string]time.Time
+
+	now func() time.Time
+}
+
+// NewStrikes returns strikes which mute the user for muteFor after limit strikes within window
+// zero limit never mutes anyone
+func NewStrikes(limit int, window, muteFor time.Duration) *Strikes {
+	return &Strikes{
+		limit:   limit,
+		window:  window,
+		muteFor: muteFor,
+		strikes: make(map[string][]time.Time),
+		muted:   make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// Add counts a strike, it returns time until which user is muted when the strike reached the limit
+func (s *Strikes) Add(user string) (time.Time, bool) {
+	if s.limit <= 0 {
+		return time.Time{}, false
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	now := s.now()
+	recent := s.strikes[user][:0]
+	for _, strike := range s.strikes[user] {
+		if now.Sub(strike) < s.window {
+			recent = append(recent, strike)
+		}
+	}
+	recent = append(recent, now)
+
+	if len(recent) < s.limit {
+		s.strikes[user] = recent
+		return time.Time{}, false
+	}
+
+	// strikes start again once the mute is over
+	delete(s.strikes, user)
+	until := now.Add(s.muteFor)
+	s.muted[user] = until
+	return until, true
+}
+
+// MutedUntil returns time until which user can't send messages if the user is muted
+func (s *Strikes) MutedUntil(user string) (time.Time, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	until, ok := s.muted[user]
+	if !ok {
+		return time.Time{}, false
+	}
+	if !s.now().Before(until) {
+		delete(s.muted, user)
+		return time.Time{}, false
+	}
+
+	return until, true
+}
+
+// Unmute lifts the mute before it is over
+func (s *Strikes) Unmute(user string) {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.muted, user)
+	delete(s.strikes, user)
+}
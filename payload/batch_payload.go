@@ -0,0 +1,142 @@
+package payload
+
+import (
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/utils"
+	"encoding/json"
+)
+
+const (
+	batchType       = payloadType("batch")
+	batchResultType = payloadType("batch_result")
+)
+
+// batch carries up to 100 payloads in a single frame
+// every payload is validated and sent on its own, sender gets batch_result with result of each one
+type batch struct {
+	Type     payloadType       `json:"type" validate:"required"`
+	From     string            `json:"from" validate:"required"`
+	Id       string            `json:"id"`
+	Payloads []json.RawMessage `json:"payloads" validate:"required,min=1,max=100"`
+}
+
+func (payload *batch) SendPayload(data *[]byte, h Hub, senderResource string) {
+	// every payload gets metadata of the batch with its own event id
+	metadata := readMetadata(*data)
+
+	results := make([]batchItemResult, 0, len(payload.Payloads))
+	for index, item := range payload.Payloads {
+		data := []byte(item)
+		result := batchItemResult{
+			Index: index,
+			Id:    batchItemId(data),
+		}
+
+		itemPayload, err := createBatchItem(&data, payload.From)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			if metadata != nil {
+				itemMetadata := *metadata
+				itemMetadata.EventId = utils.NewEventId()
+				data = StampMetadata(data, itemMetadata)
+			}
+
+			itemPayload.SendPayload(&data, h, senderResource)
+			result.Ok = true
+		}
+
+		results = append(results, result)
+	}
+
+	completeUser := utils.CreateUserFromUsernameAndResource(payload.From, senderResource)
+	conn := h.GetIndividualClient(completeUser)
+	if conn == nil {
+		return
+	}
+
+	resultData := utils.PayloadToJson(&batchResult{
+		Type:    batchResultType,
+		To:      payload.From,
+		Id:      payload.Id,
+		Results: results,
+	})
+	if resultData != nil {
+		conn.WriteToChannel(resultData)
+	}
+}
+
+// createBatchItem creates payload of a single batch item, batch items are always json
+func createBatchItem(data *[]byte, from string) (Payload, error) {
+	var base struct {
+		Type payloadType `json:"type"`
+	}
+	if err := json.Unmarshal(*data, &base); err == nil && base.Type == batchType {
+		return nil, &InvalidPayload{
+			reason: "Batch can't contain another batch.",
+		}
+	}
+
+	return CreatePayload(data, from, codec.Json)
+}
+
+// batchItemId returns id of the item if it has one so that client can match the result without index
+func batchItemId(data []byte) string {
+	var base struct {
+		Id string `json:"id"`
+	}
+	_ = json.Unmarshal(data, &base)
+
+	return base.Id
+}
+
+type batchItemResult struct {
+	Index int    `json:"index"`
+	Id    string `json:"id,omitempty"`
+	Ok    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// only server sends this
+// batchResult is the reply to batch sent by client, results are in the order of batch payloads
+type batchResult struct {
+	Type    payloadType       `json:"type"`
+	To      string            `json:"to"`
+	Id      string            `json:"id,omitempty"`
+	Results []batchItemResult `json:"results"`
+}
+
+func (payload *batchResult) SendPayload(data *[]byte, h Hub, userResource string) {
+	completeUser := utils.CreateUserFromUsernameAndResource(payload.To, userResource)
+
+	conn := h.GetIndividualClient(completeUser)
+	if conn != nil {
+		conn.WriteToChannel(data)
+	}
+}
+
+// only server sends this
+// outgoingBatch is the batch of queued payloads sent to the client in a single frame
+type outgoingBatch struct {
+	Type     payloadType       `json:"type"`
+	To       string            `json:"to"`
+	Payloads []json.RawMessage `json:"payloads"`
+}
+
+func (payload *outgoingBatch) SendPayload(data *[]byte, h Hub, userResource string) {
+	completeUser := utils.CreateUserFromUsernameAndResource(payload.To, userResource)
+
+	conn := h.GetIndividualClient(completeUser)
+	if conn != nil {
+		conn.WriteToChannel(data)
+	}
+}
+
+// CreateBatchPayload creates batch of json payloads already queued for the user
+func CreateBatchPayload(to string, payloads []json.RawMessage) Payload {
+	return &outgoingBatch{
+		Type:     batchType,
+		To:       to,
+		Payloads: payloads,
+	}
+}
@@ -0,0 +1,82 @@
+package payload
+
+import (
+	"doki.co.in/doki_real_time_service/utils"
+	"log"
+)
+
+const (
+	blockUserType        = payloadType("block_user")
+	unblockUserType      = payloadType("unblock_user")
+	muteConversationType = payloadType("mute_conversation")
+)
+
+// blockUser stops chat, typing, friend requests, mentions and presence from the user
+// blocked user is not told about it, only other resources of the sender get the payload
+type blockUser struct {
+	Type payloadType `json:"type" validate:"required"`
+	From string      `json:"from" validate:"required"`
+	User string      `json:"user" validate:"required"`
+}
+
+func (payload *blockUser) SendPayload(data *[]byte, h Hub, senderResource string) {
+	if payload.User == payload.From {
+		// invalid state
+		return
+	}
+
+	if err := h.BlockStore().Block(payload.From, payload.User); err != nil {
+		log.Printf("error blocking user: %v\n", err)
+		return
+	}
+
+	// blocked user stops getting presence of the sender
+	for subscriber := range h.GetSubscribers(payload.From) {
+		if user, _ := utils.GetUsernameAndResourceFromUser(subscriber); user == payload.User {
+			h.Unsubscribe(payload.From, subscriber)
+		}
+	}
+
+	sendToOtherResources(data, h, payload.From, senderResource)
+}
+
+type unblockUser struct {
+	Type payloadType `json:"type" validate:"required"`
+	From string      `json:"from" validate:"required"`
+	User string      `json:"user" validate:"required"`
+}
+
+func (payload *unblockUser) SendPayload(data *[]byte, h Hub, senderResource string) {
+	if err := h.BlockStore().Unblock(payload.From, payload.User); err != nil {
+		log.Printf("error unblocking user: %v\n", err)
+		return
+	}
+
+	sendToOtherResources(data, h, payload.From, senderResource)
+}
+
+// muteConversation keeps delivering the conversation but flags its payloads with "muted"
+// conversation of a direct chat is the username of the other user
+type muteConversation struct {
+	Type         payloadType `json:"type" validate:"required"`
+	From         string      `json:"from" validate:"required"`
+	Conversation string      `json:"conversation" validate:"required"`
+	Muted        bool        `json:"muted"`
+}
+
+func (payload *muteConversation) SendPayload(data *[]byte, h Hub, senderResource string) {
+	blocks := h.BlockStore()
+
+	var err error
+	if payload.Muted {
+		err = blocks.Mute(payload.From, payload.Conversation)
+	} else {
+		err = blocks.Unmute(payload.From, payload.Conversation)
+	}
+	if err != nil {
+		log.Printf("error muting conversation: %v\n", err)
+		return
+	}
+
+	sendToOtherResources(data, h, payload.From, senderResource)
+}
@@ -1,9 +1,19 @@
 package payload
 
-var payloadMap = make(map[payloadType]func() Payload)
+import "doki.co.in/doki_real_time_service/codec"
 
 // CreatePayload is factory method to create different payloads based on type
-func CreatePayload(data *[]byte, from string) (Payload, error) {
+// data is decoded with the client codec, non json data is replaced with its json
+// so that the same data can be forwarded to recipients
+func CreatePayload(data *[]byte, from string, clientCodec codec.Codec) (Payload, error) {
+	jsonData, err := clientCodec.ToJson(*data)
+	if err != nil {
+		return nil, &InvalidPayload{
+			reason: "Invalid payload received.",
+		}
+	}
+	*data = jsonData
+
 	// base payload to get type from data
 	var base = &basePayload{}
 	if !unmarshalAndValidate(data, base) {
@@ -19,23 +29,51 @@ func CreatePayload(data *[]byte, from string) (Payload, error) {
 		}
 	}
 
-	// get factory method to generate the  actual payload based on type
-	factory, exists := payloadMap[base.Type]
+	// get registration to generate the  actual payload based on type
+	registration, exists := lookup(base.Type)
 	if !exists {
 		return nil, &InvalidPayload{
 			reason: "Invalid payload received",
 		}
 	}
 
+	// payloads sent only by server are not accepted from clients
+	if registration.Auth == AuthServerOnly {
+		return nil, &InvalidPayload{
+			reason: "Payload can only be sent by server.",
+		}
+	}
+
 	// validate the payload and reject if not proper
-	payload := factory()
+	payload := registration.New()
 	if !unmarshalAndValidate(data, payload) {
 		return nil, &InvalidPayload{
 			reason: "Invalid payload received",
 		}
 	}
 
-	return payload, nil
+	// payloads with routing rule are sent by the router
+	var routed Payload
+	if registration.Rule != nil {
+		routed = &routedPayload{payload: payload, rule: registration.Rule}
+	} else {
+		routed = payload.(Payload)
+	}
+
+	// direct messages are sent only if the message policy allows them
+	if registration.Direct != "" {
+		if routed, err = authorize(data, routed, registration.Direct); err != nil {
+			return nil, err
+		}
+	}
+
+	// moderation runs first so that rejected messages don't start message requests
+	if len(registration.Moderated) > 0 {
+		routed = moderate(routed, registration.Moderated)
+	}
+
+	// rate limit is checked before anything else so that dropped payloads don't reach moderation
+	return limit(routed, registration.RateLimitClass, from), nil
 }
 
 // CreatePresencePayload creates a new presence payload to send to the client
@@ -0,0 +1,40 @@
+package payload
+
+import (
+	"doki.co.in/doki_real_time_service/push"
+	"log"
+)
+
+const (
+	registerDeviceType   = payloadType("register_device")
+	unregisterDeviceType = payloadType("unregister_device")
+)
+
+// registerDevice adds push notification token of the sender device
+// it is notified of chat messages, friend requests, mentions and likes while the user has no connected resource
+type registerDevice struct {
+	Type     payloadType `json:"type" validate:"required"`
+	From     string      `json:"from" validate:"required"`
+	Token    string      `json:"token" validate:"required,max=4096"`
+	Platform string      `json:"platform" validate:"required,oneof=fcm apns"`
+}
+
+func (payload *registerDevice) SendPayload(_ *[]byte, h Hub, _ string) {
+	device := push.Device{Token: payload.Token, Platform: payload.Platform}
+	if err := h.Devices().Register(payload.From, device); err != nil {
+		log.Printf("error registering device: %v\n", err)
+	}
+}
+
+// unregisterDevice removes push notification token, like on logout
+type unregisterDevice struct {
+	Type  payloadType `json:"type" validate:"required"`
+	From  string      `json:"from" validate:"required"`
+	Token string      `json:"token" validate:"required"`
+}
+
+func (payload *unregisterDevice) SendPayload(_ *[]byte, h Hub, _ string) {
+	if err := h.Devices().Unregister(payload.From, payload.Token); err != nil {
+		log.Printf("error unregistering device: %v\n", err)
+	}
+}
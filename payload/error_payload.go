@@ -0,0 +1,50 @@
+package payload
+
+import (
+	"doki.co.in/doki_real_time_service/utils"
+)
+
+const errorType = payloadType("error")
+
+// error codes sent to the client in error payload
+const (
+	errorCodeModeration    = "moderation_rejected"
+	errorCodeMessagePolicy = "message_policy_rejected"
+	errorCodeReactionLimit = "reaction_limit"
+	errorCodeRateLimit     = "rate_limited"
+)
+
+// only server sends this
+// errorFrame tells the sender that its payload was not sent, Id is the id of that payload
+type errorFrame struct {
+	Type   payloadType `json:"type"`
+	To     string      `json:"to"`
+	Id     string      `json:"id,omitempty"`
+	Code   string      `json:"code"`
+	Reason string      `json:"reason"`
+}
+
+func (payload *errorFrame) SendPayload(data *[]byte, h Hub, userResource string) {
+	completeUser := utils.CreateUserFromUsernameAndResource(payload.To, userResource)
+
+	conn := h.GetIndividualClient(completeUser)
+	if conn != nil {
+		conn.WriteToChannel(data)
+	}
+}
+
+// sendError sends error payload to the resource which sent the payload
+func sendError(h Hub, to, resource, id, code, reason string) {
+	frame := &errorFrame{
+		Type:   errorType,
+		To:     to,
+		Id:     id,
+		Code:   code,
+		Reason: reason,
+	}
+
+	data := utils.PayloadToJson(frame)
+	if data != nil {
+		frame.SendPayload(data, h, resource)
+	}
+}
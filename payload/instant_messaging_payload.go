@@ -24,27 +24,6 @@ type chatMessage struct {
 	SendAt  time.Time   `json:"sendAt" validate:"required"`
 }
 
-func (message *chatMessage) SendPayload(data *[]byte, h hub, senderResource string) {
-	recipient := message.To
-	sender := message.From
-
-	// this prevents sending messages twice when user sends self messages
-	if recipient != sender {
-		recipientConnectedClients := h.GetAllConnectedClients(recipient)
-		for _, conn := range recipientConnectedClients {
-			conn.WriteToChannel(data)
-		}
-	}
-
-	senderConnectedClients := h.GetAllConnectedClients(sender)
-	for res, conn := range senderConnectedClients {
-		if res != senderResource {
-			conn.WriteToChannel(data)
-		}
-	}
-
-}
-
 // typingStatus is payload for "typing_status"
 type typingStatus struct {
 	Type payloadType `json:"type" validate:"required"`
@@ -52,18 +31,6 @@ type typingStatus struct {
 	To   string      `json:"to" validate:"required"`
 }
 
-func (status *typingStatus) SendPayload(data *[]byte, h hub, _ string) {
-	recipient := status.To
-	if recipient == status.From {
-		return
-	}
-
-	recipientConnectedClients := h.GetAllConnectedClients(recipient)
-	for _, conn := range recipientConnectedClients {
-		conn.WriteToChannel(data)
-	}
-}
-
 // editMessage is payload for "edit_message"
 type editMessage struct {
 	Type     payloadType `json:"type" validate:"required"`
@@ -74,25 +41,6 @@ type editMessage struct {
 	EditedOn time.Time   `json:"editedOn" validate:"required"`
 }
 
-func (message *editMessage) SendPayload(data *[]byte, h hub, senderResource string) {
-	recipient := message.To
-	sender := message.From
-
-	if recipient != sender {
-		recipientConnectedClients := h.GetAllConnectedClients(recipient)
-		for _, conn := range recipientConnectedClients {
-			conn.WriteToChannel(data)
-		}
-	}
-
-	senderConnectedClients := h.GetAllConnectedClients(sender)
-	for res, conn := range senderConnectedClients {
-		if res != senderResource {
-			conn.WriteToChannel(data)
-		}
-	}
-}
-
 // deleteMessage is payload for "delete_message"
 type deleteMessage struct {
 	Type     payloadType `json:"type" validate:"required"`
@@ -102,25 +50,6 @@ type deleteMessage struct {
 	Everyone bool        `json:"everyone"`
 }
 
-func (message *deleteMessage) SendPayload(data *[]byte, h hub, senderResource string) {
-	recipient := message.To
-	sender := message.From
-
-	if message.Everyone && recipient != sender {
-		recipientConnectedClients := h.GetAllConnectedClients(recipient)
-		for _, conn := range recipientConnectedClients {
-			conn.WriteToChannel(data)
-		}
-	}
-
-	senderConnectedClients := h.GetAllConnectedClients(sender)
-	for res, conn := range senderConnectedClients {
-		if res != senderResource {
-			conn.WriteToChannel(data)
-		}
-	}
-}
-
 //// groupChatMessage is payload for "group_chat_message"
 //type groupChatMessage struct {
 //	Type    payloadType `json:"type" validate:"required"`
@@ -0,0 +1,170 @@
+package payload
+
+import (
+	"doki.co.in/doki_real_time_service/relation"
+	"doki.co.in/doki_real_time_service/utils"
+	"encoding/json"
+	"log"
+)
+
+const (
+	messageRequestType         = payloadType("message_request")
+	messageRequestResponseType = payloadType("message_request_response")
+)
+
+// DirectPolicy is how the message policy applies to a payload sent to the user in "to" field
+type DirectPolicy string
+
+const (
+	// DirectMessage payload is sent as message request when the policy asks for one
+	DirectMessage DirectPolicy = "message"
+
+	// DirectSignal payload is dropped unless the policy allows it, like typing status
+	DirectSignal DirectPolicy = "signal"
+)
+
+// directPayload checks the message policy before sending the payload
+type directPayload struct {
+	Payload
+	direct DirectPolicy
+	from   string
+	to     string
+	id     string
+}
+
+// authorize wraps payload which is checked by the message policy
+func authorize(data *[]byte, payload Payload, direct DirectPolicy) (Payload, error) {
+	var base struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+		Id   any    `json:"id"`
+	}
+	if err := json.Unmarshal(*data, &base); err != nil || base.To == "" {
+		return nil, &InvalidPayload{
+			reason: "Payload has no recipient.",
+		}
+	}
+
+	// id of payloads like delete_message is a list, error frame carries only a single id
+	id, _ := base.Id.(string)
+
+	return &directPayload{
+		Payload: payload,
+		direct:  direct,
+		from:    base.From,
+		to:      base.To,
+		id:      id,
+	}, nil
+}
+
+func (payload *directPayload) SendPayload(data *[]byte, h Hub, senderResource string) {
+	// self messages don't need authorization
+	if payload.from == payload.to {
+		payload.Payload.SendPayload(data, h, senderResource)
+		return
+	}
+
+	switch h.MessagePolicy().Evaluate(payload.from, payload.to) {
+	case relation.Allow:
+		payload.Payload.SendPayload(data, h, senderResource)
+	case relation.Request:
+		if payload.direct == DirectMessage {
+			sendMessageRequest(data, h, payload.from, payload.to, senderResource)
+		}
+	case relation.Reject:
+		// sender is told so that the message is not shown as delivered
+		// payloads without id, like typing status, are dropped silently
+		if payload.id != "" {
+			sendError(h, payload.from, senderResource, payload.id, errorCodeMessagePolicy, "Recipient doesn't accept messages from you.")
+		}
+	}
+}
+
+// only server sends this
+// messageRequest carries the message of a user who can't send direct messages to the recipient yet
+// recipient accepts or declines it with message_request_response
+type messageRequest struct {
+	Type    payloadType     `json:"type"`
+	From    string          `json:"from"`
+	To      string          `json:"to"`
+	Message json.RawMessage `json:"message"`
+}
+
+func (payload *messageRequest) SendPayload(data *[]byte, h Hub, _ string) {
+	for _, conn := range h.GetAllConnectedClients(payload.To) {
+		conn.WriteToChannel(data)
+	}
+}
+
+// sendMessageRequest sends message to the recipient inside a message request
+// sender's other resources get the message as it is, same as an allowed message
+func sendMessageRequest(data *[]byte, h Hub, from, to, senderResource string) {
+	sendToOtherResources(data, h, from, senderResource)
+
+	if h.BlockStore().IsBlocked(to, from) {
+		return
+	}
+
+	relations := h.Relations()
+	if relations.RequestStatus(to, from) == relation.RequestNone {
+		if err := relations.SetRequestStatus(to, from, relation.RequestPending); err != nil {
+			log.Printf("error saving message request: %v\n", err)
+			return
+		}
+	}
+
+	request := &messageRequest{
+		Type:    messageRequestType,
+		From:    from,
+		To:      to,
+		Message: *data,
+	}
+	requestData := utils.PayloadToJson(request)
+	if requestData == nil {
+		return
+	}
+
+	// request is stamped with metadata of the message so that metadata stays the last member
+	if metadata := readMetadata(*data); metadata != nil {
+		*requestData = StampMetadata(*requestData, *metadata)
+	}
+
+	request.SendPayload(requestData, h, "")
+	h.NotifyOffline(*requestData, []string{to})
+	h.NotifyWebhooks(*data)
+}
+
+// messageRequestResponse is sent by the recipient of message requests
+// accepted user can send direct messages from then on, declined user's messages are dropped
+type messageRequestResponse struct {
+	Type   payloadType `json:"type" validate:"required"`
+	From   string      `json:"from" validate:"required"`
+	User   string      `json:"user" validate:"required"`
+	Accept bool        `json:"accept"`
+}
+
+func (payload *messageRequestResponse) SendPayload(data *[]byte, h Hub, senderResource string) {
+	relations := h.Relations()
+	if relations.RequestStatus(payload.From, payload.User) == relation.RequestNone {
+		// there is no request to respond to
+		return
+	}
+
+	status := relation.RequestDeclined
+	if payload.Accept {
+		status = relation.RequestAccepted
+	}
+	if err := relations.SetRequestStatus(payload.From, payload.User, status); err != nil {
+		log.Printf("error saving message request response: %v\n", err)
+		return
+	}
+
+	sendToOtherResources(data, h, payload.From, senderResource)
+
+	// declined user is not told about it
+	if payload.Accept {
+		for _, conn := range h.GetAllConnectedClients(payload.User) {
+			conn.WriteToChannel(data)
+		}
+	}
+}
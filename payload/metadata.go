@@ -0,0 +1,69 @@
+package payload
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// MetadataProtocolVersion is the first protocol version which gets metadata on relayed payloads
+const MetadataProtocolVersion = 3
+
+// metadataMember is how metadata is appended to the payload, it is always the last member
+var metadataMember = []byte(`,"meta":`)
+
+// Metadata is stamped by server on every payload relayed from a client
+// recipients can trust it unlike fields of the payload which are set by the sender
+// ServerTs is in milliseconds
+type Metadata struct {
+	ServerTs     int64  `json:"serverTs"`
+	Resource     string `json:"resource"`
+	EventId      string `json:"eventId"`
+	VerifiedFrom string `json:"verifiedFrom,omitempty"`
+}
+
+// StampMetadata returns json payload with metadata as its last member
+// metadata sent by the client is replaced
+func StampMetadata(data []byte, metadata Metadata) []byte {
+	data = bytes.TrimSpace(data)
+	if len(data) < 2 || data[len(data)-1] != '}' {
+		return data
+	}
+
+	// member is removed in place so that the payload keeps its bytes and member order
+	// names are compared decoded, so an escaped name is removed as well
+	data = removeMember(data, "meta")
+
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return data
+	}
+
+	stamped := make([]byte, 0, len(data)+len(metadataMember)+len(encoded))
+	stamped = append(stamped, data[:len(data)-1]...)
+	stamped = append(stamped, metadataMember...)
+	stamped = append(stamped, encoded...)
+	return append(stamped, '}')
+}
+
+// readMetadata returns metadata stamped on the payload
+func readMetadata(data []byte) *Metadata {
+	var stamped struct {
+		Meta *Metadata `json:"meta"`
+	}
+	_ = json.Unmarshal(data, &stamped)
+
+	return stamped.Meta
+}
+
+// stripMetadata returns payload in the shape sent by the client for versions before metadata
+// quotes inside json strings are escaped, so only the appended member matches and the payload
+// is cut without decoding it
+func stripMetadata(data []byte) []byte {
+	index := bytes.LastIndex(data, append(metadataMember[:len(metadataMember):len(metadataMember)], '{'))
+	if index < 0 {
+		return data
+	}
+
+	// frame is shared between recipients, so it is copied instead of appending in place
+	return append(data[:index:index], '}')
+}
@@ -0,0 +1,96 @@
+package payload
+
+import (
+	"doki.co.in/doki_real_time_service/codec"
+	"doki.co.in/doki_real_time_service/moderation"
+	"doki.co.in/doki_real_time_service/utils"
+	"encoding/json"
+	"time"
+)
+
+// moderatedPayload checks text fields with the moderation pipeline before sending the payload
+type moderatedPayload struct {
+	Payload
+	fields []string
+}
+
+// moderate wraps payload whose fields are checked by the moderation pipeline
+func moderate(payload Payload, fields []string) Payload {
+	return &moderatedPayload{
+		Payload: payload,
+		fields:  fields,
+	}
+}
+
+func (payload *moderatedPayload) SendPayload(data *[]byte, h Hub, senderResource string) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(*data, &fields); err != nil {
+		return
+	}
+
+	message := moderation.Message{
+		Type: stringMember(fields, "type"),
+		From: stringMember(fields, "from"),
+		To:   stringMember(fields, "to"),
+		Id:   stringMember(fields, "id"),
+	}
+
+	for _, field := range payload.fields {
+		message.Text = stringMember(fields, field)
+		if message.Text == "" {
+			continue
+		}
+
+		verdict := h.Moderate(message)
+		switch verdict.Action {
+		case moderation.Redact:
+			*data = replaceMember(*data, field, verdict.Text)
+
+		case moderation.Hold:
+			held := moderation.Held{
+				Id:       utils.NewEventId(),
+				Message:  message,
+				Reason:   verdict.Reason,
+				Resource: senderResource,
+				HeldAt:   time.Now(),
+				Payload:  *data,
+			}
+			if !h.HoldForReview(held) {
+				sendError(h, message.From, senderResource, message.Id, errorCodeModeration, "Message can't be reviewed now.")
+			}
+			return
+
+		case moderation.Reject:
+			sendError(h, message.From, senderResource, message.Id, errorCodeModeration, verdict.Reason)
+			return
+		}
+	}
+
+	payload.Payload.SendPayload(data, h, senderResource)
+}
+
+// ReleasePayload sends payload of a held message without moderating it again
+// message policy and routing rules of the payload type still apply, rate limit was taken when it was held
+func ReleasePayload(data []byte, from, senderResource string, h Hub) error {
+	released, err := CreatePayload(&data, from, codec.Json)
+	if err != nil {
+		return err
+	}
+	if limited, ok := released.(*limitedPayload); ok {
+		released = limited.Payload
+	}
+	if moderated, ok := released.(*moderatedPayload); ok {
+		released = moderated.Payload
+	}
+
+	released.SendPayload(&data, h, senderResource)
+	return nil
+}
+
+// stringMember returns the member if it is a string, empty otherwise
+func stringMember(fields map[string]json.RawMessage, name string) string {
+	var value string
+	_ = json.Unmarshal(fields[name], &value)
+
+	return value
+}
@@ -1,7 +1,13 @@
 package payload
 
 import (
+	"doki.co.in/doki_real_time_service/block"
 	"doki.co.in/doki_real_time_service/client"
+	"doki.co.in/doki_real_time_service/moderation"
+	"doki.co.in/doki_real_time_service/push"
+	"doki.co.in/doki_real_time_service/reaction"
+	"doki.co.in/doki_real_time_service/relation"
+	"doki.co.in/doki_real_time_service/unread"
 	"encoding/json"
 	"github.com/go-playground/validator/v10"
 )
@@ -13,11 +19,12 @@ type payloadType string
 
 type Payload interface {
 	// SendPayload expects raw payload, hub, and senders resource
-	SendPayload(*[]byte, hub, string)
+	SendPayload(*[]byte, Hub, string)
 }
 
-// this is payload hub interface required to send data
-type hub interface {
+// Hub is the payload hub interface required to send data
+// it is exported so that payloads registered from other packages can route themselves
+type Hub interface {
 	GetAllConnectedClients(string) map[string]client.Client
 
 	GetIndividualClient(string) client.Client
@@ -27,6 +34,45 @@ type hub interface {
 	Unsubscribe(string, string)
 
 	GetSubscribers(string) map[string]bool
+
+	// Negotiate sets protocol version and capabilities declared by the complete user
+	Negotiate(string, int, []string)
+
+	// BlockStore returns users blocked and conversations muted by every user
+	BlockStore() block.Store
+
+	// Relations returns friends and message requests between users
+	Relations() relation.Store
+
+	// MessagePolicy returns the policy authorizing direct messages
+	MessagePolicy() relation.Policy
+
+	// Devices returns push notification tokens of every user
+	Devices() push.DeviceStore
+
+	// NotifyOffline sends push notification of the json payload to users who have no connected resource
+	NotifyOffline([]byte, []string)
+
+	// NotifyWebhooks queues the json payload for webhook endpoints of its type
+	// it is called once the payload is accepted, even if no recipient is connected
+	// it is not called for payloads dropped by rate limit, moderation, blocks of every recipient or message policy
+	NotifyWebhooks([]byte)
+
+	// Moderate returns verdict of the moderation pipeline on the message
+	Moderate(moderation.Message) moderation.Verdict
+
+	// HoldForReview keeps the message until it is released or discarded, it returns false when it can't be held
+	HoldForReview(moderation.Held) bool
+
+	// Unread returns unread message counts of every user
+	Unread() unread.Store
+
+	// Reactions returns reactions of users on messages
+	Reactions() reaction.Store
+
+	// AllowRate takes a payload of the rate limit class from the limit of username and resource
+	// it returns false when the limit is used up and the payload must be dropped
+	AllowRate(string, string, string) bool
 }
 
 type InvalidPayload struct {
@@ -43,10 +89,10 @@ type basePayload struct {
 	From string      `json:"from" validate:"required"`
 }
 
-func (base *basePayload) SendPayload(*[]byte, hub, string) {}
+func (base *basePayload) SendPayload(*[]byte, Hub, string) {}
 
 // unmarshalAndValidate first unmarshal payload json and validates it
-func unmarshalAndValidate(payload *[]byte, target Payload) bool {
+func unmarshalAndValidate(payload *[]byte, target any) bool {
 	if err := json.Unmarshal(*payload, target); err != nil {
 		//log.Printf("error unmarshalling payload: %v\n", err)
 		return false
@@ -60,28 +106,69 @@ func unmarshalAndValidate(payload *[]byte, target Payload) bool {
 	return true
 }
 
+// InitPayload registers the payloads of the service, it can be called more than once
 func InitPayload() {
+	builtinsOnce.Do(registerBuiltins)
+}
+
+// registerBuiltins registers payloads of the service
+// server only payloads are registered for introspection as clients can't send them
+func registerBuiltins() {
+	// friend relation can't be with self, so self addressed payloads are dropped
+	friendRule := &RoutingRule{Users: []string{"to"}, EchoToSender: true, DropSelfAddressed: true}
+
+	// protocol negotiation payload
+	mustRegister(Registration{Type: string(helloType), New: func() any { return &hello{} }, RateLimitClass: RateLimitProtocol})
+	mustRegister(Registration{Type: string(welcomeType), New: func() any { return &welcome{} }, Auth: AuthServerOnly})
+	mustRegister(Registration{Type: string(serverShutdownType), New: func() any { return &serverShutdown{} }, Auth: AuthServerOnly})
+
+	// multiple payloads in a single frame
+	mustRegister(Registration{Type: string(batchType), New: func() any { return &batch{} }, RateLimitClass: RateLimitProtocol})
+	mustRegister(Registration{Type: string(batchResultType), New: func() any { return &batchResult{} }, Auth: AuthServerOnly})
+	mustRegister(Registration{Type: string(errorType), New: func() any { return &errorFrame{} }, Auth: AuthServerOnly})
+
 	// instant messaging payloads
-	payloadMap[chatMessageType] = func() Payload { return &chatMessage{} }
-	payloadMap[typingStatusType] = func() Payload { return &typingStatus{} }
-	payloadMap[editMessageType] = func() Payload { return &editMessage{} }
-	payloadMap[deleteMessageType] = func() Payload { return &deleteMessage{} }
+	mustRegister(Registration{Type: string(chatMessageType), New: func() any { return &chatMessage{} }, Direct: DirectMessage, Moderated: []string{"body"}, Rule: &RoutingRule{Users: []string{"to"}, EchoToSender: true, Blockable: []string{"to"}, Mutable: true, Notify: []string{"to"}, Unread: []string{"to"}}, RateLimitClass: RateLimitMessaging})
+	mustRegister(Registration{Type: string(typingStatusType), New: func() any { return &typingStatus{} }, Direct: DirectSignal, Rule: &RoutingRule{Users: []string{"to"}, Blockable: []string{"to"}}, RateLimitClass: RateLimitTyping})
+	mustRegister(Registration{Type: string(editMessageType), New: func() any { return &editMessage{} }, Direct: DirectSignal, Moderated: []string{"body"}, Rule: ConversationRule(), RateLimitClass: RateLimitMessaging})
+	mustRegister(Registration{Type: string(deleteMessageType), New: func() any { return &deleteMessage{} }, Direct: DirectSignal, Rule: &RoutingRule{Users: []string{"to"}, When: "everyone", EchoToSender: true}, RateLimitClass: RateLimitMessaging})
+	mustRegister(Registration{Type: string(messageReactionType), New: func() any { return &messageReaction{} }, Rule: &RoutingRule{Users: []string{"to", "members"}, EchoToSender: true, Blockable: []string{"to", "members"}}, RateLimitClass: RateLimitMessaging})
+
+	// unread counts synced across resources of the user
+	mustRegister(Registration{Type: string(readReceiptType), New: func() any { return &readReceipt{} }, Rule: &RoutingRule{Users: []string{"to"}, EchoToSender: true, Blockable: []string{"to"}}, RateLimitClass: RateLimitMessaging})
+	mustRegister(Registration{Type: string(unreadUpdateType), New: func() any { return &unreadUpdate{} }, Auth: AuthServerOnly})
+	mustRegister(Registration{Type: string(unreadSyncType), New: func() any { return &unreadSync{} }, RateLimitClass: RateLimitSubscription})
+	mustRegister(Registration{Type: string(unreadCountsType), New: func() any { return &unreadCounts{} }, Auth: AuthServerOnly})
+
+	// message requests from users who can't send direct messages yet
+	mustRegister(Registration{Type: string(messageRequestType), New: func() any { return &messageRequest{} }, Auth: AuthServerOnly})
+	mustRegister(Registration{Type: string(messageRequestResponseType), New: func() any { return &messageRequestResponse{} }, RateLimitClass: RateLimitSocial})
+
+	// push notification device tokens
+	mustRegister(Registration{Type: string(registerDeviceType), New: func() any { return &registerDevice{} }, RateLimitClass: RateLimitSubscription})
+	mustRegister(Registration{Type: string(unregisterDeviceType), New: func() any { return &unregisterDevice{} }, RateLimitClass: RateLimitSubscription})
 
 	// user to user action payload
-	payloadMap[userSendFriendRequestType] = func() Payload { return &userSendFriendRequest{} }
-	payloadMap[userAcceptedFriendRequestType] = func() Payload { return &userAcceptFriendRequest{} }
-	payloadMap[userRemovesFriendRelationType] = func() Payload { return &userRemovesFriendRelation{} }
+	mustRegister(Registration{Type: string(userSendFriendRequestType), New: func() any { return &userSendFriendRequest{} }, Rule: &RoutingRule{Users: []string{"to"}, EchoToSender: true, DropSelfAddressed: true, Blockable: []string{"to"}, Notify: []string{"to"}}, RateLimitClass: RateLimitSocial})
+	mustRegister(Registration{Type: string(userAcceptedFriendRequestType), New: func() any { return &userAcceptFriendRequest{} }, Rule: friendRule, RateLimitClass: RateLimitSocial})
+	mustRegister(Registration{Type: string(userRemovesFriendRelationType), New: func() any { return &userRemovesFriendRelation{} }, Rule: friendRule, RateLimitClass: RateLimitSocial})
 
 	// user profile self action and user nodes action payload
-	payloadMap[userUpdateProfileType] = func() Payload { return &userUpdateProfile{} }
-	payloadMap[userCreateRootNodeType] = func() Payload { return &userCreateRootNode{} }
-	payloadMap[userNodeLikeActionType] = func() Payload { return &userNodeLikeAction{} }
-	payloadMap[userCreateSecondaryNodeType] = func() Payload { return &userCreateSecondaryNode{} }
+	mustRegister(Registration{Type: string(userUpdateProfileType), New: func() any { return &userUpdateProfile{} }, Rule: &RoutingRule{EchoToSender: true}, RateLimitClass: RateLimitNodes})
+	mustRegister(Registration{Type: string(userCreateRootNodeType), New: func() any { return &userCreateRootNode{} }, Rule: &RoutingRule{Users: []string{"usersTagged"}, EchoToSender: true, Blockable: []string{"usersTagged"}}, RateLimitClass: RateLimitNodes})
+	mustRegister(Registration{Type: string(userNodeLikeActionType), New: func() any { return &userNodeLikeAction{} }, Rule: &RoutingRule{Users: []string{"to"}, EchoToSender: true, Notify: []string{"to"}}, RateLimitClass: RateLimitNodes})
+	mustRegister(Registration{Type: string(userCreateSecondaryNodeType), New: func() any { return &userCreateSecondaryNode{} }, Rule: &RoutingRule{Users: []string{"to", "mentions", "replyOnNodeCreatedBy"}, EchoToSender: true, Blockable: []string{"mentions"}, Notify: []string{"mentions"}}, RateLimitClass: RateLimitNodes})
+
+	// blocking users and muting conversations payload
+	mustRegister(Registration{Type: string(blockUserType), New: func() any { return &blockUser{} }, RateLimitClass: RateLimitSocial})
+	mustRegister(Registration{Type: string(unblockUserType), New: func() any { return &unblockUser{} }, RateLimitClass: RateLimitSocial})
+	mustRegister(Registration{Type: string(muteConversationType), New: func() any { return &muteConversation{} }, RateLimitClass: RateLimitSocial})
 
 	// user presence subscription payload
-	payloadMap[userPresenceSubscriptionType] = func() Payload { return &userPresenceSubscription{} }
+	mustRegister(Registration{Type: string(userPresenceSubscriptionType), New: func() any { return &userPresenceSubscription{} }, RateLimitClass: RateLimitSubscription})
+	mustRegister(Registration{Type: string(userPresenceInfoType), New: func() any { return &userPresenceInfoPayload{} }, Auth: AuthServerOnly})
 
 	// poll actions payload
-	payloadMap[pollsSubscriptionType] = func() Payload { return &pollsSubscription{} }
-	payloadMap[pollsVotesUpdateType] = func() Payload { return &pollsVotesUpdate{} }
-}
\ No newline at end of file
+	mustRegister(Registration{Type: string(pollsSubscriptionType), New: func() any { return &pollsSubscription{} }, RateLimitClass: RateLimitSubscription})
+	mustRegister(Registration{Type: string(pollsVotesUpdateType), New: func() any { return &pollsVotesUpdate{} }, Rule: &RoutingRule{Nodes: []string{"pollId"}}, RateLimitClass: RateLimitNodes})
+}
@@ -14,7 +14,7 @@ type pollsSubscription struct {
 	Subscribe bool        `json:"subscribe"`
 }
 
-func (payload *pollsSubscription) SendPayload(_ *[]byte, h hub, senderResource string) {
+func (payload *pollsSubscription) SendPayload(_ *[]byte, h Hub, senderResource string) {
 	completeUser := utils.CreateUserFromUsernameAndResource(payload.From, senderResource)
 
 	if payload.Subscribe {
@@ -30,21 +30,3 @@ type pollsVotesUpdate struct {
 	PollId string      `json:"pollId" validate:"required"`
 	Votes  []int       `json:"votes" validate:"required"`
 }
-
-func (payload *pollsVotesUpdate) SendPayload(data *[]byte, h hub, senderResource string) {
-	// get subscribers and send
-	subscribers := h.GetSubscribers(payload.PollId)
-	for subscriber := range subscribers {
-		conn := h.GetIndividualClient(subscriber)
-		if conn == nil {
-			h.Unsubscribe(payload.PollId, subscriber)
-			continue
-		}
-
-		user, res := conn.GetUserInfo()
-		if user != payload.From || res != senderResource {
-			// send votes update to user
-			conn.WriteToChannel(data)
-		}
-	}
-}
\ No newline at end of file
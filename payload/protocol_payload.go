@@ -0,0 +1,137 @@
+package payload
+
+import (
+	"doki.co.in/doki_real_time_service/utils"
+	"encoding/json"
+	"time"
+)
+
+const (
+	// LegacyProtocolVersion is used by clients which don't declare any version
+	LegacyProtocolVersion = 1
+
+	// CurrentProtocolVersion is the protocol version spoken by the server
+	CurrentProtocolVersion = 3
+)
+
+const (
+	helloType   = payloadType("hello")
+	welcomeType = payloadType("welcome")
+)
+
+// Adapter converts json frame of current protocol version to the shape expected
+// by an older version, returning nil drops the frame for that version
+type Adapter func([]byte) []byte
+
+// adapters are keyed by protocol version and payload type
+var adapters = map[int]map[payloadType]Adapter{
+	LegacyProtocolVersion: {
+		// legacy clients don't know about shutdown frame, they reconnect on close frame
+		serverShutdownType: dropFrame,
+		welcomeType:        dropFrame,
+	},
+}
+
+func dropFrame([]byte) []byte {
+	return nil
+}
+
+// AdaptForVersion returns json frame in the shape expected by clients of the protocol version
+// returns nil if frame should not be sent to them
+func AdaptForVersion(data []byte, version int) []byte {
+	if version < MetadataProtocolVersion {
+		data = stripMetadata(data)
+	}
+
+	versionAdapters, ok := adapters[version]
+	if !ok {
+		return data
+	}
+
+	var base struct {
+		Type payloadType `json:"type"`
+	}
+	if err := json.Unmarshal(data, &base); err != nil {
+		return data
+	}
+
+	adapter, ok := versionAdapters[base.Type]
+	if !ok {
+		return data
+	}
+
+	return adapter(data)
+}
+
+// hello is the first frame sent by clients which didn't declare version while connecting
+type hello struct {
+	Type         payloadType `json:"type" validate:"required"`
+	From         string      `json:"from" validate:"required"`
+	Version      int         `json:"version" validate:"required,min=1"`
+	Capabilities []string    `json:"capabilities"`
+}
+
+func (payload *hello) SendPayload(_ *[]byte, h Hub, senderResource string) {
+	completeUser := utils.CreateUserFromUsernameAndResource(payload.From, senderResource)
+	h.Negotiate(completeUser, payload.Version, payload.Capabilities)
+}
+
+// ServerInfo is sent to the client in welcome frame
+type ServerInfo struct {
+	ServerVersion        string
+	ProtocolVersion      int
+	IncomingPayloadLimit int64
+	PingInterval         time.Duration
+	PongWait             time.Duration
+	Features             []string
+}
+
+type welcomeLimits struct {
+	IncomingPayloadLimit int64 `json:"incomingPayloadLimit"`
+	PingInterval         int64 `json:"pingInterval"`
+	PongWait             int64 `json:"pongWait"`
+}
+
+// only server sends this
+// welcome is the reply to the version declared by client
+// durations are in milliseconds
+type welcome struct {
+	Type            payloadType   `json:"type"`
+	To              string        `json:"to"`
+	Resource        string        `json:"resource"`
+	ServerVersion   string        `json:"serverVersion"`
+	ProtocolVersion int           `json:"protocolVersion"`
+	Limits          welcomeLimits `json:"limits"`
+	Features        []string      `json:"features"`
+}
+
+func (payload *welcome) SendPayload(data *[]byte, h Hub, userResource string) {
+	completeUser := utils.CreateUserFromUsernameAndResource(payload.To, userResource)
+
+	conn := h.GetIndividualClient(completeUser)
+	if conn != nil {
+		conn.WriteToChannel(data)
+	}
+}
+
+// CreateWelcomePayload creates welcome payload for the complete user
+func CreateWelcomePayload(to, resource string, info ServerInfo) Payload {
+	features := info.Features
+	if features == nil {
+		features = []string{}
+	}
+
+	return &welcome{
+		Type:            welcomeType,
+		To:              to,
+		Resource:        resource,
+		ServerVersion:   info.ServerVersion,
+		ProtocolVersion: info.ProtocolVersion,
+		Limits: welcomeLimits{
+			IncomingPayloadLimit: info.IncomingPayloadLimit,
+			PingInterval:         info.PingInterval.Milliseconds(),
+			PongWait:             info.PongWait.Milliseconds(),
+		},
+		Features: features,
+	}
+}
@@ -0,0 +1,35 @@
+package payload
+
+import "encoding/json"
+
+// limitedPayload is sent only if the sender has not used up the rate limit class of the payload type
+type limitedPayload struct {
+	Payload
+	class string
+	from  string
+}
+
+// limit wraps payload which counts against the rate limit class of the sender
+func limit(payload Payload, class, from string) Payload {
+	return &limitedPayload{
+		Payload: payload,
+		class:   class,
+		from:    from,
+	}
+}
+
+func (payload *limitedPayload) SendPayload(data *[]byte, h Hub, senderResource string) {
+	if h.AllowRate(payload.from, senderResource, payload.class) {
+		payload.Payload.SendPayload(data, h, senderResource)
+		return
+	}
+
+	// id is read only for the dropped payloads as they are rare
+	var base struct {
+		Id any `json:"id"`
+	}
+	_ = json.Unmarshal(*data, &base)
+	id, _ := base.Id.(string)
+
+	sendError(h, payload.from, senderResource, id, errorCodeRateLimit, "Too many payloads, try again later.")
+}
@@ -0,0 +1,135 @@
+package payload
+
+import (
+	"doki.co.in/doki_real_time_service/reaction"
+	"doki.co.in/doki_real_time_service/relation"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"log"
+	"slices"
+	"unicode"
+)
+
+const messageReactionType = payloadType("message_reaction")
+
+// reaction actions
+const (
+	reactionAdd    = "add"
+	reactionRemove = "remove"
+)
+
+// maxReactionLength is max bytes of a reaction, long enough for emoji sequences like flags and families
+const maxReactionLength = 32
+
+// emoji parts which are not symbols themselves
+const (
+	zeroWidthJoiner = '\u200d'
+	combiningKeycap = '\u20e3'
+)
+
+func init() {
+	if err := validate.RegisterValidation("reaction", isReaction); err != nil {
+		panic(err)
+	}
+}
+
+// messageReaction adds or removes reaction of the sender on the message with Id
+// message of a direct conversation has the other user in "to" field, message of a group
+// has GroupId and Members who receive the reaction as there is no group membership in the service
+// members can't be verified, so the message policy is checked for every member same as for "to"
+type messageReaction struct {
+	Type     payloadType `json:"type" validate:"required"`
+	From     string      `json:"from" validate:"required"`
+	To       string      `json:"to" validate:"required_without=GroupId,excluded_with=GroupId"`
+	GroupId  string      `json:"groupId"`
+	Members  []string    `json:"members" validate:"required_with=GroupId,excluded_with=To,max=256,dive,required"`
+	Id       string      `json:"id" validate:"required"`
+	Reaction string      `json:"reaction" validate:"required,reaction"`
+	Action   string      `json:"action" validate:"required,oneof=add remove"`
+}
+
+// beforeRouting saves the reaction, payload which doesn't change reactions of the message is dropped
+// and sender gets an error when the reaction limit is reached or any recipient doesn't accept messages
+func (payload *messageReaction) beforeRouting(h Hub, senderResource string) bool {
+	if !payload.allowed(h) {
+		sendError(h, payload.From, senderResource, payload.Id, errorCodeMessagePolicy, "Recipient doesn't accept messages from you.")
+		return false
+	}
+
+	conversation := payload.conversation()
+
+	var changed bool
+	var err error
+	if payload.Action == reactionRemove {
+		changed, err = h.Reactions().Remove(conversation, payload.Id, payload.From, payload.Reaction)
+	} else {
+		changed, err = h.Reactions().Add(conversation, payload.Id, payload.From, payload.Reaction)
+	}
+
+	if errors.Is(err, reaction.ErrLimit) {
+		sendError(h, payload.From, senderResource, payload.Id, errorCodeReactionLimit, "Reaction limit of the message is reached.")
+		return false
+	}
+	if err != nil {
+		log.Printf("error saving reaction: %v\n", err)
+		return false
+	}
+
+	return changed
+}
+
+// allowed returns whether message policy allows sender to message every recipient
+func (payload *messageReaction) allowed(h Hub) bool {
+	recipients := payload.Members
+	if payload.To != "" {
+		recipients = []string{payload.To}
+	}
+
+	for _, recipient := range recipients {
+		if recipient != payload.From && h.MessagePolicy().Evaluate(payload.From, recipient) != relation.Allow {
+			return false
+		}
+	}
+
+	return true
+}
+
+// conversation is the same for both users of a direct conversation and for every member of a group
+func (payload *messageReaction) conversation() string {
+	if payload.GroupId != "" {
+		return "group:" + payload.GroupId
+	}
+
+	users := []string{payload.From, payload.To}
+	slices.Sort(users)
+	return "direct:" + users[0] + ":" + users[1]
+}
+
+func isReaction(field validator.FieldLevel) bool {
+	return validReaction(field.Field().String())
+}
+
+// validReaction allows emoji including sequences joined by zero width joiner, with variation selectors,
+// skin tones, keycaps and flag tags, text and whitespace are not reactions
+func validReaction(value string) bool {
+	if value == "" || len(value) > maxReactionLength {
+		return false
+	}
+
+	emoji := false
+	for _, r := range value {
+		switch {
+		case r == unicode.ReplacementChar:
+			return false
+		case unicode.Is(unicode.So, r), r == combiningKeycap, r >= 0x1f3fb && r <= 0x1f3ff:
+			emoji = true
+		case r == zeroWidthJoiner, unicode.Is(unicode.Variation_Selector, r), r >= 0xe0020 && r <= 0xe007f:
+		case r >= '0' && r <= '9', r == '#', r == '*':
+			// keycap bases
+		default:
+			return false
+		}
+	}
+
+	return emoji
+}
@@ -0,0 +1,178 @@
+package payload
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// AuthRequirement says who can send a payload
+type AuthRequirement string
+
+const (
+	// AuthSender accepts payload from clients whose username is in "from" field
+	AuthSender AuthRequirement = "sender"
+
+	// AuthServerOnly rejects payload from clients, it is only sent by server
+	AuthServerOnly AuthRequirement = "server"
+)
+
+// rate limit classes of the service payloads, payload types of a class share the limit
+const (
+	RateLimitDefault      = "default"
+	RateLimitProtocol     = "protocol"
+	RateLimitMessaging    = "messaging"
+	RateLimitTyping       = "typing"
+	RateLimitSocial       = "social"
+	RateLimitNodes        = "nodes"
+	RateLimitSubscription = "subscription"
+)
+
+var ErrDuplicatePayloadType = errors.New("payload type is already registered")
+
+// Registration describes a payload type clients can send
+// empty Auth and RateLimitClass are AuthSender and RateLimitDefault
+type Registration struct {
+	// Type is the value of type field in json payload
+	Type string `json:"type"`
+
+	// New creates the value which json payload is unmarshalled into and validated
+	// it must be a Payload when there is no Rule as the payload routes itself
+	New func() any `json:"-"`
+
+	// Rule routes the payload, nil Rule leaves routing to SendPayload of the payload
+	Rule *RoutingRule `json:"rule,omitempty"`
+
+	// Direct checks payload sent to the user in "to" field with the message policy, empty skips the check
+	Direct DirectPolicy `json:"direct,omitempty"`
+
+	// Moderated are string fields checked by the moderation pipeline before the payload is routed
+	Moderated []string `json:"moderated,omitempty"`
+
+	Auth AuthRequirement `json:"auth"`
+
+	// RateLimitClass is the limit the payload counts against, limits of the classes are set in config
+	RateLimitClass string `json:"rateLimitClass"`
+}
+
+type registry struct {
+	sync.RWMutex
+	registrations map[payloadType]Registration
+}
+
+var (
+	payloads = registry{
+		registrations: make(map[payloadType]Registration),
+	}
+
+	// builtinsOnce registers payloads of the service before the first registration
+	// so that a custom payload can't take the name of a service payload
+	builtinsOnce sync.Once
+)
+
+// Register adds payload type which can be sent by clients
+// it can be called from main or plugin packages before the hub starts serving clients
+func Register(registration Registration) error {
+	builtinsOnce.Do(registerBuiltins)
+	return register(registration)
+}
+
+// MustRegister is same as Register but panics if payload can't be registered
+func MustRegister(registration Registration) {
+	if err := Register(registration); err != nil {
+		panic(err)
+	}
+}
+
+// RegisterRouted adds payload type which needs only type and from fields and is sent by the rule
+// it is used for payload types added from config
+func RegisterRouted(payloadType string, rule RoutingRule, rateLimitClass string) error {
+	return Register(Registration{
+		Type:           payloadType,
+		New:            func() any { return &basePayload{} },
+		Rule:           &rule,
+		RateLimitClass: rateLimitClass,
+	})
+}
+
+func mustRegister(registration Registration) {
+	if err := register(registration); err != nil {
+		panic(err)
+	}
+}
+
+func register(registration Registration) error {
+	if strings.TrimSpace(registration.Type) == "" {
+		return errors.New("payload type can't be empty")
+	}
+	if registration.New == nil {
+		return fmt.Errorf("payload type %q has no factory", registration.Type)
+	}
+
+	if registration.Rule == nil {
+		if _, ok := registration.New().(Payload); !ok {
+			return fmt.Errorf("payload type %q has neither routing rule nor SendPayload", registration.Type)
+		}
+	} else {
+		rule := *registration.Rule
+		registration.Rule = &rule
+	}
+
+	switch registration.Direct {
+	case "", DirectMessage, DirectSignal:
+	default:
+		return fmt.Errorf("payload type %q has unknown direct policy %q", registration.Type, registration.Direct)
+	}
+
+	switch registration.Auth {
+	case "":
+		registration.Auth = AuthSender
+	case AuthSender, AuthServerOnly:
+	default:
+		return fmt.Errorf("payload type %q has unknown auth requirement %q", registration.Type, registration.Auth)
+	}
+
+	if registration.RateLimitClass == "" {
+		registration.RateLimitClass = RateLimitDefault
+	}
+
+	payloads.Lock()
+	defer payloads.Unlock()
+
+	if _, ok := payloads.registrations[payloadType(registration.Type)]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicatePayloadType, registration.Type)
+	}
+
+	payloads.registrations[payloadType(registration.Type)] = registration
+	return nil
+}
+
+// Lookup returns registration of the payload type
+func Lookup(payloadType string) (Registration, bool) {
+	return lookup(payloadType)
+}
+
+func lookup[T ~string](name T) (Registration, bool) {
+	payloads.RLock()
+	defer payloads.RUnlock()
+
+	registration, ok := payloads.registrations[payloadType(name)]
+	return registration, ok
+}
+
+// Registered returns all the registered payload types sorted by type
+func Registered() []Registration {
+	payloads.RLock()
+	registrations := make([]Registration, 0, len(payloads.registrations))
+	for _, registration := range payloads.registrations {
+		registrations = append(registrations, registration)
+	}
+	payloads.RUnlock()
+
+	slices.SortFunc(registrations, func(a, b Registration) int {
+		return strings.Compare(a.Type, b.Type)
+	})
+	return registrations
+}
@@ -0,0 +1,393 @@
+package payload
+
+import (
+	"bytes"
+	"doki.co.in/doki_real_time_service/client"
+	"doki.co.in/doki_real_time_service/utils"
+	"encoding/json"
+	"slices"
+)
+
+// mutedMember flags payload of a muted conversation so that clients suppress notifications
+var mutedMember = []byte(`{"muted":true`)
+
+// RoutingRule declares who receives a payload sent by a client
+// a connection receives the payload once even when it is in more than one recipient set
+// and the sender resource never receives its own payload
+type RoutingRule struct {
+	// Users are fields of the payload with a username or a list of usernames
+	// every resource of these users receives the payload, sender is left to EchoToSender
+	Users []string `json:"users,omitempty" yaml:"users"`
+
+	// Nodes are fields of the payload with a node id or a list of node ids
+	// every resource subscribed to these nodes receives the payload
+	Nodes []string `json:"nodes,omitempty" yaml:"nodes"`
+
+	// When is a boolean field of the payload, users and nodes receive the payload only when it is true
+	When string `json:"when,omitempty" yaml:"when"`
+
+	// EchoToSender sends the payload to other resources of the sender so that they stay in sync
+	EchoToSender bool `json:"echoToSender,omitempty" yaml:"echoToSender"`
+
+	// DropSelfAddressed drops the payload when the sender is in any of the user fields
+	DropSelfAddressed bool `json:"dropSelfAddressed,omitempty" yaml:"dropSelfAddressed"`
+
+	// Blockable are user fields whose users don't receive the payload from senders they have blocked
+	Blockable []string `json:"blockable,omitempty" yaml:"blockable"`
+
+	// Mutable flags the payload with "muted" for users who have muted the conversation with the sender
+	Mutable bool `json:"mutable,omitempty" yaml:"mutable"`
+
+	// Notify are user fields whose users get push notification when they have no connected resource
+	// users who have muted the conversation don't get it
+	Notify []string `json:"notify,omitempty" yaml:"notify"`
+
+	// Unread are user fields whose users get one more unread message in the conversation with the sender
+	Unread []string `json:"unread,omitempty" yaml:"unread"`
+}
+
+// RecipientRule sends the payload to the user in "to" field
+func RecipientRule() *RoutingRule {
+	return &RoutingRule{Users: []string{"to"}}
+}
+
+// ConversationRule sends the payload to the user in "to" field and to other resources of the sender
+func ConversationRule() *RoutingRule {
+	return &RoutingRule{Users: []string{"to"}, EchoToSender: true}
+}
+
+// beforeRouting is implemented by payloads which change state of the service before they are routed
+// payload is dropped when it returns false
+type beforeRouting interface {
+	beforeRouting(h Hub, senderResource string) bool
+}
+
+// routedPayload is sent by the routing rule of its registration
+type routedPayload struct {
+	payload any
+	rule    *RoutingRule
+}
+
+func (payload *routedPayload) SendPayload(data *[]byte, h Hub, senderResource string) {
+	if hook, ok := payload.payload.(beforeRouting); ok && !hook.beforeRouting(h, senderResource) {
+		return
+	}
+
+	routes := payload.rule.routes(*data, h, senderResource)
+	h.NotifyOffline(*data, routes.offline)
+
+	// muted frame is created once and shared by every muted recipient
+	var mutedData *[]byte
+
+	for _, target := range routes.targets {
+		if !target.muted {
+			target.conn.WriteToChannel(data)
+			continue
+		}
+
+		if mutedData == nil {
+			flagged := flagMuted(*data)
+			mutedData = &flagged
+		}
+		target.conn.WriteToChannel(mutedData)
+	}
+
+	// counts are sent after the payload so that clients have the message when the badge changes
+	for _, user := range routes.unread {
+		incrementUnread(h, user, routes.sender)
+	}
+
+	// payload is accepted even if no recipient is connected or When is false, webhooks let the backend store it
+	if !routes.rejected {
+		h.NotifyWebhooks(*data)
+	}
+}
+
+// routes are the connections and users a payload is sent to
+type routes struct {
+	sender string
+
+	// rejected is set when the payload is invalid for the rule or every user it named has blocked the sender
+	// other resources of the sender still get a blocked payload so that the sender doesn't learn about the block
+	// it is not set when named users are offline or When is false, as the payload is still accepted
+	rejected bool
+
+	// targets are connections receiving the payload keyed by complete user
+	targets map[string]target
+
+	// offline are users to notify who have no connected resource
+	offline []string
+
+	// unread are users whose unread count of the conversation with the sender goes up
+	unread []string
+}
+
+// recipient is a user in the user fields of the rule
+type recipient struct {
+	// notify is set when the user gets push notification while offline
+	notify bool
+
+	// unread is set when the payload counts as an unread message
+	unread bool
+}
+
+// target is a connection receiving the payload
+type target struct {
+	conn client.Client
+
+	// muted is set when the user has muted the conversation with the sender
+	muted bool
+}
+
+// routes returns connections and users the payload is sent to
+func (rule *RoutingRule) routes(data []byte, h Hub, senderResource string) routes {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return routes{rejected: true}
+	}
+
+	var sender string
+	if err := json.Unmarshal(fields["from"], &sender); err != nil || sender == "" {
+		return routes{rejected: true}
+	}
+
+	blocks := h.BlockStore()
+
+	// same user can be in more than one field, like a mention who is also the reply target
+	users := make(map[string]recipient)
+	blocked := false
+	for _, field := range rule.Users {
+		blockable := slices.Contains(rule.Blockable, field)
+		notify := slices.Contains(rule.Notify, field)
+		unread := slices.Contains(rule.Unread, field)
+		for _, user := range fieldValues(fields[field]) {
+			if user == sender {
+				if rule.DropSelfAddressed {
+					return routes{rejected: true}
+				}
+				continue
+			}
+			if blockable && blocks.IsBlocked(user, sender) {
+				blocked = true
+				continue
+			}
+			users[user] = recipient{
+				notify: users[user].notify || notify,
+				unread: users[user].unread || unread,
+			}
+		}
+	}
+
+	r := routes{sender: sender, targets: make(map[string]target), rejected: blocked && len(users) == 0}
+	if rule.When == "" || fieldIsTrue(fields[rule.When]) {
+		for user, flags := range users {
+			muted := rule.Mutable && blocks.IsMuted(user, sender)
+
+			connected := h.GetAllConnectedClients(user)
+			if len(connected) == 0 && flags.notify && !muted {
+				r.offline = append(r.offline, user)
+			}
+			if flags.unread {
+				r.unread = append(r.unread, user)
+			}
+			for res, conn := range connected {
+				r.targets[utils.CreateUserFromUsernameAndResource(user, res)] = target{conn: conn, muted: muted}
+			}
+		}
+
+		for _, field := range rule.Nodes {
+			for _, nodeId := range fieldValues(fields[field]) {
+				for subscriber := range h.GetSubscribers(nodeId) {
+					conn := h.GetIndividualClient(subscriber)
+					if conn == nil {
+						h.Unsubscribe(nodeId, subscriber)
+						continue
+					}
+					r.targets[subscriber] = target{conn: conn}
+				}
+			}
+		}
+	}
+
+	if rule.EchoToSender {
+		for res, conn := range h.GetAllConnectedClients(sender) {
+			r.targets[utils.CreateUserFromUsernameAndResource(sender, res)] = target{conn: conn}
+		}
+	}
+
+	delete(r.targets, utils.CreateUserFromUsernameAndResource(sender, senderResource))
+	return r
+}
+
+// flagMuted returns copy of the payload with "muted" as its first member
+// metadata stays the last member so that it can still be stripped for older clients
+func flagMuted(data []byte) []byte {
+	if len(data) < 2 || data[0] != '{' {
+		return data
+	}
+
+	// muted sent by the client is removed so that it can't override the flag
+	// names are compared decoded, so an escaped name is removed as well
+	data = removeMember(data, "muted")
+
+	flagged := make([]byte, 0, len(data)+len(mutedMember))
+	flagged = append(flagged, mutedMember...)
+	if len(bytes.TrimSpace(data[1:])) > 1 {
+		flagged = append(flagged, ',')
+	}
+	return append(flagged, data[1:]...)
+}
+
+// member is a top level member of json object, start is the comma before it or its name for the first member
+type member struct {
+	name       string
+	start      int
+	valueStart int
+	end        int
+}
+
+// objectMembers returns members of json object in their order, ok is false if data is not an object
+// offsets let members be removed or replaced without decoding the payload, so other members keep their bytes and order
+func objectMembers(data []byte) (members []member, closing int, ok bool) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, 0, false
+	}
+
+	for decoder.More() {
+		start := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, 0, false
+		}
+		name, _ := token.(string)
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, 0, false
+		}
+		end := int(decoder.InputOffset())
+
+		members = append(members, member{
+			name:       name,
+			start:      start,
+			valueStart: end - len(value),
+			end:        end,
+		})
+	}
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim('}') {
+		return nil, 0, false
+	}
+	return members, int(decoder.InputOffset()) - 1, true
+}
+
+// removeMember returns payload without the member, other members are kept as they are
+func removeMember(data []byte, name string) []byte {
+	members, _, ok := objectMembers(data)
+	if !ok || !slices.ContainsFunc(members, func(m member) bool { return m.name == name }) {
+		return data
+	}
+
+	removed := make([]byte, 0, len(data))
+	removed = append(removed, data[:members[0].start]...)
+	kept := 0
+	for index, m := range members {
+		if m.name == name {
+			continue
+		}
+
+		text := data[m.start:m.end]
+		if index > 0 {
+			// comma before the member
+			text = text[1:]
+		}
+		if kept > 0 {
+			removed = append(removed, ',')
+		}
+		removed = append(removed, text...)
+		kept++
+	}
+
+	return append(removed, data[members[len(members)-1].end:]...)
+}
+
+// replaceMember returns payload with the string member set to value, other members are kept as they are
+// missing member is added before metadata so that metadata stays the last member
+func replaceMember(data []byte, name, value string) []byte {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return data
+	}
+
+	members, closing, ok := objectMembers(data)
+	if !ok {
+		return data
+	}
+
+	replaced := slices.Clone(data)
+	found := false
+	for index := len(members) - 1; index >= 0; index-- {
+		m := members[index]
+		if m.name == name {
+			replaced = slices.Replace(replaced, m.valueStart, m.end, encoded...)
+			found = true
+		}
+	}
+	if found {
+		return replaced
+	}
+
+	key, _ := json.Marshal(name)
+	added := slices.Concat([]byte(","), key, []byte(":"), encoded)
+	at := closing
+	switch {
+	case len(members) == 0:
+		added = added[1:]
+	case members[len(members)-1].name == "meta":
+		at = members[len(members)-1].start
+		if len(members) == 1 {
+			added = append(added[1:], ',')
+		}
+	}
+
+	return slices.Insert(replaced, at, added...)
+}
+
+// sendToOtherResources sends payload to every resource of the user other than the sender resource
+func sendToOtherResources(data *[]byte, h Hub, user, senderResource string) {
+	for res, conn := range h.GetAllConnectedClients(user) {
+		if res != senderResource {
+			conn.WriteToChannel(data)
+		}
+	}
+}
+
+// fieldValues returns non empty strings of a string or list of strings field
+func fieldValues(field json.RawMessage) []string {
+	var value string
+	if err := json.Unmarshal(field, &value); err == nil {
+		if value == "" {
+			return nil
+		}
+		return []string{value}
+	}
+
+	var values []string
+	_ = json.Unmarshal(field, &values)
+
+	nonEmpty := values[:0]
+	for _, value := range values {
+		if value != "" {
+			nonEmpty = append(nonEmpty, value)
+		}
+	}
+	return nonEmpty
+}
+
+func fieldIsTrue(field json.RawMessage) bool {
+	var value bool
+	_ = json.Unmarshal(field, &value)
+
+	return value
+}
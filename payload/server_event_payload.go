@@ -0,0 +1,38 @@
+package payload
+
+import (
+	"doki.co.in/doki_real_time_service/utils"
+	"time"
+)
+
+const (
+	serverShutdownType = payloadType("server_shutdown")
+)
+
+// only server sends this
+// serverShutdown is sent to every connected client before the server goes down
+// ReconnectAfter is the hint in milliseconds after which client should try to reconnect
+type serverShutdown struct {
+	Type           payloadType `json:"type"`
+	To             string      `json:"to"`
+	ReconnectAfter int64       `json:"reconnectAfter"`
+}
+
+func (payload *serverShutdown) SendPayload(data *[]byte, h Hub, userResource string) {
+	completeUser := utils.CreateUserFromUsernameAndResource(payload.To, userResource)
+
+	conn := h.GetIndividualClient(completeUser)
+	if conn != nil {
+		conn.WriteToChannel(data)
+	}
+}
+
+// CreateServerShutdownPayload creates shutdown payload for the user
+// reconnectAfter is the duration after which user should try reconnecting
+func CreateServerShutdownPayload(to string, reconnectAfter time.Duration) Payload {
+	return &serverShutdown{
+		Type:           serverShutdownType,
+		To:             to,
+		ReconnectAfter: reconnectAfter.Milliseconds(),
+	}
+}
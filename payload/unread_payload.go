@@ -0,0 +1,124 @@
+package payload
+
+import (
+	"doki.co.in/doki_real_time_service/unread"
+	"doki.co.in/doki_real_time_service/utils"
+	"log"
+	"time"
+)
+
+// CapabilityUnread is declared by clients which get unread_update and unread_counts frames
+const CapabilityUnread = "unread"
+
+const (
+	readReceiptType  = payloadType("read_receipt")
+	unreadUpdateType = payloadType("unread_update")
+	unreadSyncType   = payloadType("unread_sync")
+	unreadCountsType = payloadType("unread_counts")
+)
+
+// readReceipt is sent when the user reads the conversation with the user in "to" field
+// it resets unread count of the conversation on every resource of the sender
+// and tells the other user which message was read
+type readReceipt struct {
+	Type      payloadType `json:"type" validate:"required"`
+	From      string      `json:"from" validate:"required"`
+	To        string      `json:"to" validate:"required"`
+	MessageId string      `json:"messageId" validate:"required"`
+	ReadAt    time.Time   `json:"readAt" validate:"required"`
+}
+
+func (payload *readReceipt) beforeRouting(h Hub, _ string) bool {
+	counts, reset, err := h.Unread().Reset(payload.From, payload.To)
+	if err != nil {
+		log.Printf("error resetting unread count: %v\n", err)
+		return true
+	}
+
+	if reset {
+		sendUnreadUpdate(h, payload.From, counts)
+	}
+	return true
+}
+
+// only server sends this
+// unreadUpdate is the unread count of a conversation and total of the user, sent to every resource of the user
+type unreadUpdate struct {
+	Type payloadType `json:"type"`
+	To   string      `json:"to"`
+	unread.Counts
+}
+
+func (payload *unreadUpdate) SendPayload(data *[]byte, h Hub, _ string) {
+	for _, conn := range h.GetAllConnectedClients(payload.To) {
+		if conn.HasCapability(CapabilityUnread) {
+			conn.WriteToChannel(data)
+		}
+	}
+}
+
+// incrementUnread counts a message delivered to the user in the conversation
+func incrementUnread(h Hub, user, conversation string) {
+	counts, err := h.Unread().Increment(user, conversation)
+	if err != nil {
+		log.Printf("error incrementing unread count: %v\n", err)
+		return
+	}
+
+	sendUnreadUpdate(h, user, counts)
+}
+
+func sendUnreadUpdate(h Hub, user string, counts unread.Counts) {
+	update := &unreadUpdate{
+		Type:   unreadUpdateType,
+		To:     user,
+		Counts: counts,
+	}
+
+	data := utils.PayloadToJson(update)
+	if data != nil {
+		update.SendPayload(data, h, "")
+	}
+}
+
+// unreadSync asks for unread counts of every conversation, like after reconnecting
+type unreadSync struct {
+	Type payloadType `json:"type" validate:"required"`
+	From string      `json:"from" validate:"required"`
+}
+
+func (payload *unreadSync) SendPayload(_ *[]byte, h Hub, senderResource string) {
+	conversations := h.Unread().Unread(payload.From)
+
+	counts := &unreadCounts{
+		Type:          unreadCountsType,
+		To:            payload.From,
+		Conversations: conversations,
+	}
+	for _, count := range conversations {
+		counts.Total += count
+	}
+
+	data := utils.PayloadToJson(counts)
+	if data != nil {
+		counts.SendPayload(data, h, senderResource)
+	}
+}
+
+// only server sends this
+// unreadCounts is the reply to unread_sync with conversations which have unread messages
+type unreadCounts struct {
+	Type          payloadType    `json:"type"`
+	To            string         `json:"to"`
+	Conversations map[string]int `json:"conversations"`
+	Total         int            `json:"total"`
+}
+
+func (payload *unreadCounts) SendPayload(data *[]byte, h Hub, userResource string) {
+	completeUser := utils.CreateUserFromUsernameAndResource(payload.To, userResource)
+
+	conn := h.GetIndividualClient(completeUser)
+	if conn != nil {
+		conn.WriteToChannel(data)
+	}
+}
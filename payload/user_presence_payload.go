@@ -16,10 +16,14 @@ type userPresenceSubscription struct {
 	Subscribe bool        `json:"subscribe"`
 }
 
-func (payload *userPresenceSubscription) SendPayload(_ *[]byte, h hub, senderResource string) {
+func (payload *userPresenceSubscription) SendPayload(_ *[]byte, h Hub, senderResource string) {
 	completeUser := utils.CreateUserFromUsernameAndResource(payload.From, senderResource)
 
 	if payload.Subscribe {
+		// user who has blocked the sender doesn't share presence
+		if h.BlockStore().IsBlocked(payload.User, payload.From) {
+			return
+		}
 		h.Subscribe(payload.User, completeUser, true)
 	} else {
 		h.Unsubscribe(payload.User, completeUser)
@@ -34,7 +38,7 @@ type userPresenceInfoPayload struct {
 	Online bool        `json:"online"`
 }
 
-func (payload *userPresenceInfoPayload) SendPayload(data *[]byte, h hub, userResource string) {
+func (payload *userPresenceInfoPayload) SendPayload(data *[]byte, h Hub, userResource string) {
 	completeUser := utils.CreateUserFromUsernameAndResource(payload.To, userResource)
 
 	conn := h.GetIndividualClient(completeUser)
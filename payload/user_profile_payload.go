@@ -19,17 +19,6 @@ type userUpdateProfile struct {
 	Bio            string      `json:"bio"`
 }
 
-func (payload *userUpdateProfile) SendPayload(data *[]byte, h hub, senderResource string) {
-	user := payload.From
-
-	userConnectedClients := h.GetAllConnectedClients(user)
-	for res, conn := range userConnectedClients {
-		if res != senderResource {
-			conn.WriteToChannel(data)
-		}
-	}
-}
-
 type userCreateRootNode struct {
 	Type        payloadType `json:"type" validate:"required"`
 	From        string      `json:"from" validate:"required"`
@@ -38,26 +27,6 @@ type userCreateRootNode struct {
 	UsersTagged []string    `json:"usersTagged"`
 }
 
-func (payload *userCreateRootNode) SendPayload(data *[]byte, h hub, senderResource string) {
-	user := payload.From
-
-	userConnectedClients := h.GetAllConnectedClients(user)
-	for res, conn := range userConnectedClients {
-		if res != senderResource {
-			conn.WriteToChannel(data)
-		}
-	}
-
-	for _, user := range payload.UsersTagged {
-		userConnectedClients := h.GetAllConnectedClients(user)
-		for res, conn := range userConnectedClients {
-			if res != senderResource {
-				conn.WriteToChannel(data)
-			}
-		}
-	}
-}
-
 type parentNode struct {
 	NodeId   string `json:"nodeId" validate:"required"`
 	NodeType string `json:"nodeType" validate:"required"`
@@ -75,26 +44,6 @@ type userNodeLikeAction struct {
 	Parents      []parentNode `json:"parents,string" validate:"required"`
 }
 
-func (payload *userNodeLikeAction) SendPayload(data *[]byte, h hub, senderResource string) {
-	nodeOwner := payload.To
-	actionBy := payload.From
-
-	// this prevents sending messages twice when user interacts with self nodes
-	if nodeOwner != actionBy {
-		nodeOwnerConnectedClients := h.GetAllConnectedClients(nodeOwner)
-		for _, conn := range nodeOwnerConnectedClients {
-			conn.WriteToChannel(data)
-		}
-	}
-
-	actionByConnectedClients := h.GetAllConnectedClients(actionBy)
-	for res, conn := range actionByConnectedClients {
-		if res != senderResource {
-			conn.WriteToChannel(data)
-		}
-	}
-}
-
 type userCreateSecondaryNode struct {
 	Type                 payloadType  `json:"type" validate:"required"`
 	From                 string       `json:"from" validate:"required"`
@@ -105,40 +54,3 @@ type userCreateSecondaryNode struct {
 	ReplyOnNodeCreatedBy string       `json:"replyOnNodeCreatedBy"`
 	Parents              []parentNode `json:"parents,string" validate:"required"`
 }
-
-func (payload *userCreateSecondaryNode) SendPayload(data *[]byte, h hub, senderResource string) {
-	nodeCreator := payload.From
-	parentNodeCreator := payload.To
-
-	if nodeCreator != parentNodeCreator {
-		parentNodeCreatorConnectedClients := h.GetAllConnectedClients(parentNodeCreator)
-		for _, conn := range parentNodeCreatorConnectedClients {
-			conn.WriteToChannel(data)
-		}
-	}
-
-	nodeCreatorConnectedClients := h.GetAllConnectedClients(nodeCreator)
-	for res, conn := range nodeCreatorConnectedClients {
-		if res != senderResource {
-			conn.WriteToChannel(data)
-		}
-	}
-
-	for _, userMentioned := range payload.Mentions {
-		if userMentioned == nodeCreator || userMentioned == parentNodeCreator {
-			continue
-		}
-
-		userMentionedAllConnectedClients := h.GetAllConnectedClients(userMentioned)
-		for _, conn := range userMentionedAllConnectedClients {
-			conn.WriteToChannel(data)
-		}
-	}
-
-	if payload.ReplyOnNodeCreatedBy != nodeCreator && payload.ReplyOnNodeCreatedBy != parentNodeCreator {
-		replyOnNodeCreatedByConnectedClients := h.GetAllConnectedClients(payload.ReplyOnNodeCreatedBy)
-		for _, conn := range replyOnNodeCreatedByConnectedClients {
-			conn.WriteToChannel(data)
-		}
-	}
-}
\ No newline at end of file
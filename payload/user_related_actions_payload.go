@@ -1,6 +1,9 @@
 package payload
 
-import "time"
+import (
+	"log"
+	"time"
+)
 
 const (
 	userSendFriendRequestType     = payloadType("user_send_friend_request")
@@ -16,26 +19,15 @@ type userSendFriendRequest struct {
 	AddedOn     time.Time   `json:"addedOn" validate:"required"`
 }
 
-func (req *userSendFriendRequest) SendPayload(data *[]byte, h hub, senderResource string) {
-	userSendingRequest := req.From
-	userToSendRequest := req.To
-
-	if userSendingRequest == userToSendRequest {
-		// invalid state
-		return
-	}
-
-	userSendingRequestConnectedClients := h.GetAllConnectedClients(userSendingRequest)
-	for res, conn := range userSendingRequestConnectedClients {
-		if res != senderResource {
-			conn.WriteToChannel(data)
+// beforeRouting records the friend request so that accepting it makes the users friends
+func (req *userSendFriendRequest) beforeRouting(h Hub, _ string) bool {
+	if req.From != req.To && !h.BlockStore().IsBlocked(req.To, req.From) {
+		if err := h.Relations().AddFriendRequest(req.From, req.To); err != nil {
+			log.Printf("error saving friend request: %v\n", err)
 		}
 	}
 
-	userToSendRequestConnectedClients := h.GetAllConnectedClients(userToSendRequest)
-	for _, conn := range userToSendRequestConnectedClients {
-		conn.WriteToChannel(data)
-	}
+	return true
 }
 
 type userAcceptFriendRequest struct {
@@ -46,26 +38,15 @@ type userAcceptFriendRequest struct {
 	AddedOn     time.Time   `json:"addedOn" validate:"required"`
 }
 
-func (req *userAcceptFriendRequest) SendPayload(data *[]byte, h hub, senderResource string) {
-	userAcceptingRequest := req.From
-	userToAcceptRequest := req.To
-
-	if userAcceptingRequest == userToAcceptRequest {
-		// invalid state
-		return
-	}
-
-	userAcceptingRequestConnectedClients := h.GetAllConnectedClients(userAcceptingRequest)
-	for res, conn := range userAcceptingRequestConnectedClients {
-		if res != senderResource {
-			conn.WriteToChannel(data)
+// beforeRouting makes the users friends if the accepted friend request was sent through the service
+func (req *userAcceptFriendRequest) beforeRouting(h Hub, _ string) bool {
+	if req.From != req.To {
+		if _, err := h.Relations().AcceptFriendRequest(req.From, req.To); err != nil {
+			log.Printf("error saving friend relation: %v\n", err)
 		}
 	}
 
-	userToAcceptRequestConnectedClients := h.GetAllConnectedClients(userToAcceptRequest)
-	for _, conn := range userToAcceptRequestConnectedClients {
-		conn.WriteToChannel(data)
-	}
+	return true
 }
 
 type userRemovesFriendRelation struct {
@@ -74,24 +55,10 @@ type userRemovesFriendRelation struct {
 	To   string      `json:"to" validate:"required"`
 }
 
-func (req *userRemovesFriendRelation) SendPayload(data *[]byte, h hub, senderResource string) {
-	from := req.From
-	to := req.To
-
-	if from == to {
-		// invalid state
-		return
+func (req *userRemovesFriendRelation) beforeRouting(h Hub, _ string) bool {
+	if err := h.Relations().RemoveFriends(req.From, req.To); err != nil {
+		log.Printf("error removing friend relation: %v\n", err)
 	}
 
-	fromConnectedClients := h.GetAllConnectedClients(from)
-	for res, conn := range fromConnectedClients {
-		if res != senderResource {
-			conn.WriteToChannel(data)
-		}
-	}
-
-	toConnectedClients := h.GetAllConnectedClients(to)
-	for _, conn := range toConnectedClients {
-		conn.WriteToChannel(data)
-	}
-}
\ No newline at end of file
+	return true
+}
@@ -0,0 +1,167 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ApnsEndpoint is the production apple push notification service
+const ApnsEndpoint = "https://api.push.apple.com"
+
+// apnsTokenLifetime is how long a provider token is reused, apple rejects tokens older than an hour
+const apnsTokenLifetime = 50 * time.Minute
+
+// apnsCollapseIdLimit is the max length of apns-collapse-id header in bytes
+const apnsCollapseIdLimit = 64
+
+// ApnsKey is the token based authentication key from apple developer account
+type ApnsKey struct {
+	KeyId  string
+	TeamId string
+	Key    *ecdsa.PrivateKey
+}
+
+// ParseApnsKey parses the .p8 key file downloaded from apple developer account
+func ParseApnsKey(keyId, teamId string, pem []byte) (ApnsKey, error) {
+	key, err := jwt.ParseECPrivateKeyFromPEM(pem)
+	if err != nil {
+		return ApnsKey{}, fmt.Errorf("error parsing apns key: %w", err)
+	}
+
+	return ApnsKey{KeyId: keyId, TeamId: teamId, Key: key}, nil
+}
+
+// apnsNotifier sends notifications through apple push notification service
+type apnsNotifier struct {
+	client   *http.Client
+	endpoint string
+	topic    string
+	key      ApnsKey
+
+	// provider token is signed once and reused until it is too old
+	tokenLock sync.Mutex
+	token     string
+	signedAt  time.Time
+}
+
+// NewApnsNotifier returns notifier for the app bundle id as topic, empty endpoint is ApnsEndpoint
+func NewApnsNotifier(client *http.Client, endpoint, topic string, key ApnsKey) PushNotifier {
+	if endpoint == "" {
+		endpoint = ApnsEndpoint
+	}
+
+	return &apnsNotifier{
+		client:   client,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		topic:    topic,
+		key:      key,
+	}
+}
+
+type apnsAlert struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+}
+
+type apnsAps struct {
+	Alert apnsAlert `json:"alert"`
+	Sound string    `json:"sound"`
+}
+
+// apnsError is the error response, Unregistered and BadDeviceToken mean token is no longer valid
+type apnsError struct {
+	Reason string `json:"reason"`
+}
+
+// providerToken returns the signed provider token
+func (n *apnsNotifier) providerToken() (string, error) {
+	n.tokenLock.Lock()
+	defer n.tokenLock.Unlock()
+
+	if n.token != "" && time.Since(n.signedAt) < apnsTokenLifetime {
+		return n.token, nil
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": n.key.TeamId,
+		"iat": now.Unix(),
+	})
+	token.Header["kid"] = n.key.KeyId
+
+	signed, err := token.SignedString(n.key.Key)
+	if err != nil {
+		return "", fmt.Errorf("error signing apns provider token: %w", err)
+	}
+
+	n.token = signed
+	n.signedAt = now
+	return signed, nil
+}
+
+func (n *apnsNotifier) Notify(ctx context.Context, token string, notification Notification) error {
+	// custom data is sent next to aps dictionary
+	payload := make(map[string]any, len(notification.Data)+1)
+	for key, value := range notification.Data {
+		payload[key] = value
+	}
+	payload["aps"] = apnsAps{
+		Alert: apnsAlert{Title: notification.Title, Body: notification.Body},
+		Sound: "default",
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	providerToken, err := n.providerToken()
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, n.endpoint+"/3/device/"+token, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "bearer "+providerToken)
+	request.Header.Set("apns-topic", n.topic)
+	request.Header.Set("apns-push-type", "alert")
+	request.Header.Set("apns-priority", "10")
+	if collapseId := notification.CollapseKey; collapseId != "" {
+		if len(collapseId) > apnsCollapseIdLimit {
+			collapseId = strings.ToValidUTF8(collapseId[:apnsCollapseIdLimit], "")
+		}
+		request.Header.Set("apns-collapse-id", collapseId)
+	}
+
+	response, err := n.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	if response.StatusCode == http.StatusGone || response.StatusCode == http.StatusBadRequest {
+		var apnsErr apnsError
+		_ = json.NewDecoder(response.Body).Decode(&apnsErr)
+		if response.StatusCode == http.StatusGone || apnsErr.Reason == "BadDeviceToken" {
+			return ErrUnregistered
+		}
+		return fmt.Errorf("push notification failed with status %d: %s", response.StatusCode, apnsErr.Reason)
+	}
+
+	return statusError(response)
+}
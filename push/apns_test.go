@@ -0,0 +1,90 @@
+package push
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createApnsKey returns key parsed from a generated .p8 file
+func createApnsKey(t *testing.T) ApnsKey {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+
+	key, err := ParseApnsKey("KEY123", "TEAM123", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	require.NoError(t, err)
+	return key
+}
+
+func TestApnsNotify(t *testing.T) {
+	key := createApnsKey(t)
+
+	var header http.Header
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/3/device/device", r.URL.Path)
+		header = r.Header.Clone()
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+	}))
+	defer server.Close()
+
+	notifier := NewApnsNotifier(server.Client(), server.URL, "in.co.doki", key)
+	err := notifier.Notify(context.Background(), "device", Notification{
+		Title:       "alice",
+		Body:        "hi",
+		CollapseKey: "chat_" + strings.Repeat("a", 100),
+		Data:        map[string]string{"type": "chat_message", "from": "alice"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "in.co.doki", header.Get("apns-topic"))
+	assert.Equal(t, "alert", header.Get("apns-push-type"))
+	assert.Len(t, header.Get("apns-collapse-id"), apnsCollapseIdLimit)
+	assert.Equal(t, "chat_message", body["type"])
+	assert.Equal(t, map[string]any{"title": "alice", "body": "hi"}, body["aps"].(map[string]any)["alert"])
+
+	// provider token is signed by the key with key id and team id
+	providerToken, ok := strings.CutPrefix(header.Get("Authorization"), "bearer ")
+	require.True(t, ok)
+	token, err := jwt.Parse(providerToken, func(token *jwt.Token) (any, error) {
+		return &key.Key.PublicKey, nil
+	}, jwt.WithValidMethods([]string{"ES256"}))
+	require.NoError(t, err)
+	assert.Equal(t, "KEY123", token.Header["kid"])
+	issuer, _ := token.Claims.GetIssuer()
+	assert.Equal(t, "TEAM123", issuer)
+}
+
+func TestApnsUnregistered(t *testing.T) {
+	key := createApnsKey(t)
+
+	for status, reason := range map[int]string{
+		http.StatusGone:       "Unregistered",
+		http.StatusBadRequest: "BadDeviceToken",
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"reason":"` + reason + `"}`))
+		}))
+
+		notifier := NewApnsNotifier(server.Client(), server.URL, "in.co.doki", key)
+		err := notifier.Notify(context.Background(), "device", Notification{Title: "alice"})
+		assert.ErrorIs(t, err, ErrUnregistered, reason)
+		server.Close()
+	}
+}
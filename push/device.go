@@ -0,0 +1,68 @@
+package push
+
+import (
+	"sync"
+)
+
+// Device is a push notification token of one of the user devices
+type Device struct {
+	Token    string `json:"token"`
+	Platform string `json:"platform"`
+}
+
+// DeviceStore keeps device tokens of every user
+type DeviceStore interface {
+	Register(user string, device Device) error
+
+	Unregister(user, token string) error
+
+	Devices(user string) []Device
+}
+
+// memoryDeviceStore keeps device tokens in memory, they are lost on restart
+type memoryDeviceStore struct {
+	sync.RWMutex
+
+	// devices is user -> token -> device
+	devices map[string]map[string]Device
+}
+
+// NewMemoryDeviceStore returns store which keeps device tokens in memory only
+func NewMemoryDeviceStore() DeviceStore {
+	return &memoryDeviceStore{
+		devices: make(map[string]map[string]Device),
+	}
+}
+
+func (s *memoryDeviceStore) Register(user string, device Device) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.devices[user] == nil {
+		s.devices[user] = make(map[string]Device)
+	}
+	s.devices[user][device.Token] = device
+	return nil
+}
+
+func (s *memoryDeviceStore) Unregister(user, token string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.devices[user], token)
+	if len(s.devices[user]) == 0 {
+		delete(s.devices, user)
+	}
+	return nil
+}
+
+func (s *memoryDeviceStore) Devices(user string) []Device {
+	s.RLock()
+	defer s.RUnlock()
+
+	devices := make([]Device, 0, len(s.devices[user]))
+	for _, device := range s.devices[user] {
+		devices = append(devices, device)
+	}
+	return devices
+}
@@ -0,0 +1,134 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// FcmEndpoint is the firebase cloud messaging http v1 api
+const FcmEndpoint = "https://fcm.googleapis.com"
+
+// TokenSource returns oauth2 access token used to call the api
+type TokenSource func(ctx context.Context) (string, error)
+
+// StaticToken returns the same access token every time
+// access tokens expire in an hour, so the service uses ServiceAccountToken
+func StaticToken(token string) TokenSource {
+	return func(context.Context) (string, error) {
+		return token, nil
+	}
+}
+
+// fcmNotifier sends notifications through firebase cloud messaging http v1 api
+type fcmNotifier struct {
+	client    *http.Client
+	endpoint  string
+	projectId string
+	token     TokenSource
+}
+
+// NewFcmNotifier returns notifier for the firebase project, empty endpoint is FcmEndpoint
+func NewFcmNotifier(client *http.Client, endpoint, projectId string, token TokenSource) PushNotifier {
+	if endpoint == "" {
+		endpoint = FcmEndpoint
+	}
+
+	return &fcmNotifier{
+		client:    client,
+		endpoint:  strings.TrimSuffix(endpoint, "/"),
+		projectId: projectId,
+		token:     token,
+	}
+}
+
+type fcmMessage struct {
+	Message fcmMessageBody `json:"message"`
+}
+
+type fcmMessageBody struct {
+	Token        string            `json:"token"`
+	Notification fcmNotification   `json:"notification"`
+	Data         map[string]string `json:"data,omitempty"`
+	Android      *fcmAndroid       `json:"android,omitempty"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+}
+
+type fcmAndroid struct {
+	CollapseKey string `json:"collapse_key,omitempty"`
+}
+
+// fcmError is the error response of the api, UNREGISTERED means token is no longer valid
+type fcmError struct {
+	Error struct {
+		Status  string `json:"status"`
+		Details []struct {
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+func (n *fcmNotifier) Notify(ctx context.Context, token string, notification Notification) error {
+	message := fcmMessage{
+		Message: fcmMessageBody{
+			Token: token,
+			Notification: fcmNotification{
+				Title: notification.Title,
+				Body:  notification.Body,
+			},
+			Data: notification.Data,
+		},
+	}
+	if notification.CollapseKey != "" {
+		message.Message.Android = &fcmAndroid{CollapseKey: notification.CollapseKey}
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	accessToken, err := n.token(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting fcm access token: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/v1/projects/%s/messages:send", n.endpoint, n.projectId)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer "+accessToken)
+
+	response, err := n.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	if response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusBadRequest {
+		var fcmErr fcmError
+		if json.NewDecoder(response.Body).Decode(&fcmErr) == nil {
+			for _, detail := range fcmErr.Error.Details {
+				if detail.ErrorCode == "UNREGISTERED" {
+					return ErrUnregistered
+				}
+			}
+		}
+		return fmt.Errorf("push notification failed with status %d: %s", response.StatusCode, fcmErr.Error.Status)
+	}
+
+	return statusError(response)
+}
@@ -0,0 +1,63 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFcmNotify(t *testing.T) {
+	var message fcmMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/v1/projects/doki/messages:send", r.URL.Path)
+		assert.Equal(t, "Bearer access", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&message))
+		_, _ = w.Write([]byte(`{"name":"projects/doki/messages/1"}`))
+	}))
+	defer server.Close()
+
+	notifier := NewFcmNotifier(server.Client(), server.URL, "doki", StaticToken("access"))
+	err := notifier.Notify(context.Background(), "device", Notification{
+		Title:       "alice",
+		Body:        "hi",
+		CollapseKey: "chat_alice",
+		Data:        map[string]string{"type": "chat_message"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "device", message.Message.Token)
+	assert.Equal(t, fcmNotification{Title: "alice", Body: "hi"}, message.Message.Notification)
+	assert.Equal(t, "chat_message", message.Message.Data["type"])
+	require.NotNil(t, message.Message.Android)
+	assert.Equal(t, "chat_alice", message.Message.Android.CollapseKey)
+}
+
+func TestFcmUnregistered(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":{"status":"NOT_FOUND","details":[{"errorCode":"UNREGISTERED"}]}}`))
+	}))
+	defer server.Close()
+
+	notifier := NewFcmNotifier(server.Client(), server.URL, "doki", StaticToken("access"))
+	err := notifier.Notify(context.Background(), "device", Notification{Title: "alice"})
+	assert.ErrorIs(t, err, ErrUnregistered)
+}
+
+func TestFcmServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	notifier := NewFcmNotifier(server.Client(), server.URL, "doki", StaticToken("access"))
+	err := notifier.Notify(context.Background(), "device", Notification{Title: "alice"})
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrUnregistered)
+}
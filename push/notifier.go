@@ -0,0 +1,75 @@
+package push
+
+import (
+	"context"
+	"doki.co.in/doki_real_time_service/config"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// platforms of the device tokens
+const (
+	PlatformFcm  = "fcm"
+	PlatformApns = "apns"
+)
+
+// ErrUnregistered is returned when the device token is no longer valid and should be removed
+var ErrUnregistered = errors.New("device token is unregistered")
+
+// Notification is shown on the device of an offline user
+// notifications with same collapse key replace each other on the device
+type Notification struct {
+	Title       string
+	Body        string
+	CollapseKey string
+	Data        map[string]string
+}
+
+// PushNotifier sends notifications to devices of a single platform
+type PushNotifier interface {
+	Notify(ctx context.Context, token string, notification Notification) error
+}
+
+// NewNotifiers returns notifiers of the platforms enabled in config by platform
+func NewNotifiers(pushConfig config.PushConfig) (map[string]PushNotifier, error) {
+	client := &http.Client{Timeout: time.Duration(pushConfig.Timeout)}
+	notifiers := make(map[string]PushNotifier)
+
+	if pushConfig.FcmProjectId != "" {
+		credentials, err := os.ReadFile(pushConfig.FcmCredentialsFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading fcm credentials file: %w", err)
+		}
+
+		account, err := ParseServiceAccount(credentials)
+		if err != nil {
+			return nil, err
+		}
+		notifiers[PlatformFcm] = NewFcmNotifier(client, pushConfig.FcmEndpoint, pushConfig.FcmProjectId, ServiceAccountToken(client, account))
+	}
+
+	if pushConfig.ApnsKeyFile != "" {
+		pem, err := os.ReadFile(pushConfig.ApnsKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading apns key file: %w", err)
+		}
+
+		key, err := ParseApnsKey(pushConfig.ApnsKeyId, pushConfig.ApnsTeamId, pem)
+		if err != nil {
+			return nil, err
+		}
+		notifiers[PlatformApns] = NewApnsNotifier(client, pushConfig.ApnsEndpoint, pushConfig.ApnsTopic, key)
+	}
+
+	return notifiers, nil
+}
+
+// statusError returns error for the unsuccessful response, body is kept short as it is only logged
+func statusError(response *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+	return fmt.Errorf("push notification failed with status %d: %s", response.StatusCode, body)
+}
@@ -0,0 +1,119 @@
+package push
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fcmScope allows sending messages through firebase cloud messaging
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// tokenRefreshMargin is how long before it expires access token is refreshed
+const tokenRefreshMargin = 5 * time.Minute
+
+// ServiceAccount is google service account allowed to send messages of the firebase project
+type ServiceAccount struct {
+	ClientEmail string
+	TokenUri    string
+	Key         *rsa.PrivateKey
+}
+
+// ParseServiceAccount parses the json key file downloaded from google cloud console
+func ParseServiceAccount(data []byte) (ServiceAccount, error) {
+	var file struct {
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+		TokenUri    string `json:"token_uri"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return ServiceAccount{}, fmt.Errorf("error parsing service account: %w", err)
+	}
+	if file.ClientEmail == "" || file.TokenUri == "" {
+		return ServiceAccount{}, fmt.Errorf("service account has no client_email or token_uri")
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(file.PrivateKey))
+	if err != nil {
+		return ServiceAccount{}, fmt.Errorf("error parsing service account key: %w", err)
+	}
+
+	return ServiceAccount{ClientEmail: file.ClientEmail, TokenUri: file.TokenUri, Key: key}, nil
+}
+
+// serviceAccountToken exchanges signed assertion of the service account for access token
+type serviceAccountToken struct {
+	client  *http.Client
+	account ServiceAccount
+
+	// access token is reused until it is about to expire
+	lock        sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// ServiceAccountToken returns access tokens of the service account, refreshed before they expire
+func ServiceAccountToken(client *http.Client, account ServiceAccount) TokenSource {
+	source := &serviceAccountToken{client: client, account: account}
+	return source.token
+}
+
+func (s *serviceAccountToken) token(ctx context.Context) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	if s.accessToken != "" && now.Before(s.expiresAt.Add(-tokenRefreshMargin)) {
+		return s.accessToken, nil
+	}
+
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   s.account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   s.account.TokenUri,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(s.account.Key)
+	if err != nil {
+		return "", fmt.Errorf("error signing service account assertion: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.account.TokenUri, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("error getting access token: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error getting access token: %w", statusError(response))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&token); err != nil || token.AccessToken == "" {
+		return "", fmt.Errorf("error parsing access token response: %v", err)
+	}
+
+	s.accessToken = token.AccessToken
+	s.expiresAt = now.Add(time.Duration(token.ExpiresIn) * time.Second)
+	return s.accessToken, nil
+}
@@ -0,0 +1,72 @@
+package push
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"github.com/golang-jwt/jwt/v5"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceAccountToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var expiresIn, requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.FormValue("grant_type"))
+
+		assertion, err := jwt.Parse(r.FormValue("assertion"), func(*jwt.Token) (any, error) {
+			return &key.PublicKey, nil
+		}, jwt.WithValidMethods([]string{"RS256"}), jwt.WithAudience("http://"+r.Host+"/token"))
+		require.NoError(t, err)
+		claims := assertion.Claims.(jwt.MapClaims)
+		assert.Equal(t, "push@doki.iam.gserviceaccount.com", claims["iss"])
+		assert.Equal(t, fcmScope, claims["scope"])
+
+		_, _ = w.Write([]byte(`{"access_token":"access-` + strconv.Itoa(requests) + `","expires_in":` + strconv.Itoa(expiresIn) + `,"token_type":"Bearer"}`))
+	}))
+	defer server.Close()
+
+	keyFile, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "push@doki.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: mustMarshalPkcs8(t, key)})),
+		"token_uri":    server.URL + "/token",
+	})
+	require.NoError(t, err)
+	account, err := ParseServiceAccount(keyFile)
+	require.NoError(t, err)
+
+	token := ServiceAccountToken(server.Client(), account)
+
+	// token which expires within the refresh margin is not reused
+	expiresIn = 60
+	accessToken, err := token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "access-1", accessToken)
+
+	expiresIn = 3600
+	for range 2 {
+		accessToken, err = token(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "access-2", accessToken)
+	}
+	assert.Equal(t, 2, requests)
+}
+
+func mustMarshalPkcs8(t *testing.T, key *rsa.PrivateKey) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return der
+}
@@ -0,0 +1,141 @@
+package push
+
+import (
+	"doki.co.in/doki_real_time_service/config"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// maxBodyLength keeps notification well within payload limits of fcm and apns
+const maxBodyLength = 240
+
+// DefaultTemplates are notifications of chat messages, friend requests, mentions and likes
+// templates use fields of the json payload, like {{.from}}
+var DefaultTemplates = map[string]config.PushTemplate{
+	"chat_message": {
+		Title:       "{{.from}}",
+		Body:        "{{.body}}",
+		CollapseKey: "chat_{{.from}}",
+	},
+	"message_request": {
+		Title:       "{{.from}}",
+		Body:        "{{.from}} wants to send you a message",
+		CollapseKey: "message_request_{{.from}}",
+	},
+	"user_send_friend_request": {
+		Title:       "New friend request",
+		Body:        "{{.from}} sent you a friend request",
+		CollapseKey: "friend_request_{{.from}}",
+	},
+	"user_create_secondary_node": {
+		Title:       "New mention",
+		Body:        "{{.from}} mentioned you",
+		CollapseKey: "mention_{{.nodeId}}",
+	},
+	"user_node_like_action": {
+		Title:       "New like",
+		Body:        "{{.from}} liked your {{.nodeType}}",
+		CollapseKey: "like_{{.nodeId}}",
+		When:        "isLike",
+	},
+}
+
+// Template creates notification of a payload type
+type Template struct {
+	title       *template.Template
+	body        *template.Template
+	collapseKey *template.Template
+
+	// when is a boolean field of the payload, notification is sent only when it is true
+	when string
+}
+
+// Templates are notification templates by payload type
+type Templates map[string]*Template
+
+// NewTemplates parses default templates replaced by the configured ones
+// configured template with empty title and body disables notifications of the type
+func NewTemplates(configured map[string]config.PushTemplate) (Templates, error) {
+	texts := make(map[string]config.PushTemplate, len(DefaultTemplates)+len(configured))
+	for payloadType, text := range DefaultTemplates {
+		texts[payloadType] = text
+	}
+	for payloadType, text := range configured {
+		texts[payloadType] = text
+	}
+
+	templates := make(Templates, len(texts))
+	for payloadType, text := range texts {
+		if text.Title == "" && text.Body == "" {
+			continue
+		}
+
+		t := &Template{when: text.When}
+		var err error
+		if t.title, err = parse(payloadType, "title", text.Title); err != nil {
+			return nil, err
+		}
+		if t.body, err = parse(payloadType, "body", text.Body); err != nil {
+			return nil, err
+		}
+		if t.collapseKey, err = parse(payloadType, "collapseKey", text.CollapseKey); err != nil {
+			return nil, err
+		}
+		templates[payloadType] = t
+	}
+
+	return templates, nil
+}
+
+func parse(payloadType, name, text string) (*template.Template, error) {
+	t, err := template.New(payloadType + "." + name).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing push template %v.%v: %w", payloadType, name, err)
+	}
+
+	return t, nil
+}
+
+// Render creates notification of the json payload, it returns false when payload has no notification
+func (templates Templates) Render(data []byte) (Notification, bool) {
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return Notification{}, false
+	}
+
+	payloadType, _ := fields["type"].(string)
+	t, ok := templates[payloadType]
+	if !ok {
+		return Notification{}, false
+	}
+	if t.when != "" && fields[t.when] != true {
+		return Notification{}, false
+	}
+
+	notification := Notification{
+		Title:       execute(t.title, fields),
+		Body:        execute(t.body, fields),
+		CollapseKey: execute(t.collapseKey, fields),
+		Data:        map[string]string{"type": payloadType},
+	}
+	if from, ok := fields["from"].(string); ok {
+		notification.Data["from"] = from
+	}
+
+	if body := []rune(notification.Body); len(body) > maxBodyLength {
+		notification.Body = string(body[:maxBodyLength-1]) + "…"
+	}
+
+	return notification, true
+}
+
+func execute(t *template.Template, fields map[string]any) string {
+	var text strings.Builder
+	if err := t.Execute(&text, fields); err != nil {
+		return ""
+	}
+
+	return strings.ReplaceAll(text.String(), "<no value>", "")
+}
@@ -0,0 +1,36 @@
+package push
+
+import (
+	"doki.co.in/doki_real_time_service/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplates(t *testing.T) {
+	templates, err := NewTemplates(map[string]config.PushTemplate{
+		"user_send_friend_request": {},
+		"chat_message":             {Title: "{{.from}}", Body: "new message"},
+	})
+	require.NoError(t, err)
+
+	_, ok := templates.Render([]byte(`{"type":"user_send_friend_request","from":"alice","to":"bob"}`))
+	assert.False(t, ok)
+
+	notification, ok := templates.Render([]byte(`{"type":"chat_message","from":"alice","to":"bob","body":"secret"}`))
+	require.True(t, ok)
+	assert.Equal(t, Notification{
+		Title: "alice",
+		Body:  "new message",
+		Data:  map[string]string{"type": "chat_message", "from": "alice"},
+	}, notification)
+
+	_, ok = templates.Render([]byte(`{"type":"user_node_like_action","from":"alice","nodeId":"1","isLike":false}`))
+	assert.False(t, ok)
+
+	notification, ok = templates.Render([]byte(`{"type":"user_node_like_action","from":"alice","nodeId":"1","nodeType":"post","isLike":true}`))
+	require.True(t, ok)
+	assert.Equal(t, "alice liked your post", notification.Body)
+	assert.Equal(t, "like_1", notification.CollapseKey)
+}
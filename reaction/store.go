@@ -0,0 +1,101 @@
+package reaction
+
+import (
+	"errors"
+	"slices"
+	"sync"
+)
+
+// ErrLimit is returned when the user already has the max number of reactions on the message
+var ErrLimit = errors.New("reaction limit of the message is reached")
+
+// Store keeps reactions of users on messages
+// conversation and message id together identify a message as message ids are only unique in a conversation
+type Store interface {
+	// Add adds reaction of the user, it returns false when the user has already added it
+	Add(conversation, messageId, user, reaction string) (bool, error)
+
+	// Remove removes reaction of the user, it returns false when the user has not added it
+	Remove(conversation, messageId, user, reaction string) (bool, error)
+
+	// Reactions returns reactions on the message by user
+	Reactions(conversation, messageId string) map[string][]string
+}
+
+// message identifies a message in a conversation
+type message struct {
+	conversation string
+	id           string
+}
+
+// memoryStore keeps reactions in memory, they are lost on restart
+type memoryStore struct {
+	sync.Mutex
+	limit int
+
+	// reactions is message -> user -> reactions in the order they were added
+	reactions map[message]map[string][]string
+}
+
+// NewMemoryStore returns store which allows every user limit reactions on a message
+func NewMemoryStore(limit int) Store {
+	return &memoryStore{
+		limit:     limit,
+		reactions: make(map[message]map[string][]string),
+	}
+}
+
+func (s *memoryStore) Add(conversation, messageId, user, reaction string) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	key := message{conversation: conversation, id: messageId}
+	added := s.reactions[key][user]
+	if slices.Contains(added, reaction) {
+		return false, nil
+	}
+	if len(added) >= s.limit {
+		return false, ErrLimit
+	}
+
+	if s.reactions[key] == nil {
+		s.reactions[key] = make(map[string][]string)
+	}
+	s.reactions[key][user] = append(added, reaction)
+	return true, nil
+}
+
+func (s *memoryStore) Remove(conversation, messageId, user, reaction string) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	key := message{conversation: conversation, id: messageId}
+	added := s.reactions[key][user]
+	index := slices.Index(added, reaction)
+	if index < 0 {
+		return false, nil
+	}
+
+	added = slices.Delete(added, index, index+1)
+	if len(added) > 0 {
+		s.reactions[key][user] = added
+		return true, nil
+	}
+
+	delete(s.reactions[key], user)
+	if len(s.reactions[key]) == 0 {
+		delete(s.reactions, key)
+	}
+	return true, nil
+}
+
+func (s *memoryStore) Reactions(conversation, messageId string) map[string][]string {
+	s.Lock()
+	defer s.Unlock()
+
+	reactions := make(map[string][]string)
+	for user, added := range s.reactions[message{conversation: conversation, id: messageId}] {
+		reactions[user] = slices.Clone(added)
+	}
+	return reactions
+}
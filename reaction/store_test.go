@@ -0,0 +1,45 @@
+package reaction
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore(2)
+
+	added, err := store.Add("alice:bob", "1", "alice", "👍")
+	require.NoError(t, err)
+	assert.True(t, added)
+
+	added, _ = store.Add("alice:bob", "1", "alice", "👍")
+	assert.False(t, added)
+
+	store.Add("alice:bob", "1", "alice", "❤️")
+	_, err = store.Add("alice:bob", "1", "alice", "😂")
+	assert.ErrorIs(t, err, ErrLimit)
+
+	// limit is per user and per message
+	added, err = store.Add("alice:bob", "1", "bob", "😂")
+	require.NoError(t, err)
+	assert.True(t, added)
+	added, err = store.Add("alice:bob", "2", "alice", "😂")
+	require.NoError(t, err)
+	assert.True(t, added)
+
+	assert.Equal(t, map[string][]string{"alice": {"👍", "❤️"}, "bob": {"😂"}}, store.Reactions("alice:bob", "1"))
+
+	removed, err := store.Remove("alice:bob", "1", "alice", "👍")
+	require.NoError(t, err)
+	assert.True(t, removed)
+	removed, _ = store.Remove("alice:bob", "1", "alice", "👍")
+	assert.False(t, removed)
+
+	added, _ = store.Add("alice:bob", "1", "alice", "😂")
+	assert.True(t, added)
+
+	store.Remove("alice:bob", "2", "alice", "😂")
+	assert.Empty(t, store.Reactions("alice:bob", "2"))
+}
@@ -0,0 +1,94 @@
+package realtimepb
+
+import (
+	"encoding/json"
+	"errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+//go:generate protoc -I . --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative realtime.proto
+
+// jsonField carries payloads without a message, it is not a payload type
+const jsonField = "json"
+
+var ErrEmptyEnvelope = errors.New("envelope has no payload")
+
+// payloadOneof is looked up on use as descriptors are initialised after package variables
+func payloadOneof() protoreflect.OneofDescriptor {
+	return (&Envelope{}).ProtoReflect().Descriptor().Oneofs().ByName("payload")
+}
+
+// ToJson converts envelope to the json payload used inside the service
+// json field names are same as payload structs and type is the name of the set field
+// meta is not converted as server stamps its own
+func (e *Envelope) ToJson() ([]byte, error) {
+	message := e.ProtoReflect()
+	field := message.WhichOneof(payloadOneof())
+	if field == nil {
+		return nil, ErrEmptyEnvelope
+	}
+
+	if field.Name() == jsonField {
+		return []byte(e.GetJson()), nil
+	}
+
+	data, err := protojson.Marshal(message.Get(field).Message().Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, err
+	}
+	payload["type"], _ = json.Marshal(string(field.Name()))
+
+	return json.Marshal(payload)
+}
+
+// jsonMetadata is metadata stamped on json payloads
+type jsonMetadata struct {
+	ServerTs     int64  `json:"serverTs"`
+	Resource     string `json:"resource"`
+	EventId      string `json:"eventId"`
+	VerifiedFrom string `json:"verifiedFrom"`
+}
+
+// EnvelopeFromJson converts json payload to envelope
+// payload types without a message are sent as json field
+func EnvelopeFromJson(data []byte) (*Envelope, error) {
+	var base struct {
+		Type string        `json:"type"`
+		Meta *jsonMetadata `json:"meta"`
+	}
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, err
+	}
+
+	envelope := &Envelope{}
+	if base.Meta != nil {
+		envelope.Meta = &Metadata{
+			ServerTs:     base.Meta.ServerTs,
+			Resource:     base.Meta.Resource,
+			EventId:      base.Meta.EventId,
+			VerifiedFrom: base.Meta.VerifiedFrom,
+		}
+	}
+	message := envelope.ProtoReflect()
+
+	field := payloadOneof().Fields().ByName(protoreflect.Name(base.Type))
+	if field == nil || field.Message() == nil {
+		envelope.Payload = &Envelope_Json{Json: string(data)}
+		return envelope, nil
+	}
+
+	value := message.NewField(field)
+	options := protojson.UnmarshalOptions{DiscardUnknown: true}
+	if err := options.Unmarshal(data, value.Message().Interface()); err != nil {
+		return nil, err
+	}
+	message.Set(field, value)
+
+	return envelope, nil
+}
@@ -0,0 +1,54 @@
+package realtimepb
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestEnvelopeJsonRoundTrip(t *testing.T) {
+	payloads := map[string]string{
+		"chat": `{"type":"chat_message","from":"rohan_verma__","to":"doki_user_01","id":"0193b0c4",` +
+			`"subject":"text","body":"hey","replyOn":"0193b0c3","sendAt":"2025-01-20T10:15:30.123Z"}`,
+		"likeAction": `{"type":"user_node_like_action","from":"rohan_verma__","to":"doki_user_01","isLike":true,` +
+			`"likeCount":12,"commentCount":3,"nodeId":"post_01","nodeType":"post",` +
+			`"parents":[{"nodeId":"post_00","nodeType":"post"}]}`,
+		"presence": `{"type":"user_presence_info","to":"rohan_verma__","user":"doki_user_01","online":true}`,
+		"unknown":  `{"type":"group_chat_message","from":"rohan_verma__","to":"group_01"}`,
+	}
+
+	for name, payload := range payloads {
+		t.Run(name, func(t *testing.T) {
+			envelope, err := EnvelopeFromJson([]byte(payload))
+			require.NoError(t, err)
+
+			data, err := envelope.ToJson()
+			require.NoError(t, err)
+			assert.JSONEq(t, payload, string(data))
+		})
+	}
+}
+
+func TestEnvelopeFromJsonFields(t *testing.T) {
+	envelope, err := EnvelopeFromJson([]byte(`{"type":"poll_votes_update","from":"rohan_verma__","pollId":"poll_01","votes":[4,0,7]}`))
+	require.NoError(t, err)
+	assert.Equal(t, []int32{4, 0, 7}, envelope.GetPollVotesUpdate().GetVotes())
+
+	// offsets are accepted same as json payloads
+	envelope, err = EnvelopeFromJson([]byte(`{"type":"chat_message","from":"a","to":"b","sendAt":"2025-01-20T15:45:30+05:30"}`))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1737368130), envelope.GetChatMessage().GetSendAt().GetSeconds())
+
+	envelope, err = EnvelopeFromJson([]byte(`{"type":"typing_status","from":"a","to":"b",` +
+		`"meta":{"serverTs":1737368130123,"resource":"phone","eventId":"0193b0c4-7d4e-7c3a-9f1e-2a6b1c8d9e0f"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1737368130123), envelope.GetMeta().GetServerTs())
+	assert.Equal(t, "phone", envelope.GetMeta().GetResource())
+
+	data, err := envelope.ToJson()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"typing_status","from":"a","to":"b"}`, string(data))
+
+	_, err = (&Envelope{}).ToJson()
+	assert.ErrorIs(t, err, ErrEmptyEnvelope)
+}
@@ -0,0 +1,2070 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: realtime.proto
+
+package realtimepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Envelope struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*Envelope_Hello
+	//	*Envelope_Welcome
+	//	*Envelope_ServerShutdown
+	//	*Envelope_ChatMessage
+	//	*Envelope_TypingStatus
+	//	*Envelope_EditMessage
+	//	*Envelope_DeleteMessage
+	//	*Envelope_UserSendFriendRequest
+	//	*Envelope_UserAcceptedFriendRequest
+	//	*Envelope_UserRemovesFriendRelation
+	//	*Envelope_UserUpdateProfile
+	//	*Envelope_UserCreateRootNode
+	//	*Envelope_UserNodeLikeAction
+	//	*Envelope_UserCreateSecondaryNode
+	//	*Envelope_UserPresenceSubscription
+	//	*Envelope_UserPresenceInfo
+	//	*Envelope_PollSubscription
+	//	*Envelope_PollVotesUpdate
+	//	*Envelope_Json
+	Payload       isEnvelope_Payload `protobuf_oneof:"payload"`
+	Meta          *Metadata          `protobuf:"bytes,101,opt,name=meta,proto3" json:"meta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Envelope) Reset() {
+	*x = Envelope{}
+	mi := &file_realtime_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Envelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Envelope) ProtoMessage() {}
+
+func (x *Envelope) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Envelope.ProtoReflect.Descriptor instead.
+func (*Envelope) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Envelope) GetPayload() isEnvelope_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Envelope) GetHello() *Hello {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_Hello); ok {
+			return x.Hello
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetWelcome() *Welcome {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_Welcome); ok {
+			return x.Welcome
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetServerShutdown() *ServerShutdown {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_ServerShutdown); ok {
+			return x.ServerShutdown
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetChatMessage() *ChatMessage {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_ChatMessage); ok {
+			return x.ChatMessage
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetTypingStatus() *TypingStatus {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_TypingStatus); ok {
+			return x.TypingStatus
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetEditMessage() *EditMessage {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_EditMessage); ok {
+			return x.EditMessage
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetDeleteMessage() *DeleteMessage {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_DeleteMessage); ok {
+			return x.DeleteMessage
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetUserSendFriendRequest() *UserSendFriendRequest {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_UserSendFriendRequest); ok {
+			return x.UserSendFriendRequest
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetUserAcceptedFriendRequest() *UserAcceptedFriendRequest {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_UserAcceptedFriendRequest); ok {
+			return x.UserAcceptedFriendRequest
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetUserRemovesFriendRelation() *UserRemovesFriendRelation {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_UserRemovesFriendRelation); ok {
+			return x.UserRemovesFriendRelation
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetUserUpdateProfile() *UserUpdateProfile {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_UserUpdateProfile); ok {
+			return x.UserUpdateProfile
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetUserCreateRootNode() *UserCreateRootNode {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_UserCreateRootNode); ok {
+			return x.UserCreateRootNode
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetUserNodeLikeAction() *UserNodeLikeAction {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_UserNodeLikeAction); ok {
+			return x.UserNodeLikeAction
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetUserCreateSecondaryNode() *UserCreateSecondaryNode {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_UserCreateSecondaryNode); ok {
+			return x.UserCreateSecondaryNode
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetUserPresenceSubscription() *UserPresenceSubscription {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_UserPresenceSubscription); ok {
+			return x.UserPresenceSubscription
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetUserPresenceInfo() *UserPresenceInfo {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_UserPresenceInfo); ok {
+			return x.UserPresenceInfo
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetPollSubscription() *PollSubscription {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_PollSubscription); ok {
+			return x.PollSubscription
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetPollVotesUpdate() *PollVotesUpdate {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_PollVotesUpdate); ok {
+			return x.PollVotesUpdate
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetJson() string {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_Json); ok {
+			return x.Json
+		}
+	}
+	return ""
+}
+
+func (x *Envelope) GetMeta() *Metadata {
+	if x != nil {
+		return x.Meta
+	}
+	return nil
+}
+
+type isEnvelope_Payload interface {
+	isEnvelope_Payload()
+}
+
+type Envelope_Hello struct {
+	Hello *Hello `protobuf:"bytes,1,opt,name=hello,proto3,oneof"`
+}
+
+type Envelope_Welcome struct {
+	Welcome *Welcome `protobuf:"bytes,2,opt,name=welcome,proto3,oneof"`
+}
+
+type Envelope_ServerShutdown struct {
+	ServerShutdown *ServerShutdown `protobuf:"bytes,3,opt,name=server_shutdown,json=serverShutdown,proto3,oneof"`
+}
+
+type Envelope_ChatMessage struct {
+	ChatMessage *ChatMessage `protobuf:"bytes,10,opt,name=chat_message,json=chatMessage,proto3,oneof"`
+}
+
+type Envelope_TypingStatus struct {
+	TypingStatus *TypingStatus `protobuf:"bytes,11,opt,name=typing_status,json=typingStatus,proto3,oneof"`
+}
+
+type Envelope_EditMessage struct {
+	EditMessage *EditMessage `protobuf:"bytes,12,opt,name=edit_message,json=editMessage,proto3,oneof"`
+}
+
+type Envelope_DeleteMessage struct {
+	DeleteMessage *DeleteMessage `protobuf:"bytes,13,opt,name=delete_message,json=deleteMessage,proto3,oneof"`
+}
+
+type Envelope_UserSendFriendRequest struct {
+	UserSendFriendRequest *UserSendFriendRequest `protobuf:"bytes,20,opt,name=user_send_friend_request,json=userSendFriendRequest,proto3,oneof"`
+}
+
+type Envelope_UserAcceptedFriendRequest struct {
+	UserAcceptedFriendRequest *UserAcceptedFriendRequest `protobuf:"bytes,21,opt,name=user_accepted_friend_request,json=userAcceptedFriendRequest,proto3,oneof"`
+}
+
+type Envelope_UserRemovesFriendRelation struct {
+	UserRemovesFriendRelation *UserRemovesFriendRelation `protobuf:"bytes,22,opt,name=user_removes_friend_relation,json=userRemovesFriendRelation,proto3,oneof"`
+}
+
+type Envelope_UserUpdateProfile struct {
+	UserUpdateProfile *UserUpdateProfile `protobuf:"bytes,30,opt,name=user_update_profile,json=userUpdateProfile,proto3,oneof"`
+}
+
+type Envelope_UserCreateRootNode struct {
+	UserCreateRootNode *UserCreateRootNode `protobuf:"bytes,31,opt,name=user_create_root_node,json=userCreateRootNode,proto3,oneof"`
+}
+
+type Envelope_UserNodeLikeAction struct {
+	UserNodeLikeAction *UserNodeLikeAction `protobuf:"bytes,32,opt,name=user_node_like_action,json=userNodeLikeAction,proto3,oneof"`
+}
+
+type Envelope_UserCreateSecondaryNode struct {
+	UserCreateSecondaryNode *UserCreateSecondaryNode `protobuf:"bytes,33,opt,name=user_create_secondary_node,json=userCreateSecondaryNode,proto3,oneof"`
+}
+
+type Envelope_UserPresenceSubscription struct {
+	UserPresenceSubscription *UserPresenceSubscription `protobuf:"bytes,40,opt,name=user_presence_subscription,json=userPresenceSubscription,proto3,oneof"`
+}
+
+type Envelope_UserPresenceInfo struct {
+	UserPresenceInfo *UserPresenceInfo `protobuf:"bytes,41,opt,name=user_presence_info,json=userPresenceInfo,proto3,oneof"`
+}
+
+type Envelope_PollSubscription struct {
+	PollSubscription *PollSubscription `protobuf:"bytes,50,opt,name=poll_subscription,json=pollSubscription,proto3,oneof"`
+}
+
+type Envelope_PollVotesUpdate struct {
+	PollVotesUpdate *PollVotesUpdate `protobuf:"bytes,51,opt,name=poll_votes_update,json=pollVotesUpdate,proto3,oneof"`
+}
+
+type Envelope_Json struct {
+	Json string `protobuf:"bytes,100,opt,name=json,proto3,oneof"`
+}
+
+func (*Envelope_Hello) isEnvelope_Payload() {}
+
+func (*Envelope_Welcome) isEnvelope_Payload() {}
+
+func (*Envelope_ServerShutdown) isEnvelope_Payload() {}
+
+func (*Envelope_ChatMessage) isEnvelope_Payload() {}
+
+func (*Envelope_TypingStatus) isEnvelope_Payload() {}
+
+func (*Envelope_EditMessage) isEnvelope_Payload() {}
+
+func (*Envelope_DeleteMessage) isEnvelope_Payload() {}
+
+func (*Envelope_UserSendFriendRequest) isEnvelope_Payload() {}
+
+func (*Envelope_UserAcceptedFriendRequest) isEnvelope_Payload() {}
+
+func (*Envelope_UserRemovesFriendRelation) isEnvelope_Payload() {}
+
+func (*Envelope_UserUpdateProfile) isEnvelope_Payload() {}
+
+func (*Envelope_UserCreateRootNode) isEnvelope_Payload() {}
+
+func (*Envelope_UserNodeLikeAction) isEnvelope_Payload() {}
+
+func (*Envelope_UserCreateSecondaryNode) isEnvelope_Payload() {}
+
+func (*Envelope_UserPresenceSubscription) isEnvelope_Payload() {}
+
+func (*Envelope_UserPresenceInfo) isEnvelope_Payload() {}
+
+func (*Envelope_PollSubscription) isEnvelope_Payload() {}
+
+func (*Envelope_PollVotesUpdate) isEnvelope_Payload() {}
+
+func (*Envelope_Json) isEnvelope_Payload() {}
+
+type Metadata struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ServerTs      int64                  `protobuf:"varint,1,opt,name=server_ts,json=serverTs,proto3" json:"server_ts,omitempty"`
+	Resource      string                 `protobuf:"bytes,2,opt,name=resource,proto3" json:"resource,omitempty"`
+	EventId       string                 `protobuf:"bytes,3,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	VerifiedFrom  string                 `protobuf:"bytes,4,opt,name=verified_from,json=verifiedFrom,proto3" json:"verified_from,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Metadata) Reset() {
+	*x = Metadata{}
+	mi := &file_realtime_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Metadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Metadata) ProtoMessage() {}
+
+func (x *Metadata) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Metadata.ProtoReflect.Descriptor instead.
+func (*Metadata) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Metadata) GetServerTs() int64 {
+	if x != nil {
+		return x.ServerTs
+	}
+	return 0
+}
+
+func (x *Metadata) GetResource() string {
+	if x != nil {
+		return x.Resource
+	}
+	return ""
+}
+
+func (x *Metadata) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *Metadata) GetVerifiedFrom() string {
+	if x != nil {
+		return x.VerifiedFrom
+	}
+	return ""
+}
+
+type Hello struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	Version       int32                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Capabilities  []string               `protobuf:"bytes,3,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Hello) Reset() {
+	*x = Hello{}
+	mi := &file_realtime_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Hello) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hello) ProtoMessage() {}
+
+func (x *Hello) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hello.ProtoReflect.Descriptor instead.
+func (*Hello) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Hello) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *Hello) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Hello) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+type WelcomeLimits struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	IncomingPayloadLimit int64                  `protobuf:"varint,1,opt,name=incoming_payload_limit,json=incomingPayloadLimit,proto3" json:"incoming_payload_limit,omitempty"`
+	PingInterval         int64                  `protobuf:"varint,2,opt,name=ping_interval,json=pingInterval,proto3" json:"ping_interval,omitempty"`
+	PongWait             int64                  `protobuf:"varint,3,opt,name=pong_wait,json=pongWait,proto3" json:"pong_wait,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *WelcomeLimits) Reset() {
+	*x = WelcomeLimits{}
+	mi := &file_realtime_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WelcomeLimits) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WelcomeLimits) ProtoMessage() {}
+
+func (x *WelcomeLimits) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WelcomeLimits.ProtoReflect.Descriptor instead.
+func (*WelcomeLimits) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *WelcomeLimits) GetIncomingPayloadLimit() int64 {
+	if x != nil {
+		return x.IncomingPayloadLimit
+	}
+	return 0
+}
+
+func (x *WelcomeLimits) GetPingInterval() int64 {
+	if x != nil {
+		return x.PingInterval
+	}
+	return 0
+}
+
+func (x *WelcomeLimits) GetPongWait() int64 {
+	if x != nil {
+		return x.PongWait
+	}
+	return 0
+}
+
+type Welcome struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	To              string                 `protobuf:"bytes,1,opt,name=to,proto3" json:"to,omitempty"`
+	Resource        string                 `protobuf:"bytes,2,opt,name=resource,proto3" json:"resource,omitempty"`
+	ServerVersion   string                 `protobuf:"bytes,3,opt,name=server_version,json=serverVersion,proto3" json:"server_version,omitempty"`
+	ProtocolVersion int32                  `protobuf:"varint,4,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	Limits          *WelcomeLimits         `protobuf:"bytes,5,opt,name=limits,proto3" json:"limits,omitempty"`
+	Features        []string               `protobuf:"bytes,6,rep,name=features,proto3" json:"features,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Welcome) Reset() {
+	*x = Welcome{}
+	mi := &file_realtime_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Welcome) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Welcome) ProtoMessage() {}
+
+func (x *Welcome) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Welcome.ProtoReflect.Descriptor instead.
+func (*Welcome) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Welcome) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *Welcome) GetResource() string {
+	if x != nil {
+		return x.Resource
+	}
+	return ""
+}
+
+func (x *Welcome) GetServerVersion() string {
+	if x != nil {
+		return x.ServerVersion
+	}
+	return ""
+}
+
+func (x *Welcome) GetProtocolVersion() int32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *Welcome) GetLimits() *WelcomeLimits {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+func (x *Welcome) GetFeatures() []string {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+type ServerShutdown struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	To             string                 `protobuf:"bytes,1,opt,name=to,proto3" json:"to,omitempty"`
+	ReconnectAfter int64                  `protobuf:"varint,2,opt,name=reconnect_after,json=reconnectAfter,proto3" json:"reconnect_after,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ServerShutdown) Reset() {
+	*x = ServerShutdown{}
+	mi := &file_realtime_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerShutdown) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerShutdown) ProtoMessage() {}
+
+func (x *ServerShutdown) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerShutdown.ProtoReflect.Descriptor instead.
+func (*ServerShutdown) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ServerShutdown) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *ServerShutdown) GetReconnectAfter() int64 {
+	if x != nil {
+		return x.ReconnectAfter
+	}
+	return 0
+}
+
+type ChatMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Id            string                 `protobuf:"bytes,3,opt,name=id,proto3" json:"id,omitempty"`
+	Subject       string                 `protobuf:"bytes,4,opt,name=subject,proto3" json:"subject,omitempty"`
+	Body          string                 `protobuf:"bytes,5,opt,name=body,proto3" json:"body,omitempty"`
+	ReplyOn       string                 `protobuf:"bytes,6,opt,name=reply_on,json=replyOn,proto3" json:"reply_on,omitempty"`
+	SendAt        *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=send_at,json=sendAt,proto3" json:"send_at,omitempty"`
+	Muted         bool                   `protobuf:"varint,8,opt,name=muted,proto3" json:"muted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatMessage) Reset() {
+	*x = ChatMessage{}
+	mi := &file_realtime_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatMessage) ProtoMessage() {}
+
+func (x *ChatMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatMessage.ProtoReflect.Descriptor instead.
+func (*ChatMessage) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ChatMessage) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetBody() string {
+	if x != nil {
+		return x.Body
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetReplyOn() string {
+	if x != nil {
+		return x.ReplyOn
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetSendAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SendAt
+	}
+	return nil
+}
+
+func (x *ChatMessage) GetMuted() bool {
+	if x != nil {
+		return x.Muted
+	}
+	return false
+}
+
+type TypingStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TypingStatus) Reset() {
+	*x = TypingStatus{}
+	mi := &file_realtime_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TypingStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TypingStatus) ProtoMessage() {}
+
+func (x *TypingStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TypingStatus.ProtoReflect.Descriptor instead.
+func (*TypingStatus) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TypingStatus) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *TypingStatus) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+type EditMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Id            string                 `protobuf:"bytes,3,opt,name=id,proto3" json:"id,omitempty"`
+	Body          string                 `protobuf:"bytes,4,opt,name=body,proto3" json:"body,omitempty"`
+	EditedOn      *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=edited_on,json=editedOn,proto3" json:"edited_on,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EditMessage) Reset() {
+	*x = EditMessage{}
+	mi := &file_realtime_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EditMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EditMessage) ProtoMessage() {}
+
+func (x *EditMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EditMessage.ProtoReflect.Descriptor instead.
+func (*EditMessage) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *EditMessage) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *EditMessage) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *EditMessage) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *EditMessage) GetBody() string {
+	if x != nil {
+		return x.Body
+	}
+	return ""
+}
+
+func (x *EditMessage) GetEditedOn() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EditedOn
+	}
+	return nil
+}
+
+type DeleteMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Id            []string               `protobuf:"bytes,3,rep,name=id,proto3" json:"id,omitempty"`
+	Everyone      bool                   `protobuf:"varint,4,opt,name=everyone,proto3" json:"everyone,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteMessage) Reset() {
+	*x = DeleteMessage{}
+	mi := &file_realtime_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteMessage) ProtoMessage() {}
+
+func (x *DeleteMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteMessage.ProtoReflect.Descriptor instead.
+func (*DeleteMessage) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteMessage) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *DeleteMessage) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *DeleteMessage) GetId() []string {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *DeleteMessage) GetEveryone() bool {
+	if x != nil {
+		return x.Everyone
+	}
+	return false
+}
+
+type UserSendFriendRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	RequestedBy   string                 `protobuf:"bytes,3,opt,name=requested_by,json=requestedBy,proto3" json:"requested_by,omitempty"`
+	AddedOn       *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=added_on,json=addedOn,proto3" json:"added_on,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserSendFriendRequest) Reset() {
+	*x = UserSendFriendRequest{}
+	mi := &file_realtime_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserSendFriendRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserSendFriendRequest) ProtoMessage() {}
+
+func (x *UserSendFriendRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserSendFriendRequest.ProtoReflect.Descriptor instead.
+func (*UserSendFriendRequest) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *UserSendFriendRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *UserSendFriendRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *UserSendFriendRequest) GetRequestedBy() string {
+	if x != nil {
+		return x.RequestedBy
+	}
+	return ""
+}
+
+func (x *UserSendFriendRequest) GetAddedOn() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AddedOn
+	}
+	return nil
+}
+
+type UserAcceptedFriendRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	RequestedBy   string                 `protobuf:"bytes,3,opt,name=requested_by,json=requestedBy,proto3" json:"requested_by,omitempty"`
+	AddedOn       *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=added_on,json=addedOn,proto3" json:"added_on,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserAcceptedFriendRequest) Reset() {
+	*x = UserAcceptedFriendRequest{}
+	mi := &file_realtime_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserAcceptedFriendRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserAcceptedFriendRequest) ProtoMessage() {}
+
+func (x *UserAcceptedFriendRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserAcceptedFriendRequest.ProtoReflect.Descriptor instead.
+func (*UserAcceptedFriendRequest) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *UserAcceptedFriendRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *UserAcceptedFriendRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *UserAcceptedFriendRequest) GetRequestedBy() string {
+	if x != nil {
+		return x.RequestedBy
+	}
+	return ""
+}
+
+func (x *UserAcceptedFriendRequest) GetAddedOn() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AddedOn
+	}
+	return nil
+}
+
+type UserRemovesFriendRelation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserRemovesFriendRelation) Reset() {
+	*x = UserRemovesFriendRelation{}
+	mi := &file_realtime_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserRemovesFriendRelation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserRemovesFriendRelation) ProtoMessage() {}
+
+func (x *UserRemovesFriendRelation) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserRemovesFriendRelation.ProtoReflect.Descriptor instead.
+func (*UserRemovesFriendRelation) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *UserRemovesFriendRelation) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *UserRemovesFriendRelation) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+type UserUpdateProfile struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	From           string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	Name           string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	ProfilePicture string                 `protobuf:"bytes,3,opt,name=profile_picture,json=profilePicture,proto3" json:"profile_picture,omitempty"`
+	Bio            string                 `protobuf:"bytes,4,opt,name=bio,proto3" json:"bio,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *UserUpdateProfile) Reset() {
+	*x = UserUpdateProfile{}
+	mi := &file_realtime_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserUpdateProfile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserUpdateProfile) ProtoMessage() {}
+
+func (x *UserUpdateProfile) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserUpdateProfile.ProtoReflect.Descriptor instead.
+func (*UserUpdateProfile) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *UserUpdateProfile) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *UserUpdateProfile) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UserUpdateProfile) GetProfilePicture() string {
+	if x != nil {
+		return x.ProfilePicture
+	}
+	return ""
+}
+
+func (x *UserUpdateProfile) GetBio() string {
+	if x != nil {
+		return x.Bio
+	}
+	return ""
+}
+
+type UserCreateRootNode struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	NodeType      string                 `protobuf:"bytes,3,opt,name=node_type,json=nodeType,proto3" json:"node_type,omitempty"`
+	UsersTagged   []string               `protobuf:"bytes,4,rep,name=users_tagged,json=usersTagged,proto3" json:"users_tagged,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserCreateRootNode) Reset() {
+	*x = UserCreateRootNode{}
+	mi := &file_realtime_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserCreateRootNode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserCreateRootNode) ProtoMessage() {}
+
+func (x *UserCreateRootNode) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserCreateRootNode.ProtoReflect.Descriptor instead.
+func (*UserCreateRootNode) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *UserCreateRootNode) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *UserCreateRootNode) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UserCreateRootNode) GetNodeType() string {
+	if x != nil {
+		return x.NodeType
+	}
+	return ""
+}
+
+func (x *UserCreateRootNode) GetUsersTagged() []string {
+	if x != nil {
+		return x.UsersTagged
+	}
+	return nil
+}
+
+type ParentNode struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	NodeType      string                 `protobuf:"bytes,2,opt,name=node_type,json=nodeType,proto3" json:"node_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParentNode) Reset() {
+	*x = ParentNode{}
+	mi := &file_realtime_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParentNode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParentNode) ProtoMessage() {}
+
+func (x *ParentNode) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParentNode.ProtoReflect.Descriptor instead.
+func (*ParentNode) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ParentNode) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *ParentNode) GetNodeType() string {
+	if x != nil {
+		return x.NodeType
+	}
+	return ""
+}
+
+type UserNodeLikeAction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	IsLike        bool                   `protobuf:"varint,3,opt,name=is_like,json=isLike,proto3" json:"is_like,omitempty"`
+	LikeCount     int32                  `protobuf:"varint,4,opt,name=like_count,json=likeCount,proto3" json:"like_count,omitempty"`
+	CommentCount  int32                  `protobuf:"varint,5,opt,name=comment_count,json=commentCount,proto3" json:"comment_count,omitempty"`
+	NodeId        string                 `protobuf:"bytes,6,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	NodeType      string                 `protobuf:"bytes,7,opt,name=node_type,json=nodeType,proto3" json:"node_type,omitempty"`
+	Parents       []*ParentNode          `protobuf:"bytes,8,rep,name=parents,proto3" json:"parents,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserNodeLikeAction) Reset() {
+	*x = UserNodeLikeAction{}
+	mi := &file_realtime_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserNodeLikeAction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserNodeLikeAction) ProtoMessage() {}
+
+func (x *UserNodeLikeAction) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserNodeLikeAction.ProtoReflect.Descriptor instead.
+func (*UserNodeLikeAction) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *UserNodeLikeAction) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *UserNodeLikeAction) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *UserNodeLikeAction) GetIsLike() bool {
+	if x != nil {
+		return x.IsLike
+	}
+	return false
+}
+
+func (x *UserNodeLikeAction) GetLikeCount() int32 {
+	if x != nil {
+		return x.LikeCount
+	}
+	return 0
+}
+
+func (x *UserNodeLikeAction) GetCommentCount() int32 {
+	if x != nil {
+		return x.CommentCount
+	}
+	return 0
+}
+
+func (x *UserNodeLikeAction) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *UserNodeLikeAction) GetNodeType() string {
+	if x != nil {
+		return x.NodeType
+	}
+	return ""
+}
+
+func (x *UserNodeLikeAction) GetParents() []*ParentNode {
+	if x != nil {
+		return x.Parents
+	}
+	return nil
+}
+
+type UserCreateSecondaryNode struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	From                 string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To                   string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	NodeId               string                 `protobuf:"bytes,3,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	NodeType             string                 `protobuf:"bytes,4,opt,name=node_type,json=nodeType,proto3" json:"node_type,omitempty"`
+	Mentions             []string               `protobuf:"bytes,5,rep,name=mentions,proto3" json:"mentions,omitempty"`
+	ReplyOnNodeCreatedBy string                 `protobuf:"bytes,6,opt,name=reply_on_node_created_by,json=replyOnNodeCreatedBy,proto3" json:"reply_on_node_created_by,omitempty"`
+	Parents              []*ParentNode          `protobuf:"bytes,7,rep,name=parents,proto3" json:"parents,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *UserCreateSecondaryNode) Reset() {
+	*x = UserCreateSecondaryNode{}
+	mi := &file_realtime_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserCreateSecondaryNode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserCreateSecondaryNode) ProtoMessage() {}
+
+func (x *UserCreateSecondaryNode) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserCreateSecondaryNode.ProtoReflect.Descriptor instead.
+func (*UserCreateSecondaryNode) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *UserCreateSecondaryNode) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *UserCreateSecondaryNode) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *UserCreateSecondaryNode) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *UserCreateSecondaryNode) GetNodeType() string {
+	if x != nil {
+		return x.NodeType
+	}
+	return ""
+}
+
+func (x *UserCreateSecondaryNode) GetMentions() []string {
+	if x != nil {
+		return x.Mentions
+	}
+	return nil
+}
+
+func (x *UserCreateSecondaryNode) GetReplyOnNodeCreatedBy() string {
+	if x != nil {
+		return x.ReplyOnNodeCreatedBy
+	}
+	return ""
+}
+
+func (x *UserCreateSecondaryNode) GetParents() []*ParentNode {
+	if x != nil {
+		return x.Parents
+	}
+	return nil
+}
+
+type UserPresenceSubscription struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	User          string                 `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	Subscribe     bool                   `protobuf:"varint,3,opt,name=subscribe,proto3" json:"subscribe,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserPresenceSubscription) Reset() {
+	*x = UserPresenceSubscription{}
+	mi := &file_realtime_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserPresenceSubscription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserPresenceSubscription) ProtoMessage() {}
+
+func (x *UserPresenceSubscription) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserPresenceSubscription.ProtoReflect.Descriptor instead.
+func (*UserPresenceSubscription) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *UserPresenceSubscription) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *UserPresenceSubscription) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *UserPresenceSubscription) GetSubscribe() bool {
+	if x != nil {
+		return x.Subscribe
+	}
+	return false
+}
+
+type UserPresenceInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	To            string                 `protobuf:"bytes,1,opt,name=to,proto3" json:"to,omitempty"`
+	User          string                 `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	Online        bool                   `protobuf:"varint,3,opt,name=online,proto3" json:"online,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserPresenceInfo) Reset() {
+	*x = UserPresenceInfo{}
+	mi := &file_realtime_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserPresenceInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserPresenceInfo) ProtoMessage() {}
+
+func (x *UserPresenceInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserPresenceInfo.ProtoReflect.Descriptor instead.
+func (*UserPresenceInfo) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *UserPresenceInfo) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *UserPresenceInfo) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *UserPresenceInfo) GetOnline() bool {
+	if x != nil {
+		return x.Online
+	}
+	return false
+}
+
+type PollSubscription struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	PollId        string                 `protobuf:"bytes,2,opt,name=poll_id,json=pollId,proto3" json:"poll_id,omitempty"`
+	Subscribe     bool                   `protobuf:"varint,3,opt,name=subscribe,proto3" json:"subscribe,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PollSubscription) Reset() {
+	*x = PollSubscription{}
+	mi := &file_realtime_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PollSubscription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PollSubscription) ProtoMessage() {}
+
+func (x *PollSubscription) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PollSubscription.ProtoReflect.Descriptor instead.
+func (*PollSubscription) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *PollSubscription) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *PollSubscription) GetPollId() string {
+	if x != nil {
+		return x.PollId
+	}
+	return ""
+}
+
+func (x *PollSubscription) GetSubscribe() bool {
+	if x != nil {
+		return x.Subscribe
+	}
+	return false
+}
+
+type PollVotesUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	PollId        string                 `protobuf:"bytes,2,opt,name=poll_id,json=pollId,proto3" json:"poll_id,omitempty"`
+	Votes         []int32                `protobuf:"varint,3,rep,packed,name=votes,proto3" json:"votes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PollVotesUpdate) Reset() {
+	*x = PollVotesUpdate{}
+	mi := &file_realtime_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PollVotesUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PollVotesUpdate) ProtoMessage() {}
+
+func (x *PollVotesUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_realtime_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PollVotesUpdate.ProtoReflect.Descriptor instead.
+func (*PollVotesUpdate) Descriptor() ([]byte, []int) {
+	return file_realtime_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *PollVotesUpdate) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *PollVotesUpdate) GetPollId() string {
+	if x != nil {
+		return x.PollId
+	}
+	return ""
+}
+
+func (x *PollVotesUpdate) GetVotes() []int32 {
+	if x != nil {
+		return x.Votes
+	}
+	return nil
+}
+
+var File_realtime_proto protoreflect.FileDescriptor
+
+const file_realtime_proto_rawDesc = "" +
+	"\n" +
+	"\x0erealtime.proto\x12\x10doki.realtime.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xc8\f\n" +
+	"\bEnvelope\x12/\n" +
+	"\x05hello\x18\x01 \x01(\v2\x17.doki.realtime.v1.HelloH\x00R\x05hello\x125\n" +
+	"\awelcome\x18\x02 \x01(\v2\x19.doki.realtime.v1.WelcomeH\x00R\awelcome\x12K\n" +
+	"\x0fserver_shutdown\x18\x03 \x01(\v2 .doki.realtime.v1.ServerShutdownH\x00R\x0eserverShutdown\x12B\n" +
+	"\fchat_message\x18\n" +
+	" \x01(\v2\x1d.doki.realtime.v1.ChatMessageH\x00R\vchatMessage\x12E\n" +
+	"\rtyping_status\x18\v \x01(\v2\x1e.doki.realtime.v1.TypingStatusH\x00R\ftypingStatus\x12B\n" +
+	"\fedit_message\x18\f \x01(\v2\x1d.doki.realtime.v1.EditMessageH\x00R\veditMessage\x12H\n" +
+	"\x0edelete_message\x18\r \x01(\v2\x1f.doki.realtime.v1.DeleteMessageH\x00R\rdeleteMessage\x12b\n" +
+	"\x18user_send_friend_request\x18\x14 \x01(\v2'.doki.realtime.v1.UserSendFriendRequestH\x00R\x15userSendFriendRequest\x12n\n" +
+	"\x1cuser_accepted_friend_request\x18\x15 \x01(\v2+.doki.realtime.v1.UserAcceptedFriendRequestH\x00R\x19userAcceptedFriendRequest\x12n\n" +
+	"\x1cuser_removes_friend_relation\x18\x16 \x01(\v2+.doki.realtime.v1.UserRemovesFriendRelationH\x00R\x19userRemovesFriendRelation\x12U\n" +
+	"\x13user_update_profile\x18\x1e \x01(\v2#.doki.realtime.v1.UserUpdateProfileH\x00R\x11userUpdateProfile\x12Y\n" +
+	"\x15user_create_root_node\x18\x1f \x01(\v2$.doki.realtime.v1.UserCreateRootNodeH\x00R\x12userCreateRootNode\x12Y\n" +
+	"\x15user_node_like_action\x18  \x01(\v2$.doki.realtime.v1.UserNodeLikeActionH\x00R\x12userNodeLikeAction\x12h\n" +
+	"\x1auser_create_secondary_node\x18! \x01(\v2).doki.realtime.v1.UserCreateSecondaryNodeH\x00R\x17userCreateSecondaryNode\x12j\n" +
+	"\x1auser_presence_subscription\x18( \x01(\v2*.doki.realtime.v1.UserPresenceSubscriptionH\x00R\x18userPresenceSubscription\x12R\n" +
+	"\x12user_presence_info\x18) \x01(\v2\".doki.realtime.v1.UserPresenceInfoH\x00R\x10userPresenceInfo\x12Q\n" +
+	"\x11poll_subscription\x182 \x01(\v2\".doki.realtime.v1.PollSubscriptionH\x00R\x10pollSubscription\x12O\n" +
+	"\x11poll_votes_update\x183 \x01(\v2!.doki.realtime.v1.PollVotesUpdateH\x00R\x0fpollVotesUpdate\x12\x14\n" +
+	"\x04json\x18d \x01(\tH\x00R\x04json\x12.\n" +
+	"\x04meta\x18e \x01(\v2\x1a.doki.realtime.v1.MetadataR\x04metaB\t\n" +
+	"\apayload\"\x83\x01\n" +
+	"\bMetadata\x12\x1b\n" +
+	"\tserver_ts\x18\x01 \x01(\x03R\bserverTs\x12\x1a\n" +
+	"\bresource\x18\x02 \x01(\tR\bresource\x12\x19\n" +
+	"\bevent_id\x18\x03 \x01(\tR\aeventId\x12#\n" +
+	"\rverified_from\x18\x04 \x01(\tR\fverifiedFrom\"Y\n" +
+	"\x05Hello\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x05R\aversion\x12\"\n" +
+	"\fcapabilities\x18\x03 \x03(\tR\fcapabilities\"\x87\x01\n" +
+	"\rWelcomeLimits\x124\n" +
+	"\x16incoming_payload_limit\x18\x01 \x01(\x03R\x14incomingPayloadLimit\x12#\n" +
+	"\rping_interval\x18\x02 \x01(\x03R\fpingInterval\x12\x1b\n" +
+	"\tpong_wait\x18\x03 \x01(\x03R\bpongWait\"\xdc\x01\n" +
+	"\aWelcome\x12\x0e\n" +
+	"\x02to\x18\x01 \x01(\tR\x02to\x12\x1a\n" +
+	"\bresource\x18\x02 \x01(\tR\bresource\x12%\n" +
+	"\x0eserver_version\x18\x03 \x01(\tR\rserverVersion\x12)\n" +
+	"\x10protocol_version\x18\x04 \x01(\x05R\x0fprotocolVersion\x127\n" +
+	"\x06limits\x18\x05 \x01(\v2\x1f.doki.realtime.v1.WelcomeLimitsR\x06limits\x12\x1a\n" +
+	"\bfeatures\x18\x06 \x03(\tR\bfeatures\"I\n" +
+	"\x0eServerShutdown\x12\x0e\n" +
+	"\x02to\x18\x01 \x01(\tR\x02to\x12'\n" +
+	"\x0freconnect_after\x18\x02 \x01(\x03R\x0ereconnectAfter\"\xd5\x01\n" +
+	"\vChatMessage\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\x12\x0e\n" +
+	"\x02id\x18\x03 \x01(\tR\x02id\x12\x18\n" +
+	"\asubject\x18\x04 \x01(\tR\asubject\x12\x12\n" +
+	"\x04body\x18\x05 \x01(\tR\x04body\x12\x19\n" +
+	"\breply_on\x18\x06 \x01(\tR\areplyOn\x123\n" +
+	"\asend_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\x06sendAt\x12\x14\n" +
+	"\x05muted\x18\b \x01(\bR\x05muted\"2\n" +
+	"\fTypingStatus\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\"\x8e\x01\n" +
+	"\vEditMessage\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\x12\x0e\n" +
+	"\x02id\x18\x03 \x01(\tR\x02id\x12\x12\n" +
+	"\x04body\x18\x04 \x01(\tR\x04body\x127\n" +
+	"\tedited_on\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\beditedOn\"_\n" +
+	"\rDeleteMessage\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\x12\x0e\n" +
+	"\x02id\x18\x03 \x03(\tR\x02id\x12\x1a\n" +
+	"\beveryone\x18\x04 \x01(\bR\beveryone\"\x95\x01\n" +
+	"\x15UserSendFriendRequest\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\x12!\n" +
+	"\frequested_by\x18\x03 \x01(\tR\vrequestedBy\x125\n" +
+	"\badded_on\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\aaddedOn\"\x99\x01\n" +
+	"\x19UserAcceptedFriendRequest\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\x12!\n" +
+	"\frequested_by\x18\x03 \x01(\tR\vrequestedBy\x125\n" +
+	"\badded_on\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\aaddedOn\"?\n" +
+	"\x19UserRemovesFriendRelation\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\"v\n" +
+	"\x11UserUpdateProfile\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12'\n" +
+	"\x0fprofile_picture\x18\x03 \x01(\tR\x0eprofilePicture\x12\x10\n" +
+	"\x03bio\x18\x04 \x01(\tR\x03bio\"x\n" +
+	"\x12UserCreateRootNode\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x12\x1b\n" +
+	"\tnode_type\x18\x03 \x01(\tR\bnodeType\x12!\n" +
+	"\fusers_tagged\x18\x04 \x03(\tR\vusersTagged\"B\n" +
+	"\n" +
+	"ParentNode\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x1b\n" +
+	"\tnode_type\x18\x02 \x01(\tR\bnodeType\"\x83\x02\n" +
+	"\x12UserNodeLikeAction\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\x12\x17\n" +
+	"\ais_like\x18\x03 \x01(\bR\x06isLike\x12\x1d\n" +
+	"\n" +
+	"like_count\x18\x04 \x01(\x05R\tlikeCount\x12#\n" +
+	"\rcomment_count\x18\x05 \x01(\x05R\fcommentCount\x12\x17\n" +
+	"\anode_id\x18\x06 \x01(\tR\x06nodeId\x12\x1b\n" +
+	"\tnode_type\x18\a \x01(\tR\bnodeType\x126\n" +
+	"\aparents\x18\b \x03(\v2\x1c.doki.realtime.v1.ParentNodeR\aparents\"\xff\x01\n" +
+	"\x17UserCreateSecondaryNode\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\x12\x17\n" +
+	"\anode_id\x18\x03 \x01(\tR\x06nodeId\x12\x1b\n" +
+	"\tnode_type\x18\x04 \x01(\tR\bnodeType\x12\x1a\n" +
+	"\bmentions\x18\x05 \x03(\tR\bmentions\x126\n" +
+	"\x18reply_on_node_created_by\x18\x06 \x01(\tR\x14replyOnNodeCreatedBy\x126\n" +
+	"\aparents\x18\a \x03(\v2\x1c.doki.realtime.v1.ParentNodeR\aparents\"`\n" +
+	"\x18UserPresenceSubscription\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x12\n" +
+	"\x04user\x18\x02 \x01(\tR\x04user\x12\x1c\n" +
+	"\tsubscribe\x18\x03 \x01(\bR\tsubscribe\"N\n" +
+	"\x10UserPresenceInfo\x12\x0e\n" +
+	"\x02to\x18\x01 \x01(\tR\x02to\x12\x12\n" +
+	"\x04user\x18\x02 \x01(\tR\x04user\x12\x16\n" +
+	"\x06online\x18\x03 \x01(\bR\x06online\"]\n" +
+	"\x10PollSubscription\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x17\n" +
+	"\apoll_id\x18\x02 \x01(\tR\x06pollId\x12\x1c\n" +
+	"\tsubscribe\x18\x03 \x01(\bR\tsubscribe\"T\n" +
+	"\x0fPollVotesUpdate\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x17\n" +
+	"\apoll_id\x18\x02 \x01(\tR\x06pollId\x12\x14\n" +
+	"\x05votes\x18\x03 \x03(\x05R\x05votes2Q\n" +
+	"\bRealtime\x12E\n" +
+	"\aConnect\x12\x1a.doki.realtime.v1.Envelope\x1a\x1a.doki.realtime.v1.Envelope(\x010\x01B.Z,doki.co.in/doki_real_time_service/realtimepbb\x06proto3"
+
+var (
+	file_realtime_proto_rawDescOnce sync.Once
+	file_realtime_proto_rawDescData []byte
+)
+
+func file_realtime_proto_rawDescGZIP() []byte {
+	file_realtime_proto_rawDescOnce.Do(func() {
+		file_realtime_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_realtime_proto_rawDesc), len(file_realtime_proto_rawDesc)))
+	})
+	return file_realtime_proto_rawDescData
+}
+
+var file_realtime_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
+var file_realtime_proto_goTypes = []any{
+	(*Envelope)(nil),                  // 0: doki.realtime.v1.Envelope
+	(*Metadata)(nil),                  // 1: doki.realtime.v1.Metadata
+	(*Hello)(nil),                     // 2: doki.realtime.v1.Hello
+	(*WelcomeLimits)(nil),             // 3: doki.realtime.v1.WelcomeLimits
+	(*Welcome)(nil),                   // 4: doki.realtime.v1.Welcome
+	(*ServerShutdown)(nil),            // 5: doki.realtime.v1.ServerShutdown
+	(*ChatMessage)(nil),               // 6: doki.realtime.v1.ChatMessage
+	(*TypingStatus)(nil),              // 7: doki.realtime.v1.TypingStatus
+	(*EditMessage)(nil),               // 8: doki.realtime.v1.EditMessage
+	(*DeleteMessage)(nil),             // 9: doki.realtime.v1.DeleteMessage
+	(*UserSendFriendRequest)(nil),     // 10: doki.realtime.v1.UserSendFriendRequest
+	(*UserAcceptedFriendRequest)(nil), // 11: doki.realtime.v1.UserAcceptedFriendRequest
+	(*UserRemovesFriendRelation)(nil), // 12: doki.realtime.v1.UserRemovesFriendRelation
+	(*UserUpdateProfile)(nil),         // 13: doki.realtime.v1.UserUpdateProfile
+	(*UserCreateRootNode)(nil),        // 14: doki.realtime.v1.UserCreateRootNode
+	(*ParentNode)(nil),                // 15: doki.realtime.v1.ParentNode
+	(*UserNodeLikeAction)(nil),        // 16: doki.realtime.v1.UserNodeLikeAction
+	(*UserCreateSecondaryNode)(nil),   // 17: doki.realtime.v1.UserCreateSecondaryNode
+	(*UserPresenceSubscription)(nil),  // 18: doki.realtime.v1.UserPresenceSubscription
+	(*UserPresenceInfo)(nil),          // 19: doki.realtime.v1.UserPresenceInfo
+	(*PollSubscription)(nil),          // 20: doki.realtime.v1.PollSubscription
+	(*PollVotesUpdate)(nil),           // 21: doki.realtime.v1.PollVotesUpdate
+	(*timestamppb.Timestamp)(nil),     // 22: google.protobuf.Timestamp
+}
+var file_realtime_proto_depIdxs = []int32{
+	2,  // 0: doki.realtime.v1.Envelope.hello:type_name -> doki.realtime.v1.Hello
+	4,  // 1: doki.realtime.v1.Envelope.welcome:type_name -> doki.realtime.v1.Welcome
+	5,  // 2: doki.realtime.v1.Envelope.server_shutdown:type_name -> doki.realtime.v1.ServerShutdown
+	6,  // 3: doki.realtime.v1.Envelope.chat_message:type_name -> doki.realtime.v1.ChatMessage
+	7,  // 4: doki.realtime.v1.Envelope.typing_status:type_name -> doki.realtime.v1.TypingStatus
+	8,  // 5: doki.realtime.v1.Envelope.edit_message:type_name -> doki.realtime.v1.EditMessage
+	9,  // 6: doki.realtime.v1.Envelope.delete_message:type_name -> doki.realtime.v1.DeleteMessage
+	10, // 7: doki.realtime.v1.Envelope.user_send_friend_request:type_name -> doki.realtime.v1.UserSendFriendRequest
+	11, // 8: doki.realtime.v1.Envelope.user_accepted_friend_request:type_name -> doki.realtime.v1.UserAcceptedFriendRequest
+	12, // 9: doki.realtime.v1.Envelope.user_removes_friend_relation:type_name -> doki.realtime.v1.UserRemovesFriendRelation
+	13, // 10: doki.realtime.v1.Envelope.user_update_profile:type_name -> doki.realtime.v1.UserUpdateProfile
+	14, // 11: doki.realtime.v1.Envelope.user_create_root_node:type_name -> doki.realtime.v1.UserCreateRootNode
+	16, // 12: doki.realtime.v1.Envelope.user_node_like_action:type_name -> doki.realtime.v1.UserNodeLikeAction
+	17, // 13: doki.realtime.v1.Envelope.user_create_secondary_node:type_name -> doki.realtime.v1.UserCreateSecondaryNode
+	18, // 14: doki.realtime.v1.Envelope.user_presence_subscription:type_name -> doki.realtime.v1.UserPresenceSubscription
+	19, // 15: doki.realtime.v1.Envelope.user_presence_info:type_name -> doki.realtime.v1.UserPresenceInfo
+	20, // 16: doki.realtime.v1.Envelope.poll_subscription:type_name -> doki.realtime.v1.PollSubscription
+	21, // 17: doki.realtime.v1.Envelope.poll_votes_update:type_name -> doki.realtime.v1.PollVotesUpdate
+	1,  // 18: doki.realtime.v1.Envelope.meta:type_name -> doki.realtime.v1.Metadata
+	3,  // 19: doki.realtime.v1.Welcome.limits:type_name -> doki.realtime.v1.WelcomeLimits
+	22, // 20: doki.realtime.v1.ChatMessage.send_at:type_name -> google.protobuf.Timestamp
+	22, // 21: doki.realtime.v1.EditMessage.edited_on:type_name -> google.protobuf.Timestamp
+	22, // 22: doki.realtime.v1.UserSendFriendRequest.added_on:type_name -> google.protobuf.Timestamp
+	22, // 23: doki.realtime.v1.UserAcceptedFriendRequest.added_on:type_name -> google.protobuf.Timestamp
+	15, // 24: doki.realtime.v1.UserNodeLikeAction.parents:type_name -> doki.realtime.v1.ParentNode
+	15, // 25: doki.realtime.v1.UserCreateSecondaryNode.parents:type_name -> doki.realtime.v1.ParentNode
+	0,  // 26: doki.realtime.v1.Realtime.Connect:input_type -> doki.realtime.v1.Envelope
+	0,  // 27: doki.realtime.v1.Realtime.Connect:output_type -> doki.realtime.v1.Envelope
+	27, // [27:28] is the sub-list for method output_type
+	26, // [26:27] is the sub-list for method input_type
+	26, // [26:26] is the sub-list for extension type_name
+	26, // [26:26] is the sub-list for extension extendee
+	0,  // [0:26] is the sub-list for field type_name
+}
+
+func init() { file_realtime_proto_init() }
+func file_realtime_proto_init() {
+	if File_realtime_proto != nil {
+		return
+	}
+	file_realtime_proto_msgTypes[0].OneofWrappers = []any{
+		(*Envelope_Hello)(nil),
+		(*Envelope_Welcome)(nil),
+		(*Envelope_ServerShutdown)(nil),
+		(*Envelope_ChatMessage)(nil),
+		(*Envelope_TypingStatus)(nil),
+		(*Envelope_EditMessage)(nil),
+		(*Envelope_DeleteMessage)(nil),
+		(*Envelope_UserSendFriendRequest)(nil),
+		(*Envelope_UserAcceptedFriendRequest)(nil),
+		(*Envelope_UserRemovesFriendRelation)(nil),
+		(*Envelope_UserUpdateProfile)(nil),
+		(*Envelope_UserCreateRootNode)(nil),
+		(*Envelope_UserNodeLikeAction)(nil),
+		(*Envelope_UserCreateSecondaryNode)(nil),
+		(*Envelope_UserPresenceSubscription)(nil),
+		(*Envelope_UserPresenceInfo)(nil),
+		(*Envelope_PollSubscription)(nil),
+		(*Envelope_PollVotesUpdate)(nil),
+		(*Envelope_Json)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_realtime_proto_rawDesc), len(file_realtime_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   22,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_realtime_proto_goTypes,
+		DependencyIndexes: file_realtime_proto_depIdxs,
+		MessageInfos:      file_realtime_proto_msgTypes,
+	}.Build()
+	File_realtime_proto = out.File
+	file_realtime_proto_goTypes = nil
+	file_realtime_proto_depIdxs = nil
+}
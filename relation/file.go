@@ -0,0 +1,168 @@
+package relation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// fileStore keeps relations in memory and writes all of them to a json file on every change
+//
+// file can be exported by the backend before start so that existing friends are known from the first message
+type fileStore struct {
+	*memoryStore
+	path string
+
+	// saveLock keeps a change and its save together so that files are written in the order of changes
+	saveLock sync.Mutex
+}
+
+// fileContent is the json file
+//
+// friends is user and sorted list of friends, a friendship may be listed under either user or both
+// friendRequests is requester and sorted list of users who got the request
+// requests is user, sender of the message request and its status
+type fileContent struct {
+	Friends        map[string][]string                 `json:"friends"`
+	FriendRequests map[string][]string                 `json:"friendRequests"`
+	Requests       map[string]map[string]RequestStatus `json:"requests"`
+}
+
+// OpenFileStore returns store loaded from and persisted to the json file at path, file is created on first change
+func OpenFileStore(path string) (Store, error) {
+	store := &fileStore{
+		memoryStore: NewMemoryStore().(*memoryStore),
+		path:        path,
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading relations file: %w", err)
+	}
+
+	var content fileContent
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, fmt.Errorf("error parsing relations file %v: %w", path, err)
+	}
+
+	for user, friends := range content.Friends {
+		for _, friend := range friends {
+			store.friends[friendPair(user, friend)] = true
+		}
+	}
+	for requester, users := range content.FriendRequests {
+		for _, user := range users {
+			store.friendRequests[pair{user: requester, other: user}] = true
+		}
+	}
+	for user, senders := range content.Requests {
+		for from, status := range senders {
+			switch status {
+			case RequestPending, RequestAccepted, RequestDeclined:
+				store.requests[pair{user: user, other: from}] = status
+			default:
+				return nil, fmt.Errorf("error parsing relations file %v: unknown request status %q", path, status)
+			}
+		}
+	}
+
+	return store, nil
+}
+
+func (s *fileStore) AddFriendRequest(requester, user string) error {
+	s.saveLock.Lock()
+	defer s.saveLock.Unlock()
+
+	_ = s.memoryStore.AddFriendRequest(requester, user)
+	return s.save()
+}
+
+func (s *fileStore) AcceptFriendRequest(user, requester string) (bool, error) {
+	s.saveLock.Lock()
+	defer s.saveLock.Unlock()
+
+	accepted, _ := s.memoryStore.AcceptFriendRequest(user, requester)
+	if !accepted {
+		return false, nil
+	}
+
+	return true, s.save()
+}
+
+func (s *fileStore) RemoveFriends(user, other string) error {
+	s.saveLock.Lock()
+	defer s.saveLock.Unlock()
+
+	_ = s.memoryStore.RemoveFriends(user, other)
+	return s.save()
+}
+
+func (s *fileStore) SetRequestStatus(user, from string, status RequestStatus) error {
+	s.saveLock.Lock()
+	defer s.saveLock.Unlock()
+
+	_ = s.memoryStore.SetRequestStatus(user, from, status)
+	return s.save()
+}
+
+// save writes the file through a temporary file so that a crash never leaves a partial file
+func (s *fileStore) save() error {
+	s.RLock()
+	content := fileContent{
+		Friends:        sortedPairs(s.friends),
+		FriendRequests: sortedPairs(s.friendRequests),
+		Requests:       make(map[string]map[string]RequestStatus),
+	}
+	for request, status := range s.requests {
+		if content.Requests[request.user] == nil {
+			content.Requests[request.user] = make(map[string]RequestStatus)
+		}
+		content.Requests[request.user][request.other] = status
+	}
+	s.RUnlock()
+
+	data, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("error writing relations file: %w", err)
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(data); err != nil {
+		_ = temp.Close()
+		return fmt.Errorf("error writing relations file: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("error writing relations file: %w", err)
+	}
+
+	if err := os.Rename(temp.Name(), s.path); err != nil {
+		return fmt.Errorf("error writing relations file: %w", err)
+	}
+
+	return nil
+}
+
+// sortedPairs returns first user of every pair with sorted list of the other users
+func sortedPairs(pairs map[pair]bool) map[string][]string {
+	sorted := make(map[string][]string)
+	for p := range pairs {
+		sorted[p.user] = append(sorted[p.user], p.other)
+	}
+	for _, others := range sorted {
+		slices.Sort(others)
+	}
+
+	return sorted
+}
@@ -0,0 +1,58 @@
+package relation
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStoreIsPersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "relations.json")
+
+	// friends exported by the backend are known before any friend request is seen
+	require.NoError(t, os.WriteFile(path, []byte(`{"friends":{"bob":["alice"]},"requests":{"bob":{"carol":"declined"}}}`), 0o600))
+
+	store, err := OpenFileStore(path)
+	require.NoError(t, err)
+	assert.True(t, store.AreFriends("alice", "bob"))
+	assert.Equal(t, RequestDeclined, store.RequestStatus("bob", "carol"))
+
+	require.NoError(t, store.AddFriendRequest("dave", "bob"))
+	accepted, err := store.AcceptFriendRequest("bob", "dave")
+	require.NoError(t, err)
+	assert.True(t, accepted)
+	require.NoError(t, store.AddFriendRequest("erin", "bob"))
+	require.NoError(t, store.SetRequestStatus("bob", "carol", RequestNone))
+	require.NoError(t, store.SetRequestStatus("alice", "erin", RequestAccepted))
+
+	reopened, err := OpenFileStore(path)
+	require.NoError(t, err)
+	assert.True(t, reopened.AreFriends("bob", "alice"))
+	assert.True(t, reopened.AreFriends("bob", "dave"))
+	assert.False(t, reopened.AreFriends("bob", "erin"))
+	assert.Equal(t, RequestNone, reopened.RequestStatus("bob", "carol"))
+	assert.Equal(t, RequestAccepted, reopened.RequestStatus("alice", "erin"))
+
+	accepted, err = reopened.AcceptFriendRequest("bob", "erin")
+	require.NoError(t, err)
+	assert.True(t, accepted)
+	require.NoError(t, reopened.RemoveFriends("alice", "bob"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"friends":{"bob":["dave","erin"]},"friendRequests":{},"requests":{"alice":{"erin":"accepted"}}}`, string(data))
+}
+
+func TestOpenFileStoreRejectsInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "relations.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o600))
+
+	_, err := OpenFileStore(path)
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"requests":{"bob":{"alice":"maybe"}}}`), 0o600))
+	_, err = OpenFileStore(path)
+	assert.Error(t, err)
+}
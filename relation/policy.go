@@ -0,0 +1,69 @@
+package relation
+
+// Decision is how a direct message reaches the recipient
+type Decision int
+
+const (
+	// Allow delivers the message
+	Allow Decision = iota
+
+	// Request delivers the message as message request which the recipient must accept
+	Request
+
+	// Reject drops the message
+	Reject
+)
+
+// Policy decides whether sender can send direct messages to recipient
+type Policy interface {
+	Evaluate(sender, recipient string) Decision
+}
+
+// PolicyFunc lets a function be used as policy
+type PolicyFunc func(sender, recipient string) Decision
+
+func (f PolicyFunc) Evaluate(sender, recipient string) Decision {
+	return f(sender, recipient)
+}
+
+// Anyone allows direct messages between any users
+func Anyone() Policy {
+	return PolicyFunc(func(string, string) Decision {
+		return Allow
+	})
+}
+
+// FriendsOnly allows direct messages between friends and rejects the rest
+func FriendsOnly(store Store) Policy {
+	return PolicyFunc(func(sender, recipient string) Decision {
+		if store.AreFriends(sender, recipient) {
+			return Allow
+		}
+
+		return Reject
+	})
+}
+
+// FriendsAndRequests allows direct messages between friends and users who accepted message request of
+// each other, messages from others are message requests until the recipient accepts or declines them
+func FriendsAndRequests(store Store) Policy {
+	return PolicyFunc(func(sender, recipient string) Decision {
+		if store.AreFriends(sender, recipient) {
+			return Allow
+		}
+
+		// conversation accepted by either user is open both ways
+		if store.RequestStatus(sender, recipient) == RequestAccepted {
+			return Allow
+		}
+
+		switch store.RequestStatus(recipient, sender) {
+		case RequestAccepted:
+			return Allow
+		case RequestDeclined:
+			return Reject
+		default:
+			return Request
+		}
+	})
+}
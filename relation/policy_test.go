@@ -0,0 +1,48 @@
+package relation
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFriendsOnly(t *testing.T) {
+	store := NewMemoryStore()
+	policy := FriendsOnly(store)
+	assert.Equal(t, Reject, policy.Evaluate("alice", "bob"))
+
+	// accepting a friend request which was never sent doesn't make friends
+	accepted, err := store.AcceptFriendRequest("bob", "alice")
+	require.NoError(t, err)
+	assert.False(t, accepted)
+	assert.Equal(t, Reject, policy.Evaluate("alice", "bob"))
+
+	require.NoError(t, store.AddFriendRequest("alice", "bob"))
+	accepted, err = store.AcceptFriendRequest("bob", "alice")
+	require.NoError(t, err)
+	assert.True(t, accepted)
+	assert.Equal(t, Allow, policy.Evaluate("alice", "bob"))
+	assert.Equal(t, Allow, policy.Evaluate("bob", "alice"))
+
+	require.NoError(t, store.RemoveFriends("alice", "bob"))
+	assert.Equal(t, Reject, policy.Evaluate("bob", "alice"))
+}
+
+func TestFriendsAndRequests(t *testing.T) {
+	store := NewMemoryStore()
+	policy := FriendsAndRequests(store)
+	assert.Equal(t, Request, policy.Evaluate("alice", "bob"))
+
+	require.NoError(t, store.SetRequestStatus("bob", "alice", RequestPending))
+	assert.Equal(t, Request, policy.Evaluate("alice", "bob"))
+
+	require.NoError(t, store.SetRequestStatus("bob", "alice", RequestAccepted))
+	assert.Equal(t, Allow, policy.Evaluate("alice", "bob"))
+	assert.Equal(t, Allow, policy.Evaluate("bob", "alice"))
+
+	require.NoError(t, store.SetRequestStatus("bob", "alice", RequestDeclined))
+	assert.Equal(t, Reject, policy.Evaluate("alice", "bob"))
+	assert.Equal(t, Request, policy.Evaluate("bob", "alice"))
+
+	assert.Equal(t, Allow, Anyone().Evaluate("alice", "bob"))
+}
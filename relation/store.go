@@ -0,0 +1,129 @@
+package relation
+
+import (
+	"sync"
+)
+
+// RequestStatus is the state of messages from a user who is not a friend
+type RequestStatus string
+
+const (
+	RequestNone     RequestStatus = ""
+	RequestPending  RequestStatus = "pending"
+	RequestAccepted RequestStatus = "accepted"
+	RequestDeclined RequestStatus = "declined"
+)
+
+// Store keeps friend relations and message requests between users
+type Store interface {
+	// AddFriendRequest records friend request sent by requester to user
+	AddFriendRequest(requester, user string) error
+
+	// AcceptFriendRequest makes user and requester friends if requester has sent a friend request to user
+	// it returns false when there is no such friend request
+	AcceptFriendRequest(user, requester string) (bool, error)
+
+	// RemoveFriends removes friend relation and pending friend requests between the users
+	RemoveFriends(user, other string) error
+
+	AreFriends(user, other string) bool
+
+	// RequestStatus returns state of the message request sent by from to user
+	RequestStatus(user, from string) RequestStatus
+
+	SetRequestStatus(user, from string, status RequestStatus) error
+}
+
+// pair is an ordered pair of usernames
+type pair struct {
+	user  string
+	other string
+}
+
+// friendPair is same for both orders of the users
+func friendPair(user, other string) pair {
+	if user > other {
+		user, other = other, user
+	}
+
+	return pair{user: user, other: other}
+}
+
+// memoryStore keeps relations in memory, it is lost on restart
+type memoryStore struct {
+	sync.RWMutex
+
+	// friendRequests is requester and user who got the request
+	friendRequests map[pair]bool
+	friends        map[pair]bool
+
+	// requests is user and the sender of message request
+	requests map[pair]RequestStatus
+}
+
+// NewMemoryStore returns store which keeps relations in memory only
+func NewMemoryStore() Store {
+	return &memoryStore{
+		friendRequests: make(map[pair]bool),
+		friends:        make(map[pair]bool),
+		requests:       make(map[pair]RequestStatus),
+	}
+}
+
+func (s *memoryStore) AddFriendRequest(requester, user string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.friendRequests[pair{user: requester, other: user}] = true
+	return nil
+}
+
+func (s *memoryStore) AcceptFriendRequest(user, requester string) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	request := pair{user: requester, other: user}
+	if !s.friendRequests[request] {
+		return false, nil
+	}
+
+	delete(s.friendRequests, request)
+	s.friends[friendPair(user, requester)] = true
+	return true, nil
+}
+
+func (s *memoryStore) RemoveFriends(user, other string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.friends, friendPair(user, other))
+	delete(s.friendRequests, pair{user: user, other: other})
+	delete(s.friendRequests, pair{user: other, other: user})
+	return nil
+}
+
+func (s *memoryStore) AreFriends(user, other string) bool {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.friends[friendPair(user, other)]
+}
+
+func (s *memoryStore) RequestStatus(user, from string) RequestStatus {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.requests[pair{user: user, other: from}]
+}
+
+func (s *memoryStore) SetRequestStatus(user, from string, status RequestStatus) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if status == RequestNone {
+		delete(s.requests, pair{user: user, other: from})
+	} else {
+		s.requests[pair{user: user, other: from}] = status
+	}
+	return nil
+}
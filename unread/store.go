@@ -0,0 +1,88 @@
+package unread
+
+import (
+	"sync"
+)
+
+// Counts is unread count of a conversation and total of every conversation of the user
+type Counts struct {
+	Conversation string `json:"conversation"`
+	Unread       int    `json:"unread"`
+	Total        int    `json:"total"`
+}
+
+// Store keeps unread message counts of every user by conversation
+// conversation of a direct chat is the username of the other user
+type Store interface {
+	// Increment counts a message delivered to user in the conversation
+	Increment(user, conversation string) (Counts, error)
+
+	// Reset marks every message of the conversation read
+	// it returns false when the conversation had no unread message
+	Reset(user, conversation string) (Counts, bool, error)
+
+	// Unread returns unread count of every conversation with unread messages
+	Unread(user string) map[string]int
+}
+
+// memoryStore keeps counts in memory, they are lost on restart
+type memoryStore struct {
+	sync.Mutex
+
+	// unread is user -> conversation -> count, conversations without unread messages are removed
+	unread map[string]map[string]int
+
+	// totals is the sum of the counts of every user
+	totals map[string]int
+}
+
+// NewMemoryStore returns store which keeps counts in memory only
+func NewMemoryStore() Store {
+	return &memoryStore{
+		unread: make(map[string]map[string]int),
+		totals: make(map[string]int),
+	}
+}
+
+func (s *memoryStore) Increment(user, conversation string) (Counts, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.unread[user] == nil {
+		s.unread[user] = make(map[string]int)
+	}
+	s.unread[user][conversation]++
+	s.totals[user]++
+
+	return Counts{Conversation: conversation, Unread: s.unread[user][conversation], Total: s.totals[user]}, nil
+}
+
+func (s *memoryStore) Reset(user, conversation string) (Counts, bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	count := s.unread[user][conversation]
+	if count == 0 {
+		return Counts{Conversation: conversation, Total: s.totals[user]}, false, nil
+	}
+
+	delete(s.unread[user], conversation)
+	s.totals[user] -= count
+	if len(s.unread[user]) == 0 {
+		delete(s.unread, user)
+		delete(s.totals, user)
+	}
+
+	return Counts{Conversation: conversation, Total: s.totals[user]}, true, nil
+}
+
+func (s *memoryStore) Unread(user string) map[string]int {
+	s.Lock()
+	defer s.Unlock()
+
+	unread := make(map[string]int, len(s.unread[user]))
+	for conversation, count := range s.unread[user] {
+		unread[conversation] = count
+	}
+	return unread
+}
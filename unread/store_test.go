@@ -0,0 +1,33 @@
+package unread
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+
+	counts, err := store.Increment("bob", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, Counts{Conversation: "alice", Unread: 1, Total: 1}, counts)
+
+	store.Increment("bob", "alice")
+	counts, _ = store.Increment("bob", "carol")
+	assert.Equal(t, Counts{Conversation: "carol", Unread: 1, Total: 3}, counts)
+	assert.Equal(t, map[string]int{"alice": 2, "carol": 1}, store.Unread("bob"))
+	assert.Empty(t, store.Unread("alice"))
+
+	counts, reset, err := store.Reset("bob", "alice")
+	require.NoError(t, err)
+	assert.True(t, reset)
+	assert.Equal(t, Counts{Conversation: "alice", Total: 1}, counts)
+
+	_, reset, _ = store.Reset("bob", "alice")
+	assert.False(t, reset)
+
+	store.Reset("bob", "carol")
+	assert.Empty(t, store.Unread("bob"))
+}
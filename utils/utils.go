@@ -2,9 +2,11 @@ package utils
 
 import (
 	"crypto/rand"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 )
 
 // GetUsernameAndResourceFromUser returns given user, username and resource
@@ -36,6 +38,19 @@ func RandomString() string {
 	return fmt.Sprintf("%x", b)[2 : length+2]
 }
 
+// NewEventId returns time ordered unique id in uuid version 7 format
+func NewEventId() string {
+	var id [16]byte
+	binary.BigEndian.PutUint64(id[:8], uint64(time.Now().UnixMilli())<<16)
+	_, _ = rand.Read(id[6:])
+
+	// version and variant bits
+	id[6] = id[6]&0x0f | 0x70
+	id[8] = id[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:])
+}
+
 // PayloadToJson converts given payload to json bytes
 func PayloadToJson(payload any) *[]byte {
 	jsonBytes, err := json.Marshal(payload)
@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// deadLetter appends deliveries that failed all attempts to a json lines file
+// so that they can be replayed once the endpoint is fixed
+type deadLetter struct {
+	sync.Mutex
+	file *os.File
+}
+
+// deadLetterEntry is a single line of the dead letter file
+type deadLetterEntry struct {
+	Url      string          `json:"url"`
+	Type     string          `json:"type"`
+	Attempts int             `json:"attempts"`
+	Error    string          `json:"error"`
+	FailedAt time.Time       `json:"failedAt"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+func openDeadLetter(path string) (*deadLetter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening webhook dead letter file: %w", err)
+	}
+
+	return &deadLetter{file: file}, nil
+}
+
+func (l *deadLetter) write(job delivery, attempts int, cause error) error {
+	entry := deadLetterEntry{
+		Url:      job.endpoint.url,
+		Type:     job.payloadType,
+		Attempts: attempts,
+		FailedAt: time.Now().UTC(),
+		Payload:  job.body,
+	}
+	if cause != nil {
+		entry.Error = cause.Error()
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	l.Lock()
+	defer l.Unlock()
+	_, err = l.file.Write(append(line, '\n'))
+	return err
+}
+
+func (l *deadLetter) close() error {
+	l.Lock()
+	defer l.Unlock()
+
+	return l.file.Close()
+}
@@ -0,0 +1,274 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"doki.co.in/doki_real_time_service/config"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// headers of every delivery, receivers verify SignatureHeader using Sign
+const (
+	EventHeader     = "X-Doki-Event"
+	TimestampHeader = "X-Doki-Timestamp"
+	SignatureHeader = "X-Doki-Signature"
+)
+
+// Sign returns hmac sha256 of "timestamp.body" as sent in SignatureHeader
+// timestamp is part of the signature so that old deliveries can't be replayed
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// endpoint is a configured url with the secret used to sign its bodies
+type endpoint struct {
+	url    string
+	secret string
+}
+
+// delivery is a payload waiting to be sent to an endpoint
+type delivery struct {
+	endpoint    endpoint
+	payloadType string
+	body        []byte
+}
+
+// Dispatcher sends payloads to webhook endpoints in background
+// payloads are queued without waiting so that routing is never slowed down by the endpoints
+type Dispatcher struct {
+	client *http.Client
+
+	// endpoints by payload type
+	endpoints map[string][]endpoint
+
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+
+	queue      chan delivery
+	deadLetter *deadLetter
+
+	// closing stops the queue and ctx is cancelled when close gives up waiting for retries
+	closing sync.RWMutex
+	closed  bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+
+	// dropped counts deliveries dropped because queue was full
+	dropped atomic.Int64
+}
+
+// NewDispatcher starts workers sending payloads to the configured endpoints
+func NewDispatcher(webhookConfig config.WebhookConfig) (*Dispatcher, error) {
+	var deadLetter *deadLetter
+	if webhookConfig.DeadLetterFile != "" {
+		var err error
+		if deadLetter, err = openDeadLetter(webhookConfig.DeadLetterFile); err != nil {
+			return nil, err
+		}
+	}
+
+	d := &Dispatcher{
+		client:         &http.Client{Timeout: time.Duration(webhookConfig.Timeout)},
+		endpoints:      make(map[string][]endpoint),
+		maxAttempts:    webhookConfig.MaxAttempts,
+		initialBackoff: time.Duration(webhookConfig.InitialBackoff),
+		maxBackoff:     time.Duration(webhookConfig.MaxBackoff),
+		queue:          make(chan delivery, webhookConfig.QueueSize),
+		deadLetter:     deadLetter,
+	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+
+	for _, configured := range webhookConfig.Endpoints {
+		e := endpoint{url: configured.Url, secret: configured.Secret}
+		if e.secret == "" {
+			e.secret = webhookConfig.Secret
+		}
+		for _, payloadType := range configured.Types {
+			d.endpoints[payloadType] = append(d.endpoints[payloadType], e)
+		}
+	}
+
+	for range webhookConfig.Workers {
+		d.workers.Add(1)
+		go d.work()
+	}
+
+	return d, nil
+}
+
+// Dispatch queues the json payload for endpoints of its type
+// it never blocks, payload is dropped when the queue is full
+func (d *Dispatcher) Dispatch(data []byte) {
+	if len(d.endpoints) == 0 {
+		return
+	}
+
+	var base struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &base); err != nil {
+		return
+	}
+	endpoints := d.endpoints[base.Type]
+	if len(endpoints) == 0 {
+		return
+	}
+
+	d.closing.RLock()
+	defer d.closing.RUnlock()
+	if d.closed {
+		return
+	}
+
+	for _, e := range endpoints {
+		select {
+		case d.queue <- delivery{endpoint: e, payloadType: base.Type, body: data}:
+		default:
+			if d.dropped.Add(1)%100 == 1 {
+				log.Printf("error queueing webhook: queue is full, %v dropped so far\n", d.dropped.Load())
+			}
+		}
+	}
+}
+
+// Dropped returns number of deliveries dropped because queue was full
+func (d *Dispatcher) Dropped() int64 {
+	return d.dropped.Load()
+}
+
+// Close stops accepting payloads and waits for the queued ones to be sent
+// deliveries still being retried when ctx is done go to the dead letter file
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.closing.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.closing.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.workers.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		d.cancel()
+		<-done
+		err = ctx.Err()
+	}
+
+	if d.deadLetter != nil {
+		err = errors.Join(err, d.deadLetter.close())
+	}
+	return err
+}
+
+func (d *Dispatcher) work() {
+	defer d.workers.Done()
+
+	for job := range d.queue {
+		d.deliver(job)
+	}
+}
+
+// deliver sends the payload until it succeeds or runs out of attempts
+func (d *Dispatcher) deliver(job delivery) {
+	var err error
+	attempts := 0
+	for attempts < d.maxAttempts {
+		if attempts > 0 && !d.wait(d.backoff(attempts)) {
+			break
+		}
+
+		attempts++
+		var retry bool
+		if retry, err = d.send(job); err == nil {
+			return
+		}
+		if !retry {
+			break
+		}
+	}
+
+	log.Printf("error sending webhook %v to %v after %d attempts: %v\n", job.payloadType, job.endpoint.url, attempts, err)
+	if d.deadLetter != nil {
+		if err := d.deadLetter.write(job, attempts, err); err != nil {
+			log.Printf("error writing webhook dead letter: %v\n", err)
+		}
+	}
+}
+
+// backoff returns the wait before the next attempt, it doubles after every failed attempt
+func (d *Dispatcher) backoff(failed int) time.Duration {
+	backoff := d.initialBackoff
+	for i := 1; i < failed && backoff < d.maxBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, d.maxBackoff)
+}
+
+// wait returns false if dispatcher gave up on retries before the wait was over
+func (d *Dispatcher) wait(duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-d.ctx.Done():
+		return false
+	}
+}
+
+// send makes a single attempt, it returns whether a failed attempt is worth retrying
+func (d *Dispatcher) send(job delivery) (bool, error) {
+	request, err := http.NewRequestWithContext(d.ctx, http.MethodPost, job.endpoint.url, bytes.NewReader(job.body))
+	if err != nil {
+		return false, err
+	}
+
+	timestamp := time.Now().Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EventHeader, job.payloadType)
+	request.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(SignatureHeader, Sign(job.endpoint.secret, timestamp, job.body))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return d.ctx.Err() == nil, err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 4096))
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+
+	// other client errors won't succeed on retry
+	retry := response.StatusCode >= 500 || response.StatusCode == http.StatusTooManyRequests || response.StatusCode == http.StatusRequestTimeout
+	return retry, fmt.Errorf("webhook failed with status %d", response.StatusCode)
+}
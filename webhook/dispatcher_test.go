@@ -0,0 +1,193 @@
+package webhook
+
+import (
+	"bufio"
+	"context"
+	"doki.co.in/doki_real_time_service/config"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestConfig(urls ...string) config.WebhookConfig {
+	webhookConfig := config.Default().Webhooks
+	webhookConfig.Secret = "secret"
+	webhookConfig.InitialBackoff = config.Duration(time.Millisecond)
+	webhookConfig.MaxBackoff = config.Duration(4 * time.Millisecond)
+	for _, url := range urls {
+		webhookConfig.Endpoints = append(webhookConfig.Endpoints, config.WebhookEndpoint{Url: url, Types: []string{"chat_message"}})
+	}
+
+	return webhookConfig
+}
+
+// readDeadLetters returns entries of the dead letter file
+func readDeadLetters(t *testing.T, path string) []deadLetterEntry {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var entries []deadLetterEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry deadLetterEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func TestDispatchSigned(t *testing.T) {
+	received := make(chan *http.Request, 10)
+	bodies := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	d, err := NewDispatcher(createTestConfig(server.URL))
+	require.NoError(t, err)
+
+	d.Dispatch([]byte(`{"type":"typing_status","from":"alice","to":"bob"}`))
+	d.Dispatch([]byte(`{"type":"chat_message","from":"alice","to":"bob","body":"hi"}`))
+	require.NoError(t, d.Close(context.Background()))
+
+	require.Len(t, received, 1)
+	request, body := <-received, <-bodies
+	assert.JSONEq(t, `{"type":"chat_message","from":"alice","to":"bob","body":"hi"}`, string(body))
+	assert.Equal(t, "chat_message", request.Header.Get(EventHeader))
+
+	timestamp, err := strconv.ParseInt(request.Header.Get(TimestampHeader), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, Sign("secret", timestamp, body), request.Header.Get(SignatureHeader))
+	assert.NotEqual(t, Sign("other", timestamp, body), request.Header.Get(SignatureHeader))
+}
+
+func TestDispatchRetry(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	webhookConfig := createTestConfig(server.URL)
+	webhookConfig.DeadLetterFile = filepath.Join(t.TempDir(), "dead.jsonl")
+	d, err := NewDispatcher(webhookConfig)
+	require.NoError(t, err)
+
+	d.Dispatch([]byte(`{"type":"chat_message","from":"alice","to":"bob"}`))
+	require.NoError(t, d.Close(context.Background()))
+
+	assert.EqualValues(t, 3, attempts.Load())
+	assert.Empty(t, readDeadLetters(t, webhookConfig.DeadLetterFile))
+}
+
+func TestDispatchDeadLetter(t *testing.T) {
+	var failing, rejecting atomic.Int32
+	failingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failing.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failingServer.Close()
+	rejectingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rejecting.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer rejectingServer.Close()
+
+	webhookConfig := createTestConfig(failingServer.URL, rejectingServer.URL)
+	webhookConfig.MaxAttempts = 3
+	webhookConfig.DeadLetterFile = filepath.Join(t.TempDir(), "dead.jsonl")
+	d, err := NewDispatcher(webhookConfig)
+	require.NoError(t, err)
+
+	d.Dispatch([]byte(`{"type":"chat_message","from":"alice","to":"bob"}`))
+	require.NoError(t, d.Close(context.Background()))
+
+	// client errors are not retried
+	assert.EqualValues(t, 3, failing.Load())
+	assert.EqualValues(t, 1, rejecting.Load())
+
+	entries := readDeadLetters(t, webhookConfig.DeadLetterFile)
+	require.Len(t, entries, 2)
+	attempts := map[string]int{}
+	for _, entry := range entries {
+		attempts[entry.Url] = entry.Attempts
+		assert.Equal(t, "chat_message", entry.Type)
+		assert.JSONEq(t, `{"type":"chat_message","from":"alice","to":"bob"}`, string(entry.Payload))
+	}
+	assert.Equal(t, map[string]int{failingServer.URL: 3, rejectingServer.URL: 1}, attempts)
+}
+
+func TestDispatchQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	var delivered atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		delivered.Add(1)
+	}))
+	defer server.Close()
+
+	webhookConfig := createTestConfig(server.URL)
+	webhookConfig.QueueSize = 2
+	webhookConfig.Workers = 1
+	d, err := NewDispatcher(webhookConfig)
+	require.NoError(t, err)
+
+	// first one is taken by the worker and waits on the server, next two fill the queue
+	d.Dispatch([]byte(`{"type":"chat_message","from":"alice","to":"bob"}`))
+	require.Eventually(t, func() bool { return len(d.queue) == 0 }, time.Second, time.Millisecond)
+
+	start := time.Now()
+	for range 5 {
+		d.Dispatch([]byte(`{"type":"chat_message","from":"alice","to":"bob"}`))
+	}
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+	assert.EqualValues(t, 3, d.Dropped())
+
+	close(release)
+	require.NoError(t, d.Close(context.Background()))
+	assert.EqualValues(t, 3, delivered.Load())
+}
+
+func TestCloseTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	webhookConfig := createTestConfig(server.URL)
+	webhookConfig.InitialBackoff = config.Duration(time.Hour)
+	webhookConfig.MaxBackoff = config.Duration(time.Hour)
+	webhookConfig.DeadLetterFile = filepath.Join(t.TempDir(), "dead.jsonl")
+	d, err := NewDispatcher(webhookConfig)
+	require.NoError(t, err)
+
+	d.Dispatch([]byte(`{"type":"chat_message","from":"alice","to":"bob"}`))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, d.Close(ctx), context.DeadlineExceeded)
+
+	entries := readDeadLetters(t, webhookConfig.DeadLetterFile)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 1, entries[0].Attempts)
+
+	// payloads after close are ignored
+	d.Dispatch([]byte(`{"type":"chat_message","from":"alice","to":"bob"}`))
+}